@@ -0,0 +1,681 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/layer5io/meshkit/models/patterns"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ComposeFile is a Docker Compose file, see https://docs.docker.com/reference/compose-file/
+type ComposeFile struct {
+	Name     string                     `yaml:"name,omitempty"`
+	Services map[string]*ComposeService `yaml:"services"`
+	Volumes  map[string]*ComposeVolume  `yaml:"volumes,omitempty"`
+	Configs  map[string]*ComposeConfig  `yaml:"configs,omitempty"`
+	Secrets  map[string]*ComposeSecret  `yaml:"secrets,omitempty"`
+}
+
+type ComposeService struct {
+	Image       string                 `yaml:"image,omitempty"`
+	Entrypoint  []string               `yaml:"entrypoint,omitempty"`
+	Command     []string               `yaml:"command,omitempty"`
+	WorkingDir  string                 `yaml:"working_dir,omitempty"`
+	Environment map[string]string      `yaml:"environment,omitempty"`
+	Ports       []string               `yaml:"ports,omitempty"`
+	Expose      []string               `yaml:"expose,omitempty"`
+	Volumes     []string               `yaml:"volumes,omitempty"`
+	Configs     []ComposeServiceConfig `yaml:"configs,omitempty"`
+	Secrets     []ComposeServiceConfig `yaml:"secrets,omitempty"`
+	Healthcheck *ComposeHealthcheck    `yaml:"healthcheck,omitempty"`
+	DependsOn   []string               `yaml:"depends_on,omitempty"`
+	Deploy      *ComposeDeploy         `yaml:"deploy,omitempty"`
+}
+
+type ComposeServiceConfig struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target,omitempty"`
+}
+
+type ComposeHealthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int32    `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+type ComposeDeploy struct {
+	Replicas *int32 `yaml:"replicas,omitempty"`
+}
+
+type ComposeVolume struct{}
+
+type ComposeConfig struct {
+	Content string `yaml:"content"`
+}
+
+// ComposeSecret is a secret whose value is read, when the compose file is run, from the environment variable or the file.
+type ComposeSecret struct {
+	Environment string `yaml:"environment,omitempty"`
+	File        string `yaml:"file,omitempty"`
+}
+
+/*
+ComposeConverter converts a design into a Docker Compose file, so that it can be run locally without a cluster.
+Every container of the Deployments and StatefulSets becomes a service, Services publish the ports of the containers they select,
+ConfigMaps are resolved into the environment and configs of the services, and PersistentVolumeClaims become named volumes.
+The values of Secrets are never written to the file: every key of a Secret becomes a secret read from an environment variable
+when the file is run, mounted into the services and interpolated into their environment.
+Components which cannot be translated are reported as comments at the top of the file.
+*/
+type ComposeConverter struct{}
+
+func (c *ComposeConverter) Convert(patternFile string) (string, error) {
+	pattern, err := patterns.GetPatternFormat(patternFile)
+	if err != nil {
+		return "", err
+	}
+
+	patterns.ProcessAnnotations(pattern)
+	compose, warnings, err := NewComposeFromPatternfile(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBufferString("")
+	for _, warning := range warnings {
+		fmt.Fprintf(buf, "# Warning: %s\n", warning)
+	}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(compose); err != nil {
+		return "", ErrCreateCompose(err, pattern.Name)
+	}
+	return buf.String(), nil
+}
+
+// composeWorkload is a Deployment or StatefulSet of the design.
+type composeWorkload struct {
+	id       string
+	name     string
+	replicas *int32
+	labels   map[string]string
+	spec     corev1.PodSpec
+	// services are the names of the compose services of the containers
+	services []string
+}
+
+type composeDesign struct {
+	workloads  []*composeWorkload
+	services   []*corev1.Service
+	configMaps map[string]*corev1.ConfigMap
+	secrets    map[string]*corev1.Secret
+	// serviceIDs are the ids of the Service components, by name
+	serviceIDs map[string]string
+	warnings   []string
+}
+
+func (d *composeDesign) warn(format string, args ...interface{}) {
+	d.warnings = append(d.warnings, fmt.Sprintf(format, args...))
+}
+
+/*
+NewComposeFromPatternfile translates the design into a compose file.
+It returns the components, or the parts of them, which cannot be translated as warnings.
+*/
+func NewComposeFromPatternfile(patternFile *pattern.PatternFile) (*ComposeFile, []string, error) {
+	d := &composeDesign{configMaps: map[string]*corev1.ConfigMap{}, secrets: map[string]*corev1.Secret{}, serviceIDs: map[string]string{}}
+	compose := &ComposeFile{
+		Name:     sanitizeName(patternFile.Name),
+		Services: map[string]*ComposeService{},
+		Volumes:  map[string]*ComposeVolume{},
+		Configs:  map[string]*ComposeConfig{},
+		Secrets:  map[string]*ComposeSecret{},
+	}
+
+	for _, comp := range patternFile.Components {
+		if err := d.add(comp, compose); err != nil {
+			return nil, nil, ErrCreateCompose(err, patternFile.Name)
+		}
+	}
+
+	for _, w := range d.workloads {
+		d.addWorkload(w, compose)
+	}
+	for _, svc := range d.services {
+		d.publish(svc, compose)
+	}
+	d.addDependencies(patternFile.Relationships, compose)
+
+	return compose, d.warnings, nil
+}
+
+// add decodes the component into the kubernetes resource it describes.
+func (d *composeDesign) add(comp *component.ComponentDefinition, compose *ComposeFile) error {
+	data, err := json.Marshal(CreateK8sResourceStructure(comp))
+	if err != nil {
+		return err
+	}
+	id := comp.Id.String()
+	switch comp.Component.Kind {
+	case "Deployment":
+		deployment := appsv1.Deployment{}
+		if err := json.Unmarshal(data, &deployment); err != nil {
+			return err
+		}
+		d.workloads = append(d.workloads, &composeWorkload{id: id, name: comp.DisplayName, replicas: deployment.Spec.Replicas, labels: deployment.Spec.Template.Labels, spec: deployment.Spec.Template.Spec})
+	case "StatefulSet":
+		statefulSet := appsv1.StatefulSet{}
+		if err := json.Unmarshal(data, &statefulSet); err != nil {
+			return err
+		}
+		d.workloads = append(d.workloads, &composeWorkload{id: id, name: comp.DisplayName, replicas: statefulSet.Spec.Replicas, labels: statefulSet.Spec.Template.Labels, spec: statefulSet.Spec.Template.Spec})
+		for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+			compose.Volumes[sanitizeName(claim.Name)] = &ComposeVolume{}
+		}
+	case "Service":
+		service := corev1.Service{}
+		if err := json.Unmarshal(data, &service); err != nil {
+			return err
+		}
+		d.services = append(d.services, &service)
+		d.serviceIDs[service.Name] = id
+	case "ConfigMap":
+		configMap := corev1.ConfigMap{}
+		if err := json.Unmarshal(data, &configMap); err != nil {
+			return err
+		}
+		d.configMaps[configMap.Name] = &configMap
+	case "Secret":
+		secret := corev1.Secret{}
+		if err := json.Unmarshal(data, &secret); err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		for k, v := range secret.StringData {
+			secret.Data[k] = []byte(v)
+		}
+		d.secrets[secret.Name] = &secret
+	case "PersistentVolumeClaim":
+		compose.Volumes[sanitizeName(comp.DisplayName)] = &ComposeVolume{}
+	default:
+		d.warn("%s %s cannot be translated to Docker Compose", comp.Component.Kind, comp.DisplayName)
+	}
+	return nil
+}
+
+// addWorkload adds a service for every container of the workload.
+func (d *composeDesign) addWorkload(w *composeWorkload, compose *ComposeFile) {
+	if len(w.spec.InitContainers) > 0 {
+		d.warn("init containers of %s are not translated", w.name)
+	}
+	for _, container := range w.spec.Containers {
+		name := w.name
+		if len(w.spec.Containers) > 1 {
+			name = fmt.Sprintf("%s-%s", w.name, container.Name)
+		}
+		name = uniqueName(name, func(n string) bool { _, ok := compose.Services[n]; return ok })
+		w.services = append(w.services, name)
+
+		service := &ComposeService{
+			Image:       container.Image,
+			Entrypoint:  container.Command,
+			Command:     container.Args,
+			WorkingDir:  container.WorkingDir,
+			Healthcheck: healthcheck(container),
+		}
+		service.Environment = d.environment(w.name, container, service, compose)
+		if w.replicas != nil && *w.replicas != 1 {
+			service.Deploy = &ComposeDeploy{Replicas: w.replicas}
+		}
+		for _, port := range container.Ports {
+			service.Expose = append(service.Expose, composePort(port.ContainerPort, port.Protocol))
+		}
+		d.mount(w, container, service, compose)
+		compose.Services[name] = service
+	}
+}
+
+/*
+environment resolves the environment of the container, references to ConfigMaps and Secrets are resolved against the design.
+Keys of Secrets are mounted into the service as secrets and interpolated from the environment the compose file is run in.
+*/
+func (d *composeDesign) environment(workload string, container corev1.Container, service *ComposeService, compose *ComposeFile) map[string]string {
+	env := map[string]string{}
+	for _, from := range container.EnvFrom {
+		var values map[string]string
+		switch {
+		case from.ConfigMapRef != nil:
+			values = d.configMapData(workload, from.ConfigMapRef.Name, from.ConfigMapRef.Optional)
+		case from.SecretRef != nil:
+			values = map[string]string{}
+			for _, k := range d.secretKeys(workload, from.SecretRef.Name, from.SecretRef.Optional) {
+				values[k] = d.secretEnvironment(from.SecretRef.Name, k, service, compose)
+			}
+		}
+		for k, v := range values {
+			env[from.Prefix+k] = v
+		}
+	}
+	for _, e := range container.Env {
+		switch {
+		case e.ValueFrom == nil:
+			env[e.Name] = e.Value
+		case e.ValueFrom.ConfigMapKeyRef != nil:
+			ref := e.ValueFrom.ConfigMapKeyRef
+			if v, ok := d.configMapData(workload, ref.Name, ref.Optional)[ref.Key]; ok {
+				env[e.Name] = v
+			}
+		case e.ValueFrom.SecretKeyRef != nil:
+			ref := e.ValueFrom.SecretKeyRef
+			for _, k := range d.secretKeys(workload, ref.Name, ref.Optional) {
+				if k == ref.Key {
+					env[e.Name] = d.secretEnvironment(ref.Name, k, service, compose)
+				}
+			}
+		default:
+			d.warn("environment variable %s of %s references a field of the pod which is not available in Docker Compose", e.Name, workload)
+		}
+	}
+	return env
+}
+
+func (d *composeDesign) configMapData(workload, name string, optional *bool) map[string]string {
+	configMap, ok := d.configMaps[name]
+	if !ok {
+		if optional == nil || !*optional {
+			d.warn("ConfigMap %s referenced by %s is not part of the design", name, workload)
+		}
+		return nil
+	}
+	data := map[string]string{}
+	for k, v := range configMap.Data {
+		data[k] = v
+	}
+	for k, v := range configMap.BinaryData {
+		data[k] = string(v)
+	}
+	return data
+}
+
+// secretKeys returns the keys of the Secret, sorted. The values of Secrets are not used, see composeSecret.
+func (d *composeDesign) secretKeys(workload, name string, optional *bool) []string {
+	secret, ok := d.secrets[name]
+	if !ok {
+		if optional == nil || !*optional {
+			d.warn("Secret %s referenced by %s is not part of the design", name, workload)
+		}
+		return nil
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+/*
+composeSecret declares the secret holding the key of the Secret and returns its name.
+Its value is read from an environment variable named after the Secret and the key, e.g. DB_CREDENTIALS_PASSWORD,
+so that the value of the Secret is not written to the compose file.
+*/
+func (d *composeDesign) composeSecret(secret, key string, compose *ComposeFile) string {
+	name := sanitizeName(fmt.Sprintf("%s-%s", secret, key))
+	if _, ok := compose.Secrets[name]; !ok {
+		variable := strings.Trim(invalidEnvChars.ReplaceAllString(strings.ToUpper(secret+"_"+key), "_"), "_")
+		compose.Secrets[name] = &ComposeSecret{Environment: variable}
+		d.warn("the value of key %s of Secret %s is not written to the compose file, it is read from the environment variable %s", key, secret, variable)
+	}
+	return name
+}
+
+// mountSecret mounts the secret into the service at the target, the default target of compose is used if it is empty.
+func mountSecret(name, target string, service *ComposeService) {
+	for _, s := range service.Secrets {
+		if s.Source == name && (target == "" || s.Target == target) {
+			return
+		}
+	}
+	service.Secrets = append(service.Secrets, ComposeServiceConfig{Source: name, Target: target})
+}
+
+// secretEnvironment mounts the key of the Secret into the service and returns the value of an environment variable holding it.
+func (d *composeDesign) secretEnvironment(secret, key string, service *ComposeService, compose *ComposeFile) string {
+	name := d.composeSecret(secret, key, compose)
+	mountSecret(name, "", service)
+	return "${" + compose.Secrets[name].Environment + "}"
+}
+
+/*
+mount translates the volume mounts of the container.
+PersistentVolumeClaims become named volumes, hostPath volumes bind mounts, emptyDirs anonymous volumes,
+and the keys of ConfigMaps and Secrets become configs mounted as files.
+*/
+func (d *composeDesign) mount(w *composeWorkload, container corev1.Container, service *ComposeService, compose *ComposeFile) {
+	volumes := map[string]corev1.Volume{}
+	for _, v := range w.spec.Volumes {
+		volumes[v.Name] = v
+	}
+	claimTemplates := map[string]bool{}
+	for name := range compose.Volumes {
+		claimTemplates[name] = true
+	}
+
+	for _, m := range container.VolumeMounts {
+		mode := ""
+		if m.ReadOnly {
+			mode = ":ro"
+		}
+		volume, ok := volumes[m.Name]
+		switch {
+		case !ok:
+			// StatefulSets mount their volume claim templates by name
+			if claimTemplates[sanitizeName(m.Name)] {
+				service.Volumes = append(service.Volumes, fmt.Sprintf("%s:%s%s", sanitizeName(m.Name), m.MountPath, mode))
+			} else {
+				d.warn("volume %s mounted by %s is not defined", m.Name, w.name)
+			}
+		case volume.PersistentVolumeClaim != nil:
+			name := sanitizeName(volume.PersistentVolumeClaim.ClaimName)
+			if _, ok := compose.Volumes[name]; !ok {
+				d.warn("PersistentVolumeClaim %s mounted by %s is not part of the design, a new volume is created", volume.PersistentVolumeClaim.ClaimName, w.name)
+				compose.Volumes[name] = &ComposeVolume{}
+			}
+			service.Volumes = append(service.Volumes, fmt.Sprintf("%s:%s%s", name, m.MountPath, mode))
+		case volume.HostPath != nil:
+			service.Volumes = append(service.Volumes, fmt.Sprintf("%s:%s%s", volume.HostPath.Path, m.MountPath, mode))
+		case volume.EmptyDir != nil:
+			service.Volumes = append(service.Volumes, m.MountPath)
+		case volume.ConfigMap != nil:
+			data := d.configMapData(w.name, volume.ConfigMap.Name, volume.ConfigMap.Optional)
+			keys := make([]string, 0, len(data))
+			for k := range data {
+				keys = append(keys, k)
+			}
+			for _, f := range mountedFiles(keys, volume.ConfigMap.Items, m) {
+				name := sanitizeName(fmt.Sprintf("%s-%s", volume.ConfigMap.Name, f.key))
+				compose.Configs[name] = &ComposeConfig{Content: data[f.key]}
+				service.Configs = append(service.Configs, ComposeServiceConfig{Source: name, Target: f.target})
+			}
+		case volume.Secret != nil:
+			keys := d.secretKeys(w.name, volume.Secret.SecretName, volume.Secret.Optional)
+			for _, f := range mountedFiles(keys, volume.Secret.Items, m) {
+				mountSecret(d.composeSecret(volume.Secret.SecretName, f.key, compose), f.target, service)
+			}
+		default:
+			d.warn("volume %s of %s cannot be translated to Docker Compose", m.Name, w.name)
+		}
+	}
+}
+
+type mountedFile struct {
+	key, target string
+}
+
+// mountedFiles returns the keys of the ConfigMap or Secret mounted by the volume mount, and the path the pod would see them at.
+func mountedFiles(keys []string, items []corev1.KeyToPath, m corev1.VolumeMount) []mountedFile {
+	available := map[string]bool{}
+	for _, k := range keys {
+		available[k] = true
+	}
+	paths := map[string]string{}
+	if len(items) > 0 {
+		for _, item := range items {
+			paths[item.Key] = item.Path
+		}
+	} else {
+		for _, k := range keys {
+			paths[k] = k
+		}
+	}
+	mounted := make([]string, 0, len(paths))
+	for k := range paths {
+		mounted = append(mounted, k)
+	}
+	sort.Strings(mounted)
+
+	files := []mountedFile{}
+	for _, k := range mounted {
+		if !available[k] {
+			continue
+		}
+		target := path.Join(m.MountPath, paths[k])
+		if m.SubPath != "" {
+			if m.SubPath != paths[k] {
+				continue
+			}
+			target = m.MountPath
+		}
+		files = append(files, mountedFile{key: k, target: target})
+	}
+	return files
+}
+
+// healthcheck translates the readiness probe of the container, or its liveness probe if it has none.
+func healthcheck(container corev1.Container) *ComposeHealthcheck {
+	probe := container.ReadinessProbe
+	if probe == nil {
+		probe = container.LivenessProbe
+	}
+	if probe == nil {
+		return nil
+	}
+
+	hc := &ComposeHealthcheck{}
+	switch {
+	case probe.Exec != nil:
+		hc.Test = append([]string{"CMD"}, probe.Exec.Command...)
+	case probe.HTTPGet != nil:
+		scheme := strings.ToLower(string(probe.HTTPGet.Scheme))
+		if scheme == "" {
+			scheme = "http"
+		}
+		port := containerPort(container, probe.HTTPGet.Port)
+		hc.Test = []string{"CMD-SHELL", fmt.Sprintf("curl -fsk %s://localhost:%d%s || exit 1", scheme, port, probe.HTTPGet.Path)}
+	case probe.TCPSocket != nil:
+		hc.Test = []string{"CMD-SHELL", fmt.Sprintf("nc -z localhost %d || exit 1", containerPort(container, probe.TCPSocket.Port))}
+	default:
+		return nil
+	}
+	if probe.PeriodSeconds > 0 {
+		hc.Interval = fmt.Sprintf("%ds", probe.PeriodSeconds)
+	}
+	if probe.TimeoutSeconds > 0 {
+		hc.Timeout = fmt.Sprintf("%ds", probe.TimeoutSeconds)
+	}
+	if probe.InitialDelaySeconds > 0 {
+		hc.StartPeriod = fmt.Sprintf("%ds", probe.InitialDelaySeconds)
+	}
+	hc.Retries = probe.FailureThreshold
+	return hc
+}
+
+// containerPort resolves a port, possibly referenced by name, of the container. It returns 0 for unknown names.
+func containerPort(container corev1.Container, port intstr.IntOrString) int32 {
+	if port.Type == intstr.Int {
+		return port.IntVal
+	}
+	for _, p := range container.Ports {
+		if p.Name == port.StrVal {
+			return p.ContainerPort
+		}
+	}
+	if n, err := strconv.Atoi(port.StrVal); err == nil {
+		return int32(n)
+	}
+	return 0
+}
+
+func composePort(port int32, protocol corev1.Protocol) string {
+	if protocol == corev1.ProtocolUDP {
+		return fmt.Sprintf("%d/udp", port)
+	}
+	return strconv.Itoa(int(port))
+}
+
+// selected returns the workloads selected by the Service.
+func (d *composeDesign) selected(svc *corev1.Service) []*composeWorkload {
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	workloads := []*composeWorkload{}
+	for _, w := range d.workloads {
+		if selector.Matches(labels.Set(w.labels)) {
+			workloads = append(workloads, w)
+		}
+	}
+	return workloads
+}
+
+/*
+publish publishes the ports of the Service on the host, on the service of the container exposing the target port.
+Ports which are not declared by any container are published on the first container.
+*/
+func (d *composeDesign) publish(svc *corev1.Service, compose *ComposeFile) {
+	workloads := d.selected(svc)
+	if len(workloads) == 0 {
+		d.warn("Service %s does not select any Deployment or StatefulSet of the design", svc.Name)
+		return
+	}
+	if len(workloads) > 1 {
+		d.warn("Service %s selects several workloads, its ports are published by %s only", svc.Name, workloads[0].name)
+	}
+	w := workloads[0]
+	if len(w.spec.Containers) == 0 {
+		return
+	}
+	if w.replicas != nil && *w.replicas > 1 && len(svc.Spec.Ports) > 0 {
+		d.warn("ports published by Service %s can only be bound by one of the %d replicas of %s", svc.Name, *w.replicas, w.name)
+	}
+	for _, port := range svc.Spec.Ports {
+		target := port.TargetPort
+		if target.Type == intstr.Int && target.IntVal == 0 {
+			target = intstr.FromInt(int(port.Port))
+		}
+		index, targetPort := 0, containerPort(w.spec.Containers[0], target)
+		for i, container := range w.spec.Containers {
+			if p := containerPort(container, target); p != 0 && exposes(container, p) {
+				index, targetPort = i, p
+				break
+			}
+		}
+		if targetPort == 0 {
+			d.warn("target port %s of Service %s is not declared by %s", target.String(), svc.Name, w.name)
+			continue
+		}
+		service := compose.Services[w.services[index]]
+		service.Ports = append(service.Ports, fmt.Sprintf("%d:%s", port.Port, composePort(targetPort, port.Protocol)))
+	}
+}
+
+func exposes(container corev1.Container, port int32) bool {
+	for _, p := range container.Ports {
+		if p.ContainerPort == port {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+addDependencies derives depends_on from the relationships of the design:
+the services of the components of the from selectors depend on the services of the components of the to selectors.
+Services are resolved to the workloads they select.
+*/
+func (d *composeDesign) addDependencies(relationships []*relationship.RelationshipDefinition, compose *ComposeFile) {
+	servicesByID := map[string][]string{}
+	for _, w := range d.workloads {
+		servicesByID[w.id] = append(servicesByID[w.id], w.services...)
+	}
+	for _, svc := range d.services {
+		for _, w := range d.selected(svc) {
+			servicesByID[d.serviceIDs[svc.Name]] = append(servicesByID[d.serviceIDs[svc.Name]], w.services...)
+		}
+	}
+
+	resolve := func(items []relationship.SelectorItem) []string {
+		services := []string{}
+		for _, item := range items {
+			if item.Id != nil {
+				services = append(services, servicesByID[item.Id.String()]...)
+			}
+		}
+		return services
+	}
+
+	dependencies := map[string]map[string]bool{}
+	for _, rel := range relationships {
+		if rel == nil || rel.Selectors == nil {
+			continue
+		}
+		if rel.Status != nil && (*rel.Status == relationship.Deleted || *rel.Status == relationship.Ignored) {
+			continue
+		}
+		for _, set := range *rel.Selectors {
+			for _, from := range resolve(set.Allow.From) {
+				for _, to := range resolve(set.Allow.To) {
+					if from == to || dependencies[from][to] {
+						continue
+					}
+					// compose refuses cyclic dependencies
+					if dependsOn(dependencies, to, from) {
+						d.warn("dependency of %s on %s is omitted as it is cyclic", from, to)
+						continue
+					}
+					if dependencies[from] == nil {
+						dependencies[from] = map[string]bool{}
+					}
+					dependencies[from][to] = true
+				}
+			}
+		}
+	}
+	for name, deps := range dependencies {
+		for dep := range deps {
+			compose.Services[name].DependsOn = append(compose.Services[name].DependsOn, dep)
+		}
+		sort.Strings(compose.Services[name].DependsOn)
+	}
+}
+
+// dependsOn reports whether the service depends, directly or transitively, on the other service.
+func dependsOn(dependencies map[string]map[string]bool, service, other string) bool {
+	visited := map[string]bool{}
+	pending := []string{service}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if current == other {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		for dep := range dependencies[current] {
+			pending = append(pending, dep)
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package converter
+
+import (
+	"fmt"
+
+	"github.com/layer5io/meshkit/errors"
+)
+
+const (
+	ErrCreateHelmChartCode     = "replace_me"
+	ErrPackageHelmChartCode    = "replace_me"
+	ErrCreateComposeCode       = "replace_me"
+	ErrCreateKustomizationCode = "replace_me"
+)
+
+func ErrCreateHelmChart(err error, designName string) error {
+	return errors.New(
+		ErrCreateHelmChartCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Failed to create a Helm chart from design %s", designName)},
+		[]string{err.Error()},
+		[]string{"The configuration of a component cannot be encoded as YAML", "The design name or version cannot be used as chart name or version"},
+		[]string{"Make sure the configuration of the components is valid", "Rename the design or set a semver version"},
+	)
+}
+
+func ErrPackageHelmChart(err error, chartName string) error {
+	return errors.New(
+		ErrPackageHelmChartCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Failed to package Helm chart %s", chartName)},
+		[]string{err.Error()},
+		[]string{"The temporary directory is not writable"},
+		[]string{"Make sure the temporary directory exists and is writable"},
+	)
+}
+
+func ErrCreateCompose(err error, designName string) error {
+	return errors.New(
+		ErrCreateComposeCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Failed to create a Docker Compose file from design %s", designName)},
+		[]string{err.Error()},
+		[]string{"The configuration of a Deployment, StatefulSet, Service, ConfigMap or Secret does not match its Kubernetes schema"},
+		[]string{"Make sure the configuration of the components is valid"},
+	)
+}
+
+func ErrCreateKustomization(err error, designName string) error {
+	return errors.New(
+		ErrCreateKustomizationCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Failed to create a kustomization from design %s", designName)},
+		[]string{err.Error()},
+		[]string{"The configuration of a component cannot be encoded as YAML", "Environment names are empty or not unique"},
+		[]string{"Make sure the configuration of the components is valid", "Give every environment a unique name"},
+	)
+}
@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/layer5io/meshkit/models/patterns"
+	"github.com/layer5io/meshkit/utils"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// DefaultHelmChartVersion is the version of charts generated from designs without a valid semver version.
+const DefaultHelmChartVersion = "0.0.1"
+
+// invalidNameChars matches the characters which are not allowed in chart, template, values and compose service names.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// metadataValues are the fields of the component metadata which are configurable through the values of the chart.
+var metadataValues = []string{"namespace", "labels", "annotations"}
+
+/*
+HelmConverter converts a design into a packaged Helm chart, the returned string holds the .tgz archive.
+Every component is rendered by its own template, the configuration of the components is extracted into values.yaml
+under components.<name>-<kind>, so that it can be overridden at install time.
+*/
+type HelmConverter struct{}
+
+func (h *HelmConverter) Convert(patternFile string) (string, error) {
+	pattern, err := patterns.GetPatternFormat(patternFile)
+	if err != nil {
+		return "", err
+	}
+
+	patterns.ProcessAnnotations(pattern)
+	c, err := NewHelmChartFromPatternfile(pattern)
+	if err != nil {
+		return "", err
+	}
+	archive, err := PackageHelmChart(c)
+	if err != nil {
+		return "", err
+	}
+	return string(archive), nil
+}
+
+// NewHelmChartFromPatternfile creates a chart, named and versioned after the design, which installs the components of the design.
+func NewHelmChartFromPatternfile(patternFile *pattern.PatternFile) (*chart.Chart, error) {
+	name := sanitizeName(patternFile.Name)
+	if name == "" {
+		name = "design"
+	}
+	version := DefaultHelmChartVersion
+	if v, err := semver.NewVersion(patternFile.Version); err == nil {
+		version = v.String()
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion:  chart.APIVersionV2,
+			Name:        name,
+			Version:     version,
+			AppVersion:  patternFile.Version,
+			Description: fmt.Sprintf("Helm chart generated from the Meshery design %s", patternFile.Name),
+			Type:        "application",
+			Annotations: map[string]string{
+				"meshery.io/design-id": patternFile.Id.String(),
+			},
+		},
+	}
+
+	componentValues := map[string]interface{}{}
+	for _, comp := range patternFile.Components {
+		key := uniqueName(fmt.Sprintf("%s-%s", comp.DisplayName, comp.Component.Kind), func(n string) bool { _, ok := componentValues[n]; return ok })
+		values, template := helmTemplate(key, comp)
+		componentValues[key] = values
+		c.Templates = append(c.Templates, &chart.File{Name: fmt.Sprintf("templates/%s.yaml", key), Data: template})
+	}
+	c.Values = map[string]interface{}{"components": componentValues}
+
+	buf := bytes.NewBufferString("")
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Values); err != nil {
+		return nil, ErrCreateHelmChart(err, patternFile.Name)
+	}
+	c.Raw = []*chart.File{{Name: chartutil.ValuesfileName, Data: buf.Bytes()}}
+
+	if err := c.Validate(); err != nil {
+		return nil, ErrCreateHelmChart(err, patternFile.Name)
+	}
+	return c, nil
+}
+
+// PackageHelmChart returns the chart packaged as by `helm package`.
+func PackageHelmChart(c *chart.Chart) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "meshery-helm-chart")
+	if err != nil {
+		return nil, ErrPackageHelmChart(err, c.Name())
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := chartutil.Save(c, dir)
+	if err != nil {
+		return nil, ErrPackageHelmChart(err, c.Name())
+	}
+	archive, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrPackageHelmChart(err, c.Name())
+	}
+	return archive, nil
+}
+
+/*
+helmTemplate returns the values and the template of the component.
+The values hold the configuration of the component and can disable the component. The name, apiVersion and kind
+are values too, rather than text of the template, so that they are never evaluated by Helm as template actions.
+*/
+func helmTemplate(key string, comp *component.ComponentDefinition) (map[string]interface{}, []byte) {
+	resource := CreateK8sResourceStructure(comp)
+	values := map[string]interface{}{"enabled": true, "name": comp.DisplayName}
+	for k, v := range resource {
+		if k != "metadata" {
+			values[k] = v
+		}
+	}
+
+	// CreateK8sResourceStructure only keeps the name, labels and annotations of the metadata
+	metadata := map[string]interface{}{}
+	if _confMetadata, ok := comp.Configuration["metadata"]; ok {
+		if confMetadata, err := utils.Cast[map[string]interface{}](_confMetadata); err == nil {
+			for k, v := range confMetadata {
+				if k != "name" {
+					metadata[k] = v
+				}
+			}
+		}
+	}
+	for _, k := range metadataValues {
+		if v, ok := metadata[k]; ok {
+			if m, isMap := v.(map[string]interface{}); !isMap || len(m) > 0 {
+				values[k] = v
+			}
+			delete(metadata, k)
+		}
+	}
+	if len(metadata) > 0 {
+		values["metadata"] = metadata
+	}
+
+	var tpl strings.Builder
+	fmt.Fprintf(&tpl, "{{- $c := index .Values.components %q }}\n", key)
+	tpl.WriteString("{{- if $c.enabled }}\n")
+	tpl.WriteString("apiVersion: {{ $c.apiVersion | quote }}\nkind: {{ $c.kind | quote }}\nmetadata:\n  name: {{ $c.name | quote }}\n")
+	tpl.WriteString("  {{- with $c.namespace }}\n  namespace: {{ . }}\n  {{- end }}\n")
+	tpl.WriteString("  {{- with $c.labels }}\n  labels:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\n")
+	tpl.WriteString("  {{- with $c.annotations }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\n")
+	tpl.WriteString("  {{- with $c.metadata }}\n  {{- toYaml . | nindent 2 }}\n  {{- end }}\n")
+	tpl.WriteString("{{- with omit $c \"enabled\" \"name\" \"apiVersion\" \"kind\" \"namespace\" \"labels\" \"annotations\" \"metadata\" }}\n{{ toYaml . }}\n{{- end }}\n")
+	tpl.WriteString("{{- end }}\n")
+	return values, []byte(tpl.String())
+}
+
+// sanitizeName lowercases the name and replaces the characters which are not allowed in chart and compose names with dashes.
+func sanitizeName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// uniqueName sanitizes the name and appends a number to it if it is taken.
+func uniqueName(name string, taken func(string) bool) string {
+	name = sanitizeName(name)
+	if name == "" {
+		name = "component"
+	}
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	return unique
+}
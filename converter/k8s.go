@@ -2,6 +2,9 @@ package converter
 
 import (
 	"bytes"
+	"fmt"
+	"path"
+	"sort"
 
 	"github.com/layer5io/meshkit/models/patterns"
 	"github.com/layer5io/meshkit/utils"
@@ -10,7 +13,10 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-type K8sConverter struct{}
+type K8sConverter struct {
+	// SplitFiles writes every resource to its own file, the returned string then holds a tar archive of the files, see NewK8sManifestFilesFromPatternfile.
+	SplitFiles bool
+}
 
 func (k *K8sConverter) Convert(patternFile string) (string, error) {
 	pattern, err := patterns.GetPatternFormat(patternFile)
@@ -19,15 +25,63 @@ func (k *K8sConverter) Convert(patternFile string) (string, error) {
 	}
 
 	patterns.ProcessAnnotations(pattern)
-	return NewK8sManifestsFromPatternfile(pattern)
+	if !k.SplitFiles {
+		return NewK8sManifestsFromPatternfile(pattern)
+	}
+	files, err := NewK8sManifestFilesFromPatternfile(pattern)
+	if err != nil {
+		return "", err
+	}
+	archive, err := writeTar(files)
+	if err != nil {
+		return "", err
+	}
+	return string(archive), nil
+}
+
+// resourceOrder is the order resources are applied in, kinds which are not listed, i.e. custom resources, are applied last.
+var resourceOrder = [][]string{
+	{"CustomResourceDefinition"},
+	{"Namespace"},
+	{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"},
+	{"PriorityClass", "NetworkPolicy", "ResourceQuota", "LimitRange", "StorageClass", "PersistentVolume", "PersistentVolumeClaim", "ConfigMap", "Secret"},
+	{"Pod", "ReplicationController", "ReplicaSet", "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob"},
+	{"Service", "IngressClass", "Ingress", "HorizontalPodAutoscaler", "PodDisruptionBudget", "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration", "APIService"},
+}
+
+var resourceRank = func() map[string]int {
+	rank := map[string]int{}
+	for i, kinds := range resourceOrder {
+		for _, kind := range kinds {
+			rank[kind] = i
+		}
+	}
+	return rank
+}()
+
+func kindRank(kind string) int {
+	if rank, ok := resourceRank[kind]; ok {
+		return rank
+	}
+	return len(resourceOrder)
+}
+
+// sortByDependency returns the components in the order they can be applied in, components of the same group keep their order in the design.
+func sortByDependency(components []*component.ComponentDefinition) []*component.ComponentDefinition {
+	sorted := append([]*component.ComponentDefinition{}, components...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return kindRank(sorted[i].Component.Kind) < kindRank(sorted[j].Component.Kind)
+	})
+	return sorted
 }
 
+// NewK8sManifestsFromPatternfile returns the resources of the design as multi-document YAML, in the order they can be applied in.
 func NewK8sManifestsFromPatternfile(patternFile *pattern.PatternFile) (string, error) {
 
 	buf := bytes.NewBufferString("")
 
 	enc := yaml.NewEncoder(buf)
-	for _, comp := range patternFile.Components {
+	for _, comp := range sortByDependency(patternFile.Components) {
 		err := enc.Encode(CreateK8sResourceStructure(comp))
 		if err != nil {
 			return "", err
@@ -36,9 +90,39 @@ func NewK8sManifestsFromPatternfile(patternFile *pattern.PatternFile) (string, e
 	return buf.String(), nil
 }
 
+/*
+NewK8sManifestFilesFromPatternfile returns a file per resource of the design, laid out as <namespace>/<index>-<kind>-<name>.yaml,
+resources without a namespace are at the top level. The index keeps the files in the order they can be applied in.
+*/
+func NewK8sManifestFilesFromPatternfile(patternFile *pattern.PatternFile) ([]archiveFile, error) {
+	components := sortByDependency(patternFile.Components)
+	width := len(fmt.Sprint(len(components)))
+	files := []archiveFile{}
+	for i, comp := range components {
+		resource := CreateK8sResourceStructure(comp)
+		data, err := yaml.Marshal(resource)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%0*d-%s.yaml", width, i+1, sanitizeName(fmt.Sprintf("%s-%s", comp.Component.Kind, comp.DisplayName)))
+		if ns := resourceNamespace(resource); ns != "" {
+			name = path.Join(sanitizeName(ns), name)
+		}
+		files = append(files, archiveFile{name: name, data: data})
+	}
+	return files, nil
+}
+
+func resourceNamespace(resource map[string]interface{}) string {
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	ns, _ := metadata["namespace"].(string)
+	return ns
+}
+
 func CreateK8sResourceStructure(comp *component.ComponentDefinition) map[string]interface{} {
 	annotations := map[string]interface{}{}
 	labels := map[string]interface{}{}
+	namespace := ""
 
 	_confMetadata, ok := comp.Configuration["metadata"]
 	if ok {
@@ -55,17 +139,24 @@ func CreateK8sResourceStructure(comp *component.ComponentDefinition) map[string]
 			if ok {
 				labels, _ = utils.Cast[map[string]interface{}](_label)
 			}
+
+			namespace, _ = confMetadata["namespace"].(string)
 		}
 	}
 
+	metadata := map[string]interface{}{
+		"name":        comp.DisplayName,
+		"annotations": annotations,
+		"labels":      labels,
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+
 	component := map[string]interface{}{
 		"apiVersion": comp.Component.Version,
 		"kind":       comp.Component.Kind,
-		"metadata": map[string]interface{}{
-			"name":        comp.DisplayName,
-			"annotations": annotations,
-			"labels":      labels,
-		},
+		"metadata":   metadata,
 	}
 
 	for k, v := range comp.Configuration {
@@ -0,0 +1,291 @@
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/layer5io/meshkit/models/patterns"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	kustomizationAPIVersion = "kustomize.config.k8s.io/v1beta1"
+	kustomizationFileName   = "kustomization.yaml"
+	kustomizeBaseDir        = "base"
+	kustomizeOverlaysDir    = "overlays"
+	kustomizeResourcesFile  = "resources.yaml"
+)
+
+// KustomizeEnvironment is an environment the design is deployed to, it becomes an overlay of the base.
+type KustomizeEnvironment struct {
+	Name string `json:"name" yaml:"name"`
+	// Namespace sets the namespace of all resources in the environment, if not empty.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Configurations are the complete configurations of the components in the environment, by component id or display name.
+	// Only the fields which differ from the configuration in the design are patched, fields missing from it are removed.
+	Configurations map[string]map[string]interface{} `json:"configurations,omitempty" yaml:"configurations,omitempty"`
+}
+
+/*
+KustomizeConverter converts a design into a kustomize base, holding the manifests of the design, and an overlay per environment,
+the returned string holds the tar archive:
+
+	base/kustomization.yaml
+	base/resources.yaml
+	overlays/<environment>/kustomization.yaml
+	overlays/<environment>/patches/<kind>-<name>.yaml
+
+If Environments is empty, the environments are the parameter profiles of the design, see KustomizeEnvironmentsFromProfiles.
+*/
+type KustomizeConverter struct {
+	Environments []KustomizeEnvironment
+}
+
+func (k *KustomizeConverter) Convert(patternFile string) (string, error) {
+	pattern, err := patterns.GetPatternFormat(patternFile)
+	if err != nil {
+		return "", err
+	}
+
+	environments := k.Environments
+	if len(environments) == 0 {
+		pattern, environments, err = KustomizeEnvironmentsFromProfiles(pattern)
+		if err != nil {
+			return "", err
+		}
+	}
+	patterns.ProcessAnnotations(pattern)
+	archive, err := NewKustomizationFromPatternfile(pattern, environments)
+	if err != nil {
+		return "", err
+	}
+	return string(archive), nil
+}
+
+/*
+KustomizeEnvironmentsFromProfiles returns the design with the default values of its parameters, to be used as the base,
+and an environment for every parameter profile of the design, configuring the components with the values of the profile.
+Designs without parameters are returned as they are, without environments.
+*/
+func KustomizeEnvironmentsFromProfiles(design *pattern.PatternFile) (*pattern.PatternFile, []KustomizeEnvironment, error) {
+	params, err := patterns.GetParameters(design)
+	if err != nil || params == nil {
+		return design, nil, err
+	}
+	base, err := patterns.ResolveParameters(design, "", nil)
+	if err != nil {
+		return nil, nil, ErrCreateKustomization(err, design.Name)
+	}
+
+	profiles := make([]string, 0, len(params.Profiles))
+	for profile := range params.Profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	environments := make([]KustomizeEnvironment, 0, len(profiles))
+	for _, profile := range profiles {
+		resolved, err := patterns.ResolveParameters(design, profile, nil)
+		if err != nil {
+			return nil, nil, ErrCreateKustomization(err, design.Name)
+		}
+		env := KustomizeEnvironment{Name: profile, Configurations: map[string]map[string]interface{}{}}
+		for _, comp := range resolved.Components {
+			if comp != nil {
+				env.Configurations[comp.Id.String()] = comp.Configuration
+			}
+		}
+		environments = append(environments, env)
+	}
+	return base, environments, nil
+}
+
+type kustomization struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Namespace  string               `yaml:"namespace,omitempty"`
+	Resources  []string             `yaml:"resources"`
+	Patches    []kustomizationPatch `yaml:"patches,omitempty"`
+}
+
+type kustomizationPatch struct {
+	Path string `yaml:"path"`
+}
+
+// NewKustomizationFromPatternfile returns the tar archive holding the base and the overlays of the environments.
+func NewKustomizationFromPatternfile(patternFile *pattern.PatternFile, environments []KustomizeEnvironment) ([]byte, error) {
+	manifests, err := NewK8sManifestsFromPatternfile(patternFile)
+	if err != nil {
+		return nil, ErrCreateKustomization(err, patternFile.Name)
+	}
+
+	files := []archiveFile{}
+	add := func(name string, v interface{}) error {
+		buf := bytes.NewBufferString("")
+		enc := yaml.NewEncoder(buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		files = append(files, archiveFile{name: name, data: buf.Bytes()})
+		return nil
+	}
+
+	if err := add(path.Join(kustomizeBaseDir, kustomizationFileName), kustomization{
+		APIVersion: kustomizationAPIVersion,
+		Kind:       "Kustomization",
+		Resources:  []string{kustomizeResourcesFile},
+	}); err != nil {
+		return nil, ErrCreateKustomization(err, patternFile.Name)
+	}
+	files = append(files, archiveFile{name: path.Join(kustomizeBaseDir, kustomizeResourcesFile), data: []byte(manifests)})
+
+	overlays := map[string]bool{}
+	for _, env := range environments {
+		dir := sanitizeName(env.Name)
+		if dir == "" || overlays[dir] {
+			return nil, ErrCreateKustomization(fmt.Errorf("environment names must be unique and not empty, got %q", env.Name), patternFile.Name)
+		}
+		overlays[dir] = true
+		dir = path.Join(kustomizeOverlaysDir, dir)
+
+		k := kustomization{
+			APIVersion: kustomizationAPIVersion,
+			Kind:       "Kustomization",
+			Namespace:  env.Namespace,
+			Resources:  []string{path.Join("..", "..", kustomizeBaseDir)},
+		}
+		names := map[string]bool{}
+		for _, comp := range patternFile.Components {
+			patch := kustomizePatch(comp, env)
+			if patch == nil {
+				continue
+			}
+			name := uniqueName(fmt.Sprintf("%s-%s", comp.Component.Kind, comp.DisplayName), func(n string) bool { return names[n] })
+			names[name] = true
+			file := path.Join("patches", name+".yaml")
+			if err := add(path.Join(dir, file), patch); err != nil {
+				return nil, ErrCreateKustomization(err, patternFile.Name)
+			}
+			k.Patches = append(k.Patches, kustomizationPatch{Path: file})
+		}
+		if err := add(path.Join(dir, kustomizationFileName), k); err != nil {
+			return nil, ErrCreateKustomization(err, patternFile.Name)
+		}
+	}
+
+	archive, err := writeTar(files)
+	if err != nil {
+		return nil, ErrCreateKustomization(err, patternFile.Name)
+	}
+	return archive, nil
+}
+
+/*
+kustomizePatch returns the strategic merge patch applying the configuration of the component in the environment, nil if it does not differ.
+The patch identifies the resource by apiVersion, kind, name and namespace, as they are in the base.
+*/
+func kustomizePatch(comp *component.ComponentDefinition, env KustomizeEnvironment) map[string]interface{} {
+	configuration, ok := env.Configurations[comp.Id.String()]
+	if !ok {
+		configuration, ok = env.Configurations[comp.DisplayName]
+	}
+	if !ok {
+		return nil
+	}
+	base := CreateK8sResourceStructure(comp)
+	envComp := *comp
+	envComp.Configuration = configuration
+	diff, changed := configurationDiff(base, CreateK8sResourceStructure(&envComp))
+	if !changed {
+		return nil
+	}
+
+	patch := diff.(map[string]interface{})
+	metadata, _ := patch["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["name"] = comp.DisplayName
+	if baseMetadata, ok := base["metadata"].(map[string]interface{}); ok {
+		if ns, ok := baseMetadata["namespace"]; ok {
+			metadata["namespace"] = ns
+		}
+	}
+	patch["metadata"] = metadata
+	patch["apiVersion"] = base["apiVersion"]
+	patch["kind"] = base["kind"]
+	return patch
+}
+
+/*
+configurationDiff returns the merge patch turning base into target: changed values are set,
+objects are compared field by field, removed fields are set to null and lists are replaced as a whole.
+*/
+func configurationDiff(base, target interface{}) (interface{}, bool) {
+	baseMap, baseIsMap := base.(map[string]interface{})
+	targetMap, targetIsMap := target.(map[string]interface{})
+	if !baseIsMap || !targetIsMap {
+		if reflect.DeepEqual(normalizeYAML(base), normalizeYAML(target)) {
+			return nil, false
+		}
+		return target, true
+	}
+
+	diff := map[string]interface{}{}
+	for k, v := range targetMap {
+		if d, changed := configurationDiff(baseMap[k], v); changed {
+			diff[k] = d
+		}
+	}
+	for k := range baseMap {
+		if _, ok := targetMap[k]; !ok {
+			diff[k] = nil
+		}
+	}
+	return diff, len(diff) > 0
+}
+
+// normalizeYAML converts the value to its YAML representation, so that e.g. int and float64 values compare equal.
+func normalizeYAML(v interface{}) interface{} {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized interface{}
+	if err := yaml.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+func writeTar(files []archiveFile) ([]byte, error) {
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	// the files have a fixed time, so that converting the same design produces the same archive
+	modTime := time.Unix(0, 0)
+	for _, f := range files {
+		header := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: modTime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,7 +1,11 @@
 package converter
 
 import (
+	"encoding/json"
+
 	"github.com/layer5io/meshkit/converter"
+	"github.com/layer5io/meshkit/models/patterns"
+	"github.com/layer5io/meshkit/utils"
 )
 
 type ConvertFormat interface {
@@ -12,7 +16,47 @@ func NewFormatConverter(format DesignFormat) (ConvertFormat, error) {
 	switch format {
 	case K8sManifest:
 		return &converter.K8sConverter{}, nil
+	case HelmChart:
+		return &converter.HelmConverter{}, nil
+	case DockerCompose:
+		return &converter.ComposeConverter{}, nil
+	case Kustomize:
+		return &converter.KustomizeConverter{}, nil
 	default:
 		return nil, ErrUnknownFormat(format)
 	}
 }
+
+/*
+NewParameterizedFormatConverter returns the converter of the format, resolving the parameters of designs before converting them.
+The values of the parameters are taken from values, then from the profile of the design, then from the defaults of the parameters.
+*/
+func NewParameterizedFormatConverter(format DesignFormat, profile string, values map[string]interface{}) (ConvertFormat, error) {
+	c, err := NewFormatConverter(format)
+	if err != nil {
+		return nil, err
+	}
+	return &parameterizedConverter{converter: c, profile: profile, values: values}, nil
+}
+
+type parameterizedConverter struct {
+	converter ConvertFormat
+	profile   string
+	values    map[string]interface{}
+}
+
+func (pc *parameterizedConverter) Convert(patternFile string) (string, error) {
+	design, err := patterns.GetPatternFormat(patternFile)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := patterns.ResolveParameters(design, pc.profile, pc.values)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return "", utils.ErrMarshal(err)
+	}
+	return pc.converter.Convert(string(data))
+}
@@ -8,8 +8,13 @@ import (
 
 const (
 	ErrUnknownFormatCode = "meshkit-11245"
+	ErrImportDesignCode  = "replace_me"
 )
 
 func ErrUnknownFormat(format DesignFormat) error {
 	return errors.New(ErrUnknownFormatCode, errors.Alert, []string{fmt.Sprintf("\"%s\" format is not supported", format)}, []string{fmt.Sprintf("Failed to export design in \"%s\" format", format)}, []string{"The format is not supported by the current version of Meshery server"}, []string{"Make sure to export design in one of the supported format"})
 }
+
+func ErrImportDesign(err error, name string) error {
+	return errors.New(ErrImportDesignCode, errors.Alert, []string{fmt.Sprintf("Failed to import \"%s\" as a design", name)}, []string{err.Error()}, []string{"The manifests, chart or compose file is not valid", "The registry could not be queried"}, []string{"Make sure the file is valid and can be rendered or converted with helm or kompose", "Make sure the registry is reachable"})
+}
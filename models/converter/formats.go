@@ -7,4 +7,5 @@ const (
 	DockerCompose DesignFormat = "Docker Compose"
 	K8sManifest   DesignFormat = "Kubernetes Manifest"
 	Design        DesignFormat = "Design"
+	Kustomize     DesignFormat = "Kustomize"
 )
\ No newline at end of file
@@ -0,0 +1,230 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshkit/models/meshmodel/registry"
+	regv1beta1 "github.com/layer5io/meshkit/models/meshmodel/registry/v1beta1"
+	"github.com/layer5io/meshkit/models/patterns"
+	"github.com/layer5io/meshkit/utils/helm"
+	"github.com/layer5io/meshkit/utils/kubernetes/kompose"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// DesignSchemaVersion is the schema version of imported designs.
+const DesignSchemaVersion = "designs.meshery.io/v1beta1"
+
+/*
+Importer converts Kubernetes manifests, Helm charts and Docker Compose files into designs.
+Every resource is resolved against the components registered in the registry, resources without a registered component are skipped and reported.
+Relationships are inferred from the references between the resources, e.g. Services selecting Deployments or Pods mounting ConfigMaps,
+using the relationship definitions registered for the kinds of the resources.
+*/
+type Importer struct {
+	regManager *registry.RegistryManager
+	// ModelName restricts the components resources are resolved to to the model, all models are considered when empty.
+	ModelName string
+	// KubernetesVersion is the version charts are rendered for, unless the chart requires a specific version.
+	KubernetesVersion string
+
+	components    map[string]*component.ComponentDefinition
+	relationships []*relationship.RelationshipDefinition
+}
+
+func NewImporter(regManager *registry.RegistryManager) *Importer {
+	return &Importer{regManager: regManager}
+}
+
+/*
+Import converts the data, in the given format, into a design named name.
+Helm charts are expected as packaged charts (.tgz). It returns the resources which could not be imported as warnings.
+*/
+func (im *Importer) Import(format DesignFormat, name string, data []byte) (*pattern.PatternFile, []string, error) {
+	switch format {
+	case K8sManifest:
+		return im.ImportManifests(name, data)
+	case HelmChart:
+		return im.ImportHelmChart(name, data)
+	case DockerCompose:
+		return im.ImportCompose(name, data)
+	default:
+		return nil, nil, ErrUnknownFormat(format)
+	}
+}
+
+// ImportHelmChart renders the packaged chart with its default values and imports the resulting manifests.
+func (im *Importer) ImportHelmChart(name string, archive []byte) (*pattern.PatternFile, []string, error) {
+	c, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, nil, ErrImportDesign(err, name)
+	}
+	manifests, err := helm.DryRunHelmChart(c, im.KubernetesVersion)
+	if err != nil {
+		return nil, nil, ErrImportDesign(err, name)
+	}
+	return im.ImportManifests(name, manifests)
+}
+
+// ImportCompose converts the compose file into Kubernetes manifests using kompose and imports them.
+func (im *Importer) ImportCompose(name string, composeFile []byte) (*pattern.PatternFile, []string, error) {
+	manifests, err := kompose.Convert(kompose.DockerComposeFile(composeFile))
+	if err != nil {
+		return nil, nil, ErrImportDesign(err, name)
+	}
+	return im.ImportManifests(name, []byte(manifests))
+}
+
+// ImportManifests imports the resources of the multi-document YAML, or JSON, manifests. Lists are imported item by item.
+func (im *Importer) ImportManifests(name string, manifests []byte) (*pattern.PatternFile, []string, error) {
+	resources, err := decodeManifests(manifests)
+	if err != nil {
+		return nil, nil, ErrImportDesign(err, name)
+	}
+
+	id, _ := uuid.NewV4()
+	design := &pattern.PatternFile{
+		Id:            id,
+		Name:          name,
+		SchemaVersion: DesignSchemaVersion,
+		Components:    []*component.ComponentDefinition{},
+		Relationships: []*relationship.RelationshipDefinition{},
+	}
+	patterns.AssignVersion(design)
+
+	warnings := []string{}
+	for _, resource := range resources {
+		comp, err := im.component(resource)
+		if err != nil {
+			return nil, nil, ErrImportDesign(err, name)
+		}
+		if comp == nil {
+			warnings = append(warnings, fmt.Sprintf("no component is registered for %s %s (%s)", resource.kind(), resource.name(), resource.apiVersion()))
+			continue
+		}
+		design.Components = append(design.Components, comp)
+	}
+
+	design.Relationships, err = im.inferRelationships(design.Components)
+	if err != nil {
+		return nil, nil, ErrImportDesign(err, name)
+	}
+	return design, warnings, nil
+}
+
+type manifestResource map[string]interface{}
+
+func (r manifestResource) str(key string) string {
+	s, _ := r[key].(string)
+	return s
+}
+
+func (r manifestResource) kind() string       { return r.str("kind") }
+func (r manifestResource) apiVersion() string { return r.str("apiVersion") }
+
+func (r manifestResource) name() string {
+	metadata, _ := r["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+func decodeManifests(manifests []byte) ([]manifestResource, error) {
+	resources := []manifestResource{}
+	dec := yaml.NewDecoder(bytes.NewReader(manifests))
+	for {
+		doc := map[string]interface{}{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		resource := manifestResource(doc)
+		if resource.kind() == "" {
+			continue
+		}
+		if resource.kind() != "List" {
+			resources = append(resources, resource)
+			continue
+		}
+		items, _ := doc["items"].([]interface{})
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok && manifestResource(m).kind() != "" {
+				resources = append(resources, manifestResource(m))
+			}
+		}
+	}
+}
+
+/*
+component creates a component of the design from the resource, it returns nil if no component is registered for the kind and apiVersion of the resource.
+The configuration of the component is the resource without its kind and apiVersion.
+*/
+func (im *Importer) component(resource manifestResource) (*component.ComponentDefinition, error) {
+	def, err := im.definition(resource.kind(), resource.apiVersion())
+	if err != nil || def == nil {
+		return nil, err
+	}
+	comp := *def
+	comp.Id, _ = uuid.NewV4()
+	comp.DisplayName = resource.name()
+	comp.Configuration = map[string]interface{}{}
+	for k, v := range resource {
+		if k != "kind" && k != "apiVersion" {
+			comp.Configuration[k] = v
+		}
+	}
+	return &comp, nil
+}
+
+/*
+definition returns the component registered for the kind and apiVersion.
+If several models register it, the component of the latest version of the model is used.
+*/
+func (im *Importer) definition(kind, apiVersion string) (*component.ComponentDefinition, error) {
+	if im.components == nil {
+		im.components = map[string]*component.ComponentDefinition{}
+	}
+	key := apiVersion + "/" + kind
+	if def, ok := im.components[key]; ok {
+		return def, nil
+	}
+	entities, _, _, err := im.regManager.GetEntities(&regv1beta1.ComponentFilter{Name: kind, APIVersion: apiVersion, ModelName: im.ModelName, Trim: true})
+	if err != nil {
+		return nil, err
+	}
+	var def *component.ComponentDefinition
+	for _, e := range entities {
+		candidate, ok := e.(*component.ComponentDefinition)
+		if !ok {
+			continue
+		}
+		if def == nil || newerModel(candidate, def) {
+			def = candidate
+		}
+	}
+	im.components[key] = def
+	return def, nil
+}
+
+func newerModel(a, b *component.ComponentDefinition) bool {
+	va, errA := semver.NewVersion(a.Model.Model.Version)
+	vb, errB := semver.NewVersion(b.Model.Model.Version)
+	switch {
+	case errA == nil && errB == nil && !va.Equal(vb):
+		return va.GreaterThan(vb)
+	case errA == nil && errB != nil:
+		return true
+	case errA != nil && errB == nil:
+		return false
+	}
+	return a.Model.Name < b.Model.Name
+}
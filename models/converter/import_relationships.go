@@ -0,0 +1,347 @@
+package converter
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gofrs/uuid"
+	regv1alpha3 "github.com/layer5io/meshkit/models/meshmodel/registry/v1alpha3"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Sub types of the relationship definitions preferred for references, when several definitions match the kinds of the resources.
+const (
+	networkSubType = "network"
+	mountSubType   = "mount"
+)
+
+// reference is a reference of a resource to another resource, e.g. of a Service to the Deployment it selects.
+type reference struct {
+	from, to *component.ComponentDefinition
+	// subType is the preferred sub type of the relationship
+	subType string
+}
+
+// podTemplatePaths are the paths of the pod specs in the workloads.
+var podTemplatePaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+func lookup(m map[string]interface{}, path ...string) interface{} {
+	var v interface{} = m
+	for _, key := range path {
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = next[key]
+	}
+	return v
+}
+
+func lookupString(m map[string]interface{}, path ...string) string {
+	s, _ := lookup(m, path...).(string)
+	return s
+}
+
+// decodeInto converts the configuration value into the typed value.
+func decodeInto(v interface{}, out interface{}) bool {
+	if v == nil {
+		return false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, out) == nil
+}
+
+// podTemplate returns the pod spec and the labels of the pods of the workload, nil if the component is not a workload.
+func podTemplate(comp *component.ComponentDefinition) (*corev1.PodSpec, map[string]string) {
+	path, ok := podTemplatePaths[comp.Component.Kind]
+	if !ok {
+		return nil, nil
+	}
+	spec := &corev1.PodSpec{}
+	if !decodeInto(lookup(comp.Configuration, path...), spec) {
+		return nil, nil
+	}
+	podLabels := map[string]string{}
+	metadataPath := append(append([]string{}, path[:len(path)-1]...), "metadata", "labels")
+	decodeInto(lookup(comp.Configuration, metadataPath...), &podLabels)
+	return spec, podLabels
+}
+
+// clusterScopedKinds are the kinds of the resources which do not belong to a namespace.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"StorageClass":                   true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CustomResourceDefinition":       true,
+	"PriorityClass":                  true,
+	"IngressClass":                   true,
+	"RuntimeClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+	"MutatingWebhookConfiguration":   true,
+}
+
+// namespaceOf returns the namespace of the resource of the component, empty for cluster-scoped kinds.
+func namespaceOf(comp *component.ComponentDefinition) string {
+	if clusterScopedKinds[comp.Component.Kind] {
+		return ""
+	}
+	return lookupString(comp.Configuration, "metadata", "namespace")
+}
+
+// resourceKey identifies a resource by its kind, namespace and name, the namespace is ignored for cluster-scoped kinds.
+func resourceKey(kind, namespace, name string) string {
+	if clusterScopedKinds[kind] {
+		namespace = ""
+	}
+	return kind + "/" + namespace + "/" + name
+}
+
+/*
+references finds the references between the components, resolving names within the design.
+Names are resolved in the namespace of the referencing resource, unless the reference specifies a namespace.
+*/
+func references(components []*component.ComponentDefinition) []reference {
+	byName := map[string]*component.ComponentDefinition{}
+	for _, comp := range components {
+		byName[resourceKey(comp.Component.Kind, namespaceOf(comp), comp.DisplayName)] = comp
+	}
+	refs := []reference{}
+	refIn := func(from *component.ComponentDefinition, kind, namespace, name, subType string) {
+		if to, ok := byName[resourceKey(kind, namespace, name)]; ok && name != "" && to != from {
+			refs = append(refs, reference{from: from, to: to, subType: subType})
+		}
+	}
+
+	for _, comp := range components {
+		namespace := namespaceOf(comp)
+		ref := func(from *component.ComponentDefinition, kind, name, subType string) {
+			refIn(from, kind, namespace, name, subType)
+		}
+		if namespace != "" {
+			ref(comp, "Namespace", namespace, "")
+		}
+
+		switch comp.Component.Kind {
+		case "Service":
+			selector := map[string]string{}
+			if !decodeInto(lookup(comp.Configuration, "spec", "selector"), &selector) || len(selector) == 0 {
+				break
+			}
+			for _, workload := range components {
+				if namespaceOf(workload) != namespace {
+					continue
+				}
+				if _, podLabels := podTemplate(workload); podLabels != nil && labels.SelectorFromSet(selector).Matches(labels.Set(podLabels)) {
+					refs = append(refs, reference{from: comp, to: workload, subType: networkSubType})
+				}
+			}
+		case "Ingress":
+			if name := lookupString(comp.Configuration, "spec", "defaultBackend", "service", "name"); name != "" {
+				ref(comp, "Service", name, networkSubType)
+			}
+			rules, _ := lookup(comp.Configuration, "spec", "rules").([]interface{})
+			for _, rule := range rules {
+				r, _ := rule.(map[string]interface{})
+				paths, _ := lookup(r, "http", "paths").([]interface{})
+				for _, p := range paths {
+					pm, _ := p.(map[string]interface{})
+					ref(comp, "Service", lookupString(pm, "backend", "service", "name"), networkSubType)
+				}
+			}
+		case "RoleBinding", "ClusterRoleBinding":
+			ref(comp, lookupString(comp.Configuration, "roleRef", "kind"), lookupString(comp.Configuration, "roleRef", "name"), "")
+			subjects, _ := comp.Configuration["subjects"].([]interface{})
+			for _, subject := range subjects {
+				s, _ := subject.(map[string]interface{})
+				// subjects of cluster role bindings are namespaced in the subject
+				subjectNamespace := namespace
+				if ns := lookupString(s, "namespace"); ns != "" {
+					subjectNamespace = ns
+				}
+				refIn(comp, lookupString(s, "kind"), subjectNamespace, lookupString(s, "name"), "")
+			}
+		case "HorizontalPodAutoscaler":
+			ref(comp, lookupString(comp.Configuration, "spec", "scaleTargetRef", "kind"), lookupString(comp.Configuration, "spec", "scaleTargetRef", "name"), "")
+		}
+
+		spec, _ := podTemplate(comp)
+		if spec == nil {
+			continue
+		}
+		ref(comp, "ServiceAccount", spec.ServiceAccountName, "")
+		for _, secret := range spec.ImagePullSecrets {
+			ref(comp, "Secret", secret.Name, "")
+		}
+		for _, v := range spec.Volumes {
+			switch {
+			case v.PersistentVolumeClaim != nil:
+				ref(comp, "PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName, mountSubType)
+			case v.ConfigMap != nil:
+				ref(comp, "ConfigMap", v.ConfigMap.Name, mountSubType)
+			case v.Secret != nil:
+				ref(comp, "Secret", v.Secret.SecretName, mountSubType)
+			}
+		}
+		for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+			for _, from := range c.EnvFrom {
+				if from.ConfigMapRef != nil {
+					ref(comp, "ConfigMap", from.ConfigMapRef.Name, "")
+				}
+				if from.SecretRef != nil {
+					ref(comp, "Secret", from.SecretRef.Name, "")
+				}
+			}
+			for _, e := range c.Env {
+				if e.ValueFrom != nil && e.ValueFrom.ConfigMapKeyRef != nil {
+					ref(comp, "ConfigMap", e.ValueFrom.ConfigMapKeyRef.Name, "")
+				}
+				if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+					ref(comp, "Secret", e.ValueFrom.SecretKeyRef.Name, "")
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// relationshipDefinitions returns the enabled relationship definitions of the registry, sorted by kind, type and sub type.
+func (im *Importer) relationshipDefinitions() ([]*relationship.RelationshipDefinition, error) {
+	if im.relationships != nil {
+		return im.relationships, nil
+	}
+	entities, _, _, err := im.regManager.GetEntities(&regv1alpha3.RelationshipFilter{ModelName: im.ModelName})
+	if err != nil {
+		return nil, err
+	}
+	defs := []*relationship.RelationshipDefinition{}
+	for _, e := range entities {
+		def, ok := e.(*relationship.RelationshipDefinition)
+		if !ok || def.Selectors == nil || (def.Status != nil && *def.Status != relationship.Enabled) {
+			continue
+		}
+		defs = append(defs, def)
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		a, b := defs[i], defs[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.RelationshipType != b.RelationshipType {
+			return a.RelationshipType < b.RelationshipType
+		}
+		return a.SubType < b.SubType
+	})
+	im.relationships = defs
+	return defs, nil
+}
+
+func selects(item relationship.SelectorItem, comp *component.ComponentDefinition) bool {
+	if item.Kind != nil && *item.Kind != wildcard && *item.Kind != comp.Component.Kind {
+		return false
+	}
+	if item.Model != nil && item.Model.Name != "" && item.Model.Name != wildcard && item.Model.Name != comp.Model.Name {
+		return false
+	}
+	return item.Kind != nil || item.Model != nil
+}
+
+const wildcard = "*"
+
+/*
+inferRelationships creates a relationship for every reference between the components, from the first registered definition
+whose allow selectors select the kinds of the components, in either direction. Definitions of the preferred sub type are used first.
+*/
+func (im *Importer) inferRelationships(components []*component.ComponentDefinition) ([]*relationship.RelationshipDefinition, error) {
+	relationships := []*relationship.RelationshipDefinition{}
+	refs := references(components)
+	if len(refs) == 0 {
+		return relationships, nil
+	}
+	defs, err := im.relationshipDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	created := map[string]bool{}
+	for _, ref := range refs {
+		rel := instantiate(defs, ref, true)
+		if rel == nil {
+			rel = instantiate(defs, ref, false)
+		}
+		if rel == nil {
+			continue
+		}
+		from, to := (*rel.Selectors)[0].Allow.From[0].Id, (*rel.Selectors)[0].Allow.To[0].Id
+		key := string(rel.Kind) + rel.RelationshipType + rel.SubType + from.String() + to.String()
+		if created[key] {
+			continue
+		}
+		created[key] = true
+		relationships = append(relationships, rel)
+	}
+	return relationships, nil
+}
+
+// instantiate creates the relationship between the components of the reference, considering only definitions of the preferred sub type if preferred is set.
+func instantiate(defs []*relationship.RelationshipDefinition, ref reference, preferred bool) *relationship.RelationshipDefinition {
+	if preferred && ref.subType == "" {
+		return nil
+	}
+	for _, def := range defs {
+		if preferred && def.SubType != ref.subType {
+			continue
+		}
+		for _, set := range *def.Selectors {
+			for _, fromItem := range set.Allow.From {
+				for _, toItem := range set.Allow.To {
+					switch {
+					case selects(fromItem, ref.from) && selects(toItem, ref.to):
+						return newRelationship(def, fromItem, ref.from, toItem, ref.to)
+					case selects(fromItem, ref.to) && selects(toItem, ref.from):
+						return newRelationship(def, fromItem, ref.to, toItem, ref.from)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func newRelationship(def *relationship.RelationshipDefinition, fromItem relationship.SelectorItem, from *component.ComponentDefinition, toItem relationship.SelectorItem, to *component.ComponentDefinition) *relationship.RelationshipDefinition {
+	rel := *def
+	rel.Id, _ = uuid.NewV4()
+	rel.Selectors = &relationship.SelectorSet{{
+		Allow: relationship.Selector{
+			From: []relationship.SelectorItem{selectorItem(fromItem, from)},
+			To:   []relationship.SelectorItem{selectorItem(toItem, to)},
+		},
+	}}
+	return &rel
+}
+
+// selectorItem narrows the selector item of the definition to the component.
+func selectorItem(item relationship.SelectorItem, comp *component.ComponentDefinition) relationship.SelectorItem {
+	id := comp.Id
+	kind := comp.Component.Kind
+	item.Id = &id
+	item.Kind = &kind
+	return item
+}
@@ -0,0 +1,211 @@
+package converter
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/registry"
+	"github.com/meshery/schemas/models/v1alpha3"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/connection"
+	"github.com/meshery/schemas/models/v1beta1/model"
+	"gorm.io/gorm/logger"
+)
+
+// newTestImporter returns an importer resolving resources against a kubernetes model,
+// with network, mount and permission relationships.
+func newTestImporter(t *testing.T) *Importer {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	rm, err := registry.NewRegistryManager(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := model.ModelDefinition{
+		SchemaVersion: v1beta1.ModelSchemaVersion,
+		Name:          "kubernetes",
+		DisplayName:   "Kubernetes",
+		Status:        "enabled",
+		Category:      category.CategoryDefinition{Name: "Orchestration"},
+		Model:         model.Model{Version: "1.30.0"},
+		Registrant:    connection.Connection{Kind: "github"},
+	}
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatal(err)
+	}
+	for kind, apiVersion := range map[string]string{
+		"Deployment":         "apps/v1",
+		"Service":            "v1",
+		"ConfigMap":          "v1",
+		"ServiceAccount":     "v1",
+		"Namespace":          "v1",
+		"ClusterRoleBinding": "rbac.authorization.k8s.io/v1",
+	} {
+		comp := component.ComponentDefinition{
+			SchemaVersion: v1beta1.ComponentSchemaVersion,
+			DisplayName:   kind,
+			Component:     component.Component{Kind: kind, Version: apiVersion, Schema: `{"type": "object"}`},
+			Model:         m,
+		}
+		if _, _, err := rm.RegisterEntity(m.Registrant, &comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, def := range [][4]string{
+		{"non-binding", networkSubType, "Service", "Deployment"},
+		{"binding", mountSubType, "ConfigMap", "Deployment"},
+		{"non-binding", "permission", "ClusterRoleBinding", "ServiceAccount"},
+	} {
+		from, to := def[2], def[3]
+		enabled := relationship.Enabled
+		rel := relationship.RelationshipDefinition{
+			SchemaVersion:    v1alpha3.RelationshipSchemaVersion,
+			Kind:             "edge",
+			RelationshipType: def[0],
+			SubType:          def[1],
+			Status:           &enabled,
+			Model:            m,
+			Selectors: &relationship.SelectorSet{{Allow: relationship.Selector{
+				From: []relationship.SelectorItem{{Kind: &from, Model: &model.ModelDefinition{Name: "kubernetes"}}},
+				To:   []relationship.SelectorItem{{Kind: &to, Model: &model.ModelDefinition{Name: "kubernetes"}}},
+			}}},
+		}
+		if _, _, err := rm.RegisterEntity(m.Registrant, &rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewImporter(rm)
+}
+
+// relationshipSummary describes the relationships of the design as <sub type> <namespace/name of from> -> <namespace/name of to>.
+func relationshipSummary(components []*component.ComponentDefinition, relationships []*relationship.RelationshipDefinition) []string {
+	names := map[string]string{}
+	for _, c := range components {
+		names[c.Id.String()] = lookupString(c.Configuration, "metadata", "namespace") + "/" + c.DisplayName
+	}
+	summary := []string{}
+	for _, r := range relationships {
+		set := (*r.Selectors)[0]
+		summary = append(summary, r.SubType+" "+names[set.Allow.From[0].Id.String()]+" -> "+names[set.Allow.To[0].Id.String()])
+	}
+	sort.Strings(summary)
+	return summary
+}
+
+const namespacedManifests = `
+apiVersion: v1
+kind: Namespace
+metadata: {name: a}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: cfg, namespace: a}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: cfg, namespace: b}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata: {name: runner, namespace: a}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata: {name: runner}
+roleRef: {kind: ClusterRole, name: view}
+subjects: [{kind: ServiceAccount, name: runner, namespace: a}, {kind: ServiceAccount, name: runner, namespace: b}]
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata: {name: web, namespace: a}
+  spec:
+    template:
+      metadata: {labels: {app: web}}
+      spec:
+        containers: [{name: web, image: nginx}]
+        volumes: [{name: config, configMap: {name: cfg}}]
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata: {name: web, namespace: b}
+  spec:
+    template:
+      metadata: {labels: {app: web}}
+      spec:
+        containers: [{name: web, image: nginx}]
+        volumes: [{name: config, configMap: {name: cfg}}]
+- apiVersion: v1
+  kind: Service
+  metadata: {name: web, namespace: a}
+  spec: {selector: {app: web}}
+- apiVersion: v1
+  kind: Service
+  metadata: {name: web, namespace: b}
+  spec: {selector: {app: web}}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata: {name: web, namespace: a}
+`
+
+func TestImportManifests(t *testing.T) {
+	im := newTestImporter(t)
+	design, warnings, err := im.ImportManifests("namespaced", []byte(namespacedManifests))
+	if err != nil {
+		t.Fatalf("ImportManifests() error = %v", err)
+	}
+	if want := []string{"no component is registered for Ingress web (networking.k8s.io/v1)"}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("ImportManifests() warnings = %q, want %q", warnings, want)
+	}
+	if len(design.Components) != 9 || design.Name != "namespaced" || design.SchemaVersion != DesignSchemaVersion {
+		t.Errorf("ImportManifests() = %s with %d components, want namespaced with 9", design.Name, len(design.Components))
+	}
+	want := []string{
+		"mount a/cfg -> a/web",
+		"mount b/cfg -> b/web",
+		"network a/web -> a/web",
+		"network b/web -> b/web",
+		"permission /runner -> a/runner",
+	}
+	if got := relationshipSummary(design.Components, design.Relationships); !reflect.DeepEqual(got, want) {
+		t.Errorf("ImportManifests() relationships = %q, want %q", got, want)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	comp := func(kind, namespace, name string, configuration map[string]interface{}) *component.ComponentDefinition {
+		c := &component.ComponentDefinition{DisplayName: name, Component: component.Component{Kind: kind}, Configuration: configuration}
+		if configuration == nil {
+			c.Configuration = map[string]interface{}{}
+		}
+		c.Configuration["metadata"] = map[string]interface{}{"name": name, "namespace": namespace}
+		return c
+	}
+	// the namespace of cluster-scoped resources is ignored
+	namespace := comp("Namespace", "ignored", "a", nil)
+	role := comp("ClusterRole", "ignored", "view", nil)
+	binding := comp("RoleBinding", "a", "view", map[string]interface{}{"roleRef": map[string]interface{}{"kind": "ClusterRole", "name": "view"}})
+	otherBinding := comp("RoleBinding", "b", "view", map[string]interface{}{"roleRef": map[string]interface{}{"kind": "ClusterRole", "name": "view"}})
+
+	refs := references([]*component.ComponentDefinition{namespace, role, binding, otherBinding})
+	got := []string{}
+	for _, r := range refs {
+		got = append(got, namespaceOf(r.from)+"/"+r.from.DisplayName+" -> "+r.to.Component.Kind+" "+r.to.DisplayName)
+	}
+	want := []string{"a/view -> Namespace a", "a/view -> ClusterRole view", "b/view -> ClusterRole view"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("references() = %q, want %q", got, want)
+	}
+}
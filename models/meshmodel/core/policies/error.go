@@ -1,6 +1,10 @@
 package policies
 
-import "github.com/layer5io/meshkit/errors"
+import (
+	"fmt"
+
+	"github.com/layer5io/meshkit/errors"
+)
 
 const (
 	ErrPrepareForEvalCode = "meshkit-11144"
@@ -14,3 +18,16 @@ func ErrPrepareForEval(err error) error {
 func ErrEval(err error) error {
 	return errors.New(ErrEvalCode, errors.Alert, []string{"error evaluating policy for the given input"}, []string{err.Error()}, []string{"The policy query is invalid, see: https://github.com/open-policy-agent/opa/blob/main/rego/resultset.go (Allowed func)"}, []string{"please provide a valid non-empty query"})
 }
+
+const (
+	ErrLoadPoliciesCode  = "replace_me"
+	ErrWatchPoliciesCode = "replace_me"
+)
+
+func ErrLoadPolicies(err error, policyDir string) error {
+	return errors.New(ErrLoadPoliciesCode, errors.Alert, []string{fmt.Sprintf("error loading the policies of %s", policyDir)}, []string{err.Error()}, []string{"the policy directory or bundle does not exist", "a policy does not compile", "a data file of the bundle is not valid JSON or YAML"}, []string{"make sure the policy directory or bundle exists and its policies compile with opa check"})
+}
+
+func ErrWatchPolicies(err error, policyDir string) error {
+	return errors.New(ErrWatchPoliciesCode, errors.Alert, []string{fmt.Sprintf("error watching the policies of %s", policyDir)}, []string{err.Error()}, []string{"the policy directory or bundle does not exist", "the limit of watched files has been reached"}, []string{"make sure the policy directory or bundle exists", "raise the inotify watch limit of the system"})
+}
@@ -3,12 +3,20 @@ package policies
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
 	"github.com/layer5io/meshkit/models/meshmodel/registry"
 	"github.com/layer5io/meshkit/models/meshmodel/registry/v1alpha3"
 	"github.com/layer5io/meshkit/utils"
 	"github.com/meshery/schemas/models/v1beta1/pattern"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/loader"
 	"github.com/open-policy-agent/opa/rego"
 
 	"github.com/open-policy-agent/opa/storage"
@@ -18,37 +26,247 @@ import (
 
 var SyncRelationship sync.Mutex
 
+// reloadDelay is the time Watch waits for further changes of the policies before reloading them.
+const reloadDelay = 200 * time.Millisecond
+
+/*
+Rego evaluates the policies of policyDir, loaded as rego.Load loads them: the .rego, .json and .yaml files of the
+directory and of its subdirectories, data files being merged into data at the path of their directory, or an OPA bundle tarball.
+The policies are compiled once, and again when Reload is called or, once Watch is called, when they change.
+The registered relationships are available to the policies as data.relationships, they are refreshed
+before the next evaluation whenever the registry manager registers a relationship.
+Evaluations are safe for concurrent use, the prepared queries are cached by query string.
+*/
 type Rego struct {
-	store       storage.Store
-	ctx         context.Context
-	transaction storage.Transaction
-	policyDir   string
+	ctx        context.Context
+	policyDir  string
+	regManager *registry.RegistryManager
+
+	mx       sync.RWMutex
+	store    storage.Store
+	compiler *ast.Compiler
+	queries  map[string]rego.PreparedEvalQuery
+
+	relationshipsStale atomic.Bool
+	unsubscribe        func()
+
+	watchMx sync.Mutex
+	watcher *fsnotify.Watcher
 }
 
 func NewRegoInstance(policyDir string, regManager *registry.RegistryManager) (*Rego, error) {
-	var txn storage.Transaction
-	var store storage.Store
+	r := &Rego{
+		ctx:        context.Background(),
+		policyDir:  policyDir,
+		regManager: regManager,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	r.unsubscribe = regManager.OnRegister(func(e entity.Entity) {
+		if e.Type() == entity.RelationshipDefinition {
+			r.relationshipsStale.Store(true)
+		}
+	})
+	return r, nil
+}
+
+/*
+Reload compiles the policies and loads the data files and the registered relationships into a new store.
+The policies in use are kept if they fail to compile.
+*/
+func (r *Rego) Reload() error {
+	result, err := loader.NewFileLoader().
+		WithProcessAnnotation(true).
+		Filtered([]string{r.policyDir}, nil)
+	if err != nil {
+		return ErrLoadPolicies(err, r.policyDir)
+	}
+	modules := result.ParsedModules()
+	compiler := ast.NewCompiler()
+	if compiler.Compile(modules); compiler.Failed() {
+		return ErrLoadPolicies(compiler.Errors, r.policyDir)
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range result.Documents {
+		data[k] = v
+	}
+	// relationships registered while they are loaded are refreshed before the next evaluation
+	r.relationshipsStale.Store(false)
+	relationships, err := r.relationships()
+	if err != nil {
+		return err
+	}
+	data["relationships"] = relationships
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.compiler = compiler
+	r.store = inmem.NewFromObject(data)
+	r.queries = map[string]rego.PreparedEvalQuery{}
+	return nil
+}
 
-	ctx := context.Background()
-	registeredRelationships, _, _, err := regManager.GetEntities(&v1alpha3.RelationshipFilter{})
+func (r *Rego) relationships() ([]interface{}, error) {
+	registeredRelationships, _, _, err := r.regManager.GetEntities(&v1alpha3.RelationshipFilter{})
 	if err != nil {
 		return nil, err
 	}
+	return utils.MarshalAndUnmarshal[[]entity.Entity, []interface{}](registeredRelationships)
+}
+
+// refreshRelationships replaces data.relationships by the relationships currently registered.
+func (r *Rego) refreshRelationships() error {
+	relationships, err := r.relationships()
+	if err != nil {
+		return err
+	}
+	r.mx.RLock()
+	store := r.store
+	r.mx.RUnlock()
+	return storage.WriteOne(r.ctx, store, storage.AddOp, storage.Path{"relationships"}, relationships)
+}
+
+// preparedQuery returns the query prepared for evaluation against the compiled policies, preparing it on first use.
+func (r *Rego) preparedQuery(regoQueryString string) (rego.PreparedEvalQuery, error) {
+	r.mx.RLock()
+	query, ok := r.queries[regoQueryString]
+	r.mx.RUnlock()
+	if ok {
+		return query, nil
+	}
 
-	if len(registeredRelationships) > 0 {
-		data := map[string]interface{}{
-			"relationships": registeredRelationships,
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if query, ok := r.queries[regoQueryString]; ok {
+		return query, nil
+	}
+	query, err := rego.New(
+		rego.Query(regoQueryString),
+		rego.Compiler(r.compiler),
+		rego.Store(r.store),
+	).PrepareForEval(r.ctx)
+	if err != nil {
+		return query, err
+	}
+	r.queries[regoQueryString] = query
+	return query, nil
+}
+
+/*
+Watch reloads the policies whenever the files of policyDir change, until ctx is done or Close is called.
+Changes are batched, policies which fail to compile are logged and the previous ones kept.
+The registered relationships are still refreshed once ctx is done, until Close is called.
+*/
+func (r *Rego) Watch(ctx context.Context) error {
+	r.watchMx.Lock()
+	defer r.watchMx.Unlock()
+	if r.watcher != nil {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return ErrWatchPolicies(err, r.policyDir)
+	}
+
+	info, err := os.Stat(r.policyDir)
+	if err != nil {
+		_ = watcher.Close()
+		return ErrWatchPolicies(err, r.policyDir)
+	}
+	if info.IsDir() {
+		err = filepath.WalkDir(r.policyDir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return err
+			}
+			return watcher.Add(path)
+		})
+	} else {
+		// bundle tarballs are usually replaced rather than written in place, the directory holding them is watched
+		err = watcher.Add(filepath.Dir(r.policyDir))
+	}
+	if err != nil {
+		_ = watcher.Close()
+		return ErrWatchPolicies(err, r.policyDir)
+	}
+	r.watcher = watcher
+
+	go r.watch(ctx, watcher, !info.IsDir())
+	return nil
+}
+
+func (r *Rego) watch(ctx context.Context, watcher *fsnotify.Watcher, bundleFile bool) {
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.stopWatching(watcher)
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if bundleFile && filepath.Clean(event.Name) != filepath.Clean(r.policyDir) {
+				continue
+			}
+			if !bundleFile && event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = watcher.Add(event.Name)
+				}
+			}
+			if !bundleFile && !policyFile(event.Name) {
+				continue
+			}
+			timer.Reset(reloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logrus.Error(ErrWatchPolicies(err, r.policyDir))
+		case <-timer.C:
+			if err := r.Reload(); err != nil {
+				logrus.Error(err)
+			}
 		}
-		store = inmem.NewFromObject(data)
-		txn, _ = store.NewTransaction(ctx, storage.WriteParams)
+	}
+}
 
+// policyFile reports whether the file is part of the policies: rego files, data files, bundle manifests and directories.
+func policyFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".rego", ".json", ".yaml", ".yml", ".manifest", "":
+		return true
+	}
+	return false
+}
+
+// stopWatching closes the watcher unless it has already been replaced, the registry subscription is kept.
+func (r *Rego) stopWatching(watcher *fsnotify.Watcher) {
+	r.watchMx.Lock()
+	defer r.watchMx.Unlock()
+	if r.watcher == watcher {
+		_ = r.watcher.Close()
+		r.watcher = nil
+	}
+}
+
+// Close stops watching the policies and the registry.
+func (r *Rego) Close() {
+	if r == nil {
+		return
+	}
+	r.watchMx.Lock()
+	if r.watcher != nil {
+		_ = r.watcher.Close()
+		r.watcher = nil
+	}
+	r.watchMx.Unlock()
+	if r.unsubscribe != nil {
+		r.unsubscribe()
 	}
-	return &Rego{
-		store:       store,
-		ctx:         ctx,
-		transaction: txn,
-		policyDir:   policyDir,
-	}, nil
 }
 
 // RegoPolicyHandler takes the required inputs and run the query against all the policy files provided
@@ -57,12 +275,14 @@ func (r *Rego) RegoPolicyHandler(designFile pattern.PatternFile, regoQueryString
 	if r == nil {
 		return evaluationResponse, ErrEval(fmt.Errorf("policy engine is not yet ready"))
 	}
-	regoEngine, err := rego.New(
-		rego.Query(regoQueryString),
-		rego.Load([]string{r.policyDir}, nil),
-		rego.Store(r.store),
-		rego.Transaction(r.transaction),
-	).PrepareForEval(r.ctx)
+	if r.relationshipsStale.Swap(false) {
+		if err := r.refreshRelationships(); err != nil {
+			r.relationshipsStale.Store(true)
+			logrus.Error("error refreshing the registered relationships", err)
+		}
+	}
+
+	regoEngine, err := r.preparedQuery(regoQueryString)
 	if err != nil {
 		logrus.Error("error preparing for evaluation", err)
 		return evaluationResponse, ErrPrepareForEval(err)
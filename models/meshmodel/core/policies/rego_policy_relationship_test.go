@@ -0,0 +1,152 @@
+package policies
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/registry"
+	"github.com/meshery/schemas/models/v1alpha3"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/connection"
+	"github.com/meshery/schemas/models/v1beta1/model"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+	"gorm.io/gorm/logger"
+)
+
+// testPolicy evaluates to the value of the data file of the config directory and the number of registered relationships.
+const testPolicy = `package meshkit
+
+evaluate := {"evaluationHash": sprintf("%v/%d", [data.config.value, count(data.relationships)])}
+`
+
+func newTestRegistryManager(t *testing.T) *registry.RegistryManager {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	rm, err := registry.NewRegistryManager(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rm
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestRego returns a Rego instance for a policy directory holding testPolicy and the data file config/settings.json.
+func newTestRego(t *testing.T, rm *registry.RegistryManager) (*Rego, string) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "policy.rego"), testPolicy)
+	writeFile(t, filepath.Join(dir, "config", "settings.json"), `{"value": "a"}`)
+	r, err := NewRegoInstance(dir, rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	return r, dir
+}
+
+func evaluate(t *testing.T, r *Rego) string {
+	t.Helper()
+	res, err := r.RegoPolicyHandler(pattern.PatternFile{}, "data.meshkit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.EvaluationHash == nil {
+		t.Fatal("evaluation result is empty")
+	}
+	return *res.EvaluationHash
+}
+
+func TestRegoReload(t *testing.T) {
+	r, dir := newTestRego(t, newTestRegistryManager(t))
+	if got := evaluate(t, r); got != "a/0" {
+		t.Fatalf("evaluate = %s, want a/0", got)
+	}
+
+	writeFile(t, filepath.Join(dir, "config", "settings.json"), `{"value": "b"}`)
+	if got := evaluate(t, r); got != "a/0" {
+		t.Errorf("evaluate = %s before Reload, want a/0", got)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := evaluate(t, r); got != "b/0" {
+		t.Errorf("evaluate = %s after Reload, want b/0", got)
+	}
+
+	// policies which fail to compile are not used
+	writeFile(t, filepath.Join(dir, "policy.rego"), "package meshkit\n\nevaluate := undefined_ref\n")
+	if err := r.Reload(); err == nil {
+		t.Error("Reload succeeded with an invalid policy")
+	}
+	if got := evaluate(t, r); got != "b/0" {
+		t.Errorf("evaluate = %s after a failed Reload, want b/0", got)
+	}
+}
+
+func TestRegoWatch(t *testing.T) {
+	r, dir := newTestRego(t, newTestRegistryManager(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := r.Watch(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "config", "settings.json"), `{"value": "b"}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for got := evaluate(t, r); got != "b/0"; got = evaluate(t, r) {
+		if time.Now().After(deadline) {
+			t.Fatalf("evaluate = %s after the data file changed, want b/0", got)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRegoRefreshRelationships(t *testing.T) {
+	rm := newTestRegistryManager(t)
+	r, _ := newTestRego(t, rm)
+	if got := evaluate(t, r); got != "a/0" {
+		t.Fatalf("evaluate = %s, want a/0", got)
+	}
+
+	m := model.ModelDefinition{
+		SchemaVersion: v1beta1.ModelSchemaVersion,
+		Name:          "kubernetes",
+		DisplayName:   "Kubernetes",
+		Status:        "enabled",
+		Category:      category.CategoryDefinition{Name: "Orchestration"},
+		Model:         model.Model{Version: "1.0.0"},
+		Registrant:    connection.Connection{Kind: "github"},
+	}
+	rel := relationship.RelationshipDefinition{
+		SchemaVersion:    v1alpha3.RelationshipSchemaVersion,
+		Kind:             "edge",
+		RelationshipType: "non-binding",
+		SubType:          "network",
+		Model:            m,
+	}
+	if _, _, err := rm.RegisterEntity(m.Registrant, &rel); err != nil {
+		t.Fatal(err)
+	}
+	if got := evaluate(t, r); got != "a/1" {
+		t.Errorf("evaluate = %s after registering a relationship, want a/1", got)
+	}
+}
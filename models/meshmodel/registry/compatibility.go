@@ -0,0 +1,350 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/model"
+)
+
+// CompatibilityPolicy decides what happens when a newly registered model version changes component schemas in a breaking way.
+type CompatibilityPolicy string
+
+const (
+	// CompatibilityIgnore does not check schema compatibility.
+	CompatibilityIgnore CompatibilityPolicy = ""
+	// CompatibilityAnnotate registers the model and records the result of the check in the model metadata.
+	CompatibilityAnnotate CompatibilityPolicy = "annotate"
+	// CompatibilityBlock refuses to register a model with breaking schema changes, otherwise behaves like CompatibilityAnnotate.
+	CompatibilityBlock CompatibilityPolicy = "block"
+)
+
+// SchemaChangeKind classifies a single change between two versions of a schema.
+type SchemaChangeKind string
+
+const (
+	PropertyAdded       SchemaChangeKind = "property-added"
+	PropertyRemoved     SchemaChangeKind = "property-removed"
+	RequiredAdded       SchemaChangeKind = "required-added"
+	RequiredRemoved     SchemaChangeKind = "required-removed"
+	TypeNarrowed        SchemaChangeKind = "type-narrowed"
+	TypeWidened         SchemaChangeKind = "type-widened"
+	TypeChanged         SchemaChangeKind = "type-changed"
+	EnumNarrowed        SchemaChangeKind = "enum-narrowed"
+	EnumWidened         SchemaChangeKind = "enum-widened"
+	AdditionalForbidden SchemaChangeKind = "additional-properties-forbidden"
+	AdditionalAllowed   SchemaChangeKind = "additional-properties-allowed"
+	// ComponentRemoved is reported for a component of the previous version missing from the new version, its path is empty.
+	ComponentRemoved SchemaChangeKind = "component-removed"
+)
+
+// SchemaChange is a change to the property at Path, a JSON pointer into the configuration described by the schema.
+type SchemaChange struct {
+	Path     string           `json:"path"`
+	Kind     SchemaChangeKind `json:"kind"`
+	Breaking bool             `json:"breaking"`
+	Message  string           `json:"message"`
+}
+
+// ComponentCompatibility is the result of comparing the schema of a component between two versions of its model.
+type ComponentCompatibility struct {
+	Kind            string         `json:"kind"`
+	PreviousVersion string         `json:"previousVersion"`
+	Version         string         `json:"version"`
+	Breaking        bool           `json:"breaking"`
+	Changes         []SchemaChange `json:"changes"`
+}
+
+// ModelCompatibility summarizes the schema compatibility of a model version with the previously registered version.
+type ModelCompatibility struct {
+	PreviousVersion string                   `json:"previousVersion"`
+	Breaking        bool                     `json:"breaking"`
+	Components      []ComponentCompatibility `json:"components"`
+}
+
+// BreakingComponents returns the kinds of the components with breaking changes.
+func (mc ModelCompatibility) BreakingComponents() []string {
+	kinds := []string{}
+	for _, c := range mc.Components {
+		if c.Breaking {
+			kinds = append(kinds, c.Kind)
+		}
+	}
+	return kinds
+}
+
+// CheckModelCompatibility compares the component schemas of the given model version with the closest lower version of the model
+// present in the registry. Components are matched by kind, a component missing from the new version is reported as breaking.
+// It returns nil when no previous version of the model is registered.
+func (rm *RegistryManager) CheckModelCompatibility(m model.ModelDefinition, components []component.ComponentDefinition) (*ModelCompatibility, error) {
+	previous, err := rm.previousModelVersion(m)
+	if err != nil || previous == nil {
+		return nil, err
+	}
+
+	var previousComponents []component.ComponentDefinition
+	err = rm.db.Where("model_id = ?", previous.Id).Find(&previousComponents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]component.ComponentDefinition, len(components))
+	for _, c := range components {
+		current[c.Component.Kind] = c
+	}
+
+	result := &ModelCompatibility{PreviousVersion: previous.Model.Version, Components: []ComponentCompatibility{}}
+	for _, prev := range previousComponents {
+		cc := ComponentCompatibility{Kind: prev.Component.Kind, PreviousVersion: previous.Model.Version, Version: m.Model.Version}
+		c, ok := current[prev.Component.Kind]
+		if !ok {
+			cc.Breaking = true
+			cc.Changes = []SchemaChange{{Path: "", Kind: ComponentRemoved, Breaking: true, Message: "component has been removed from the model"}}
+			result.Components = append(result.Components, cc)
+			result.Breaking = true
+			continue
+		}
+		changes, err := DiffSchemas(prev.Component.Schema, c.Component.Schema)
+		if err != nil {
+			return nil, ErrSchemaDiff(err, prev.Component.Kind)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		cc.Changes = changes
+		for _, change := range changes {
+			cc.Breaking = cc.Breaking || change.Breaking
+		}
+		result.Breaking = result.Breaking || cc.Breaking
+		result.Components = append(result.Components, cc)
+	}
+	sort.Slice(result.Components, func(i, j int) bool { return result.Components[i].Kind < result.Components[j].Kind })
+	return result, nil
+}
+
+// previousModelVersion returns the registered model with the same name and the highest version lower than the version of m.
+func (rm *RegistryManager) previousModelVersion(m model.ModelDefinition) (*model.ModelDefinition, error) {
+	current, err := semver.NewVersion(m.Model.Version)
+	if err != nil {
+		// versions which are not semantic versions cannot be ordered
+		return nil, nil
+	}
+	var candidates []model.ModelDefinition
+	err = rm.db.Where("name = ?", m.Name).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *model.ModelDefinition
+	var previousVersion *semver.Version
+	for i := range candidates {
+		v, err := semver.NewVersion(candidates[i].Model.Version)
+		if err != nil || !v.LessThan(current) {
+			continue
+		}
+		if previousVersion == nil || v.GreaterThan(previousVersion) {
+			previous, previousVersion = &candidates[i], v
+		}
+	}
+	return previous, nil
+}
+
+// DiffSchemas compares two versions of a JSON schema and classifies every change.
+// A change is breaking when configuration valid for the previous schema may be invalid for the current one:
+// removed properties, narrowed types or enums, new required properties and forbidding additional properties.
+func DiffSchemas(previous, current string) ([]SchemaChange, error) {
+	var prev, curr map[string]interface{}
+	if strings.TrimSpace(previous) == "" || strings.TrimSpace(current) == "" {
+		return []SchemaChange{}, nil
+	}
+	if err := json.Unmarshal([]byte(previous), &prev); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(current), &curr); err != nil {
+		return nil, err
+	}
+	changes := []SchemaChange{}
+	diffSchema("", prev, curr, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+	return changes, nil
+}
+
+func diffSchema(path string, prev, curr map[string]interface{}, changes *[]SchemaChange) {
+	add := func(p string, kind SchemaChangeKind, breaking bool, format string, args ...interface{}) {
+		*changes = append(*changes, SchemaChange{Path: p, Kind: kind, Breaking: breaking, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// type
+	prevTypes, currTypes := schemaTypes(prev), schemaTypes(curr)
+	if len(prevTypes) > 0 && len(currTypes) > 0 && !sameSet(prevTypes, currTypes) {
+		switch {
+		case isSubset(currTypes, prevTypes):
+			add(path, TypeNarrowed, true, "type narrowed from %s to %s", strings.Join(prevTypes, ", "), strings.Join(currTypes, ", "))
+		case isSubset(prevTypes, currTypes):
+			add(path, TypeWidened, false, "type widened from %s to %s", strings.Join(prevTypes, ", "), strings.Join(currTypes, ", "))
+		default:
+			add(path, TypeChanged, true, "type changed from %s to %s", strings.Join(prevTypes, ", "), strings.Join(currTypes, ", "))
+		}
+	}
+
+	// enum
+	prevEnum, prevHasEnum := enumValues(prev)
+	currEnum, currHasEnum := enumValues(curr)
+	switch {
+	case currHasEnum && !prevHasEnum:
+		add(path, EnumNarrowed, true, "values restricted to %s", strings.Join(currEnum, ", "))
+	case prevHasEnum && !currHasEnum:
+		add(path, EnumWidened, false, "values are no longer restricted")
+	case prevHasEnum && currHasEnum && !sameSet(prevEnum, currEnum):
+		if isSubset(prevEnum, currEnum) {
+			add(path, EnumWidened, false, "allowed values extended")
+		} else {
+			add(path, EnumNarrowed, true, "allowed values no longer include %s", strings.Join(difference(prevEnum, currEnum), ", "))
+		}
+	}
+
+	// additionalProperties
+	if allowsAdditional(prev) && !allowsAdditional(curr) {
+		add(path, AdditionalForbidden, true, "additional properties are no longer allowed")
+	} else if !allowsAdditional(prev) && allowsAdditional(curr) {
+		add(path, AdditionalAllowed, false, "additional properties are allowed")
+	}
+
+	// properties
+	prevProps, _ := prev["properties"].(map[string]interface{})
+	currProps, _ := curr["properties"].(map[string]interface{})
+	prevRequired, currRequired := requiredProperties(prev), requiredProperties(curr)
+	for name, p := range prevProps {
+		propPath := path + "/" + escapePointer(name)
+		c, ok := currProps[name]
+		if !ok {
+			add(propPath, PropertyRemoved, true, "property %q has been removed", name)
+			continue
+		}
+		pm, _ := p.(map[string]interface{})
+		cm, _ := c.(map[string]interface{})
+		if pm != nil && cm != nil {
+			diffSchema(propPath, pm, cm, changes)
+		}
+	}
+	for name := range currProps {
+		if _, ok := prevProps[name]; !ok {
+			propPath := path + "/" + escapePointer(name)
+			if currRequired[name] {
+				add(propPath, RequiredAdded, true, "new property %q is required", name)
+			} else {
+				add(propPath, PropertyAdded, false, "property %q has been added", name)
+			}
+		}
+	}
+	for name := range currRequired {
+		if _, existed := prevProps[name]; existed && !prevRequired[name] {
+			add(path+"/"+escapePointer(name), RequiredAdded, true, "property %q is now required", name)
+		}
+	}
+	for name := range prevRequired {
+		if !currRequired[name] {
+			if _, exists := currProps[name]; exists {
+				add(path+"/"+escapePointer(name), RequiredRemoved, false, "property %q is no longer required", name)
+			}
+		}
+	}
+
+	// array items
+	prevItems, _ := prev["items"].(map[string]interface{})
+	currItems, _ := curr["items"].(map[string]interface{})
+	if prevItems != nil && currItems != nil {
+		diffSchema(path+"/-", prevItems, currItems, changes)
+	}
+}
+
+// schemaTypes returns the sorted types allowed by a schema. "integer" is implied by "number".
+func schemaTypes(schema map[string]interface{}) []string {
+	set := map[string]struct{}{}
+	switch t := schema["type"].(type) {
+	case string:
+		set[t] = struct{}{}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				set[s] = struct{}{}
+			}
+		}
+	}
+	if _, ok := set["number"]; ok {
+		set["integer"] = struct{}{}
+	}
+	types := make([]string, 0, len(set))
+	for t := range set {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func enumValues(schema map[string]interface{}) ([]string, bool) {
+	enum, ok := schema["enum"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	values := make([]string, 0, len(enum))
+	for _, v := range enum {
+		byt, _ := json.Marshal(v)
+		values = append(values, string(byt))
+	}
+	sort.Strings(values)
+	return values, true
+}
+
+func allowsAdditional(schema map[string]interface{}) bool {
+	allowed, ok := schema["additionalProperties"].(bool)
+	return !ok || allowed
+}
+
+func requiredProperties(schema map[string]interface{}) map[string]bool {
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, v := range list {
+			if name, ok := v.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+	return required
+}
+
+func isSubset(subset, set []string) bool {
+	return len(difference(subset, set)) == 0
+}
+
+func sameSet(a, b []string) bool {
+	return isSubset(a, b) && isSubset(b, a)
+}
+
+// difference returns the elements of a which are not in b.
+func difference(a, b []string) []string {
+	in := make(map[string]struct{}, len(b))
+	for _, v := range b {
+		in[v] = struct{}{}
+	}
+	diff := []string{}
+	for _, v := range a {
+		if _, ok := in[v]; !ok {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
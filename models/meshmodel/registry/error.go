@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/layer5io/meshkit/errors"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
 )
 
 var (
@@ -15,6 +16,12 @@ var (
 	ErrUnknownHostInMapCode            = "replace_me"
 	ErrCreatingUserDataDirectoryCode   = "replace_me"
 	ErrGetByIdCode                     = "replace_me"
+	ErrContentHashCode                 = "replace_me"
+	ErrInvalidQueryCode                = "replace_me"
+	ErrSearchIndexCode                 = "replace_me"
+	ErrSchemaDiffCode                  = "replace_me"
+	ErrExportCode                      = "replace_me"
+	ErrResolveSelectorCode             = "replace_me"
 )
 
 func ErrGetById(err error, id string) error {
@@ -52,3 +59,27 @@ func ErrRegisteringEntity(failedMsg string, hostName string) error {
 func ErrCreatingUserDataDirectory(dir string) error {
 	return errors.New(ErrCreatingUserDataDirectoryCode, errors.Fatal, []string{"Unable to create the directory for storing user data at: ", dir}, []string{"Unable to create the directory for storing user data at: ", dir}, []string{}, []string{})
 }
+
+func ErrContentHash(err error, entityType entity.EntityType) error {
+	return errors.New(ErrContentHashCode, errors.Alert, []string{fmt.Sprintf("Unable to compute the content hash of the %s", entityType)}, []string{err.Error()}, []string{"The definition contains values that cannot be encoded as JSON."}, []string{"Verify that the definition is valid JSON / YAML and conforms to its schema."})
+}
+
+func ErrInvalidQuery(err error) error {
+	return errors.New(ErrInvalidQueryCode, errors.Alert, []string{"Invalid registry filter"}, []string{err.Error()}, []string{"The filter expression refers to an unknown field or uses an unsupported operator.", "The cursor is malformed or was not returned by the registry."}, []string{"Use the fields and operators supported by the filter of the entity being queried.", "Use the cursor returned with the previous page of results."})
+}
+
+func ErrSearchIndex(err error) error {
+	return errors.New(ErrSearchIndexCode, errors.Alert, []string{"Unable to search the registry"}, []string{err.Error()}, []string{"The search index could not be created or updated.", "Registry might be inaccessible at the moment"}, []string{"Rebuild the search index using RebuildSearchIndex.", "If the registry is inaccesible, please try again after some time"})
+}
+
+func ErrSchemaDiff(err error, kind string) error {
+	return errors.New(ErrSchemaDiffCode, errors.Alert, []string{fmt.Sprintf("Unable to compare the schemas of component %s between model versions", kind)}, []string{err.Error()}, []string{"The schema of the component is not valid JSON."}, []string{"Verify that the schema of the component is a valid JSON schema."})
+}
+
+func ErrExport(err error) error {
+	return errors.New(ErrExportCode, errors.Alert, []string{"Unable to export models from the registry"}, []string{err.Error()}, []string{"The filter does not select models.", "The output directory is not writable.", "Registry might be inaccessible at the moment"}, []string{"Use a model filter to select the models to export.", "Verify the permissions of the output directory.", "If the registry is inaccesible, please try again after some time"})
+}
+
+func ErrResolveSelector(err error, selector string) error {
+	return errors.New(ErrResolveSelectorCode, errors.Alert, []string{fmt.Sprintf("Unable to resolve the relationship selector %s", selector)}, []string{err.Error()}, []string{"Registry might be inaccessible at the moment"}, []string{"If the registry is inaccesible, please try again after some time"})
+}
@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/models/oci"
+	"github.com/layer5io/meshkit/utils"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/model"
+)
+
+// ExportFormat is the packaging of an exported model.
+type ExportFormat string
+
+const (
+	// ExportDir writes every model to a directory.
+	ExportDir ExportFormat = "dir"
+	// ExportTar writes every model to a gzipped tarball.
+	ExportTar ExportFormat = "tar"
+	// ExportOCI writes every model to an OCI image tarball.
+	ExportOCI ExportFormat = "oci"
+)
+
+// ExportOptions configures RegistryManager.Export.
+type ExportOptions struct {
+	// OutputDir is the directory the bundles are written to, it is created if it does not exist.
+	OutputDir string
+	Format    ExportFormat
+	// Assets is the asset store the SVGs were stored in during registration, e.g. a registration.AssetStore.
+	// The SVG references stored in the registry are resolved through it and inlined into the exported definitions.
+	Assets SVGResolver
+}
+
+// SVGResolver returns the SVG stored under a reference.
+type SVGResolver interface {
+	Get(ref string) (string, error)
+}
+
+// ExportedModel describes a model written by Export.
+type ExportedModel struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Path          string `json:"path"`
+	Components    int    `json:"components"`
+	Relationships int    `json:"relationships"`
+}
+
+/*
+Export writes the models selected by the filter, together with their components and relationships, to bundles that can be imported again with models/registration.
+Each model is written to its own bundle using the layout:
+
+	<name>-<version>/
+		model.json
+		components/<kind>-<id>.json
+		relationships/<kind>-<type>-<subtype>-<id>.json
+
+Directories are used as they are, tarballs and OCI images contain the directory.
+*/
+func (rm *RegistryManager) Export(f entity.Filter, opts ExportOptions) ([]ExportedModel, error) {
+	if opts.Format == "" {
+		opts.Format = ExportDir
+	}
+	if opts.Format != ExportDir && opts.Format != ExportTar && opts.Format != ExportOCI {
+		return nil, ErrExport(fmt.Errorf("unsupported export format %q", opts.Format))
+	}
+
+	entities, _, _, err := rm.GetEntities(f)
+	if err != nil {
+		return nil, ErrExport(err)
+	}
+
+	err = os.MkdirAll(opts.OutputDir, 0755)
+	if err != nil {
+		return nil, ErrExport(utils.ErrCreateDir(err, opts.OutputDir))
+	}
+
+	exported := []ExportedModel{}
+	for _, en := range entities {
+		m, ok := en.(*model.ModelDefinition)
+		if !ok {
+			return nil, ErrExport(fmt.Errorf("filter returned %s, only models can be exported", en.Type()))
+		}
+		em, err := rm.exportModel(*m, opts)
+		if err != nil {
+			return nil, ErrExport(fmt.Errorf("model %s: %w", m.Name, err))
+		}
+		exported = append(exported, em)
+	}
+	return exported, nil
+}
+
+func (rm *RegistryManager) exportModel(m model.ModelDefinition, opts ExportOptions) (ExportedModel, error) {
+	var components []component.ComponentDefinition
+	err := rm.db.Where("model_id = ?", m.Id).Find(&components).Error
+	if err != nil {
+		return ExportedModel{}, err
+	}
+	var relationships []relationship.RelationshipDefinition
+	err = rm.db.Where("model_id = ?", m.Id).Find(&relationships).Error
+	if err != nil {
+		return ExportedModel{}, err
+	}
+
+	name := utils.FormatName(fmt.Sprintf("%s-%s", m.Name, m.Model.Version))
+	// directories are staged next to their destination, so that they can be renamed into place
+	tmpDir := ""
+	if opts.Format == ExportDir {
+		tmpDir = opts.OutputDir
+	}
+	stagingDir, err := os.MkdirTemp(tmpDir, ".model-export-")
+	if err != nil {
+		return ExportedModel{}, utils.ErrCreateDir(err, stagingDir)
+	}
+	defer os.RemoveAll(stagingDir)
+	stagingDir = filepath.Join(stagingDir, name)
+
+	err = writeModelDir(stagingDir, m, components, relationships, opts.Assets)
+	if err != nil {
+		return ExportedModel{}, err
+	}
+
+	var path string
+	switch opts.Format {
+	case ExportDir:
+		path = filepath.Join(opts.OutputDir, name)
+		err = replaceDir(stagingDir, path)
+	case ExportTar:
+		path = filepath.Join(opts.OutputDir, name+".tar.gz")
+		err = writeTarball(stagingDir, path)
+	case ExportOCI:
+		path = filepath.Join(opts.OutputDir, name+".tar")
+		err = writeOCIImage(stagingDir, path, name, m)
+	}
+	if err != nil {
+		return ExportedModel{}, err
+	}
+
+	return ExportedModel{
+		Name:          m.Name,
+		Version:       m.Model.Version,
+		Path:          path,
+		Components:    len(components),
+		Relationships: len(relationships),
+	}, nil
+}
+
+// replaceDir renames the directory to dest, replacing the files of a previous export of the model instead of merging with them.
+func replaceDir(dir, dest string) error {
+	previous := dest + ".previous"
+	if err := os.RemoveAll(previous); err != nil {
+		return err
+	}
+	if err := os.Rename(dest, previous); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(dir, dest); err != nil {
+		// restore the previous export
+		_ = os.Rename(previous, dest)
+		return err
+	}
+	return os.RemoveAll(previous)
+}
+
+func writeModelDir(dir string, m model.ModelDefinition, components []component.ComponentDefinition, relationships []relationship.RelationshipDefinition, assets SVGResolver) error {
+	for _, d := range []string{dir, filepath.Join(dir, "components"), filepath.Join(dir, "relationships")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return utils.ErrCreateDir(err, d)
+		}
+	}
+
+	// components and relationships are written to their own files
+	m.Components = nil
+	m.Relationships = nil
+	if m.Metadata != nil {
+		m.Metadata.SvgColor = resolveSVG(m.Metadata.SvgColor, assets)
+		m.Metadata.SvgWhite = resolveSVG(m.Metadata.SvgWhite, assets)
+		if m.Metadata.SvgComplete != nil {
+			svgComplete := resolveSVG(*m.Metadata.SvgComplete, assets)
+			m.Metadata.SvgComplete = &svgComplete
+		}
+	}
+	if err := utils.WriteJSONToFile(filepath.Join(dir, "model.json"), m); err != nil {
+		return err
+	}
+
+	for _, comp := range components {
+		comp.Model = m
+		if comp.Styles != nil {
+			comp.Styles.SvgColor = resolveSVG(comp.Styles.SvgColor, assets)
+			comp.Styles.SvgWhite = resolveSVG(comp.Styles.SvgWhite, assets)
+			comp.Styles.SvgComplete = resolveSVG(comp.Styles.SvgComplete, assets)
+		}
+		// components of the same kind, e.g. of different API groups, are told apart by their id
+		filename := utils.FormatName(fmt.Sprintf("%s-%s", comp.Component.Kind, comp.Id)) + ".json"
+		if err := utils.WriteJSONToFile(filepath.Join(dir, "components", filename), comp); err != nil {
+			return err
+		}
+	}
+
+	for _, rel := range relationships {
+		rel.Model = m
+		filename := utils.FormatName(fmt.Sprintf("%s-%s-%s-%s", rel.Kind, rel.RelationshipType, rel.SubType, rel.Id)) + ".json"
+		if err := utils.WriteJSONToFile(filepath.Join(dir, "relationships", filename), rel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveSVG returns the content of the SVG a registered entity points to.
+// During registration SVGs are replaced by their reference in the asset store.
+// Values which are not references, or which are not in the asset store, are returned unchanged.
+func resolveSVG(value string, assets SVGResolver) string {
+	if assets == nil || value == "" || strings.Contains(value, "<svg") {
+		return value
+	}
+	svg, err := assets.Get(value)
+	if err != nil {
+		return value
+	}
+	return svg
+}
+
+func writeTarball(src, dest string) error {
+	file, err := os.Create(dest)
+	if err != nil {
+		return utils.ErrCreateFile(err, dest)
+	}
+	defer file.Close()
+	if err := utils.Compress(src, file); err != nil {
+		return utils.ErrCompressToTarGZ(err, src)
+	}
+	return nil
+}
+
+func writeOCIImage(src, dest, name string, m model.ModelDefinition) error {
+	img, err := oci.BuildImage(src, oci.WithModel(m))
+	if err != nil {
+		return err
+	}
+	return oci.SaveOCIArtifact(img, dest, name)
+}
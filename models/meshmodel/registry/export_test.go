@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/meshery/schemas/models/v1beta1/model"
+)
+
+// modelsFilter selects every registered model.
+type modelsFilter struct{}
+
+func (modelsFilter) Create(map[string]interface{}) {}
+
+func (modelsFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, error) {
+	var models []model.ModelDefinition
+	if err := db.Find(&models).Error; err != nil {
+		return nil, 0, 0, err
+	}
+	entities := []entity.Entity{}
+	for i := range models {
+		entities = append(entities, &models[i])
+	}
+	return entities, int64(len(entities)), len(entities), nil
+}
+
+func (modelsFilter) GetById(db *database.Handler) (entity.Entity, error) {
+	return nil, nil
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if !info.IsDir() {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestExportDir(t *testing.T) {
+	rm := newTestRegistryManager(t)
+	m := testModel()
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatal(err)
+	}
+	pod := testComponent(m, "Pod")
+	if _, _, err := rm.RegisterEntity(m.Registrant, &pod); err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	dir := filepath.Join(out, "kubernetes-1.29.0")
+	// a component exported before, which is no longer registered
+	if err := os.MkdirAll(filepath.Join(dir, "components"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "components", "removed.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		exported, err := rm.Export(modelsFilter{}, ExportOptions{OutputDir: out})
+		if err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if len(exported) != 1 || exported[0].Path != dir || exported[0].Components != 1 {
+			t.Fatalf("Export() = %+v", exported)
+		}
+		want := []string{
+			"kubernetes-1.29.0/components/pod-" + pod.Id.String() + ".json",
+			"kubernetes-1.29.0/model.json",
+		}
+		if got := listFiles(t, out); !reflect.DeepEqual(got, want) {
+			t.Errorf("export %d wrote %v, want %v", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	models "github.com/layer5io/meshkit/models/meshmodel/core/v1beta1"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/utils"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/model"
+)
+
+// ChangeType describes how an entity differs between two imports.
+type ChangeType string
+
+const (
+	EntityAdded    ChangeType = "added"
+	EntityModified ChangeType = "modified"
+	EntityRemoved  ChangeType = "removed"
+)
+
+// EntityFingerprint identifies a registered entity by its identity key and the hash of its content.
+type EntityFingerprint struct {
+	Type entity.EntityType `json:"type"`
+	Key  string            `json:"key"`
+	Hash string            `json:"hash"`
+}
+
+// EntityChange is a single difference between two imports.
+type EntityChange struct {
+	EntityFingerprint
+	Change       ChangeType `json:"change"`
+	PreviousHash string     `json:"previousHash,omitempty"`
+}
+
+// ContentHash returns the SHA-256 hash of the canonical JSON form of the entity.
+// Fields that are assigned by the registry during registration (ids, status, registrant) are
+// left out, so the same definition always hashes to the same value.
+func ContentHash(en entity.Entity) (string, error) {
+	content, err := utils.MarshalAndUnmarshal[entity.Entity, map[string]interface{}](en)
+	if err != nil {
+		return "", ErrContentHash(err, en.Type())
+	}
+
+	delete(content, "id")
+	delete(content, "status")
+
+	switch en.Type() {
+	case entity.Model:
+		delete(content, "components")
+		delete(content, "relationships")
+		delete(content, "registrant")
+		delete(content, "connection_id")
+		if cat, ok := content["category"].(map[string]interface{}); ok {
+			delete(cat, "id")
+		}
+	default:
+		// Components, relationships and policies embed the complete model they belong to,
+		// only the identity of the model is part of their content.
+		if m, ok := content["model"].(map[string]interface{}); ok {
+			content["model"] = map[string]interface{}{
+				"name":    m["name"],
+				"version": m["version"],
+				"model":   m["model"],
+			}
+		}
+	}
+
+	// encoding/json writes map keys in sorted order, which keeps the output canonical.
+	byt, err := json.Marshal(content)
+	if err != nil {
+		return "", ErrContentHash(err, en.Type())
+	}
+	hash := sha256.Sum256(byt)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// EntityKey returns the identity of the entity within a registrant.
+// Two versions of the same entity share a key, while their content hashes differ.
+func EntityKey(en entity.Entity) string {
+	switch e := en.(type) {
+	case *model.ModelDefinition:
+		return fmt.Sprintf("%s@%s", e.Name, e.Model.Version)
+	case *component.ComponentDefinition:
+		return fmt.Sprintf("%s@%s/%s@%s", e.Model.Name, e.Model.Model.Version, e.Component.Kind, e.Component.Version)
+	case *relationship.RelationshipDefinition:
+		return fmt.Sprintf("%s@%s/%s/%s/%s", e.Model.Name, e.Model.Model.Version, e.Kind, e.RelationshipType, e.SubType)
+	case *models.PolicyDefinition:
+		return fmt.Sprintf("%s@%s/%s/%s", e.Model.Name, e.Model.Model.Version, e.Kind, e.SubType)
+	case *category.CategoryDefinition:
+		return e.Name
+	default:
+		return en.GetEntityDetail()
+	}
+}
+
+// NewEntityFingerprint computes the fingerprint of the given entity.
+func NewEntityFingerprint(en entity.Entity) (EntityFingerprint, error) {
+	hash, err := ContentHash(en)
+	if err != nil {
+		return EntityFingerprint{}, err
+	}
+	return EntityFingerprint{
+		Type: en.Type(),
+		Key:  EntityKey(en),
+		Hash: hash,
+	}, nil
+}
+
+// DiffImports compares the fingerprints of two imports and returns the entities that were added,
+// modified or removed in the current import. Unchanged entities are not part of the result.
+func DiffImports(previous, current []EntityFingerprint) []EntityChange {
+	type fingerprintKey struct {
+		entityType entity.EntityType
+		key        string
+	}
+
+	prev := make(map[fingerprintKey]EntityFingerprint, len(previous))
+	for _, fp := range previous {
+		prev[fingerprintKey{fp.Type, fp.Key}] = fp
+	}
+
+	changes := []EntityChange{}
+	seen := make(map[fingerprintKey]struct{}, len(current))
+	for _, fp := range current {
+		k := fingerprintKey{fp.Type, fp.Key}
+		seen[k] = struct{}{}
+		old, ok := prev[k]
+		if !ok {
+			changes = append(changes, EntityChange{EntityFingerprint: fp, Change: EntityAdded})
+			continue
+		}
+		if old.Hash != fp.Hash {
+			changes = append(changes, EntityChange{EntityFingerprint: fp, Change: EntityModified, PreviousHash: old.Hash})
+		}
+	}
+	for k, fp := range prev {
+		if _, ok := seen[k]; !ok {
+			changes = append(changes, EntityChange{EntityFingerprint: fp, Change: EntityRemoved, PreviousHash: fp.Hash})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
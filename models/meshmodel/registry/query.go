@@ -0,0 +1,366 @@
+package registry
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/gofrs/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operator is the comparison performed by a predicate of a filter expression.
+type Operator string
+
+const (
+	Equal              Operator = "eq"
+	NotEqual           Operator = "ne"
+	In                 Operator = "in"
+	NotIn              Operator = "nin"
+	Like               Operator = "like"
+	GreaterThan        Operator = "gt"
+	GreaterThanOrEqual Operator = "gte"
+	LessThan           Operator = "lt"
+	LessThanOrEqual    Operator = "lte"
+	Exists             Operator = "exists"
+	// SemverRange matches versions satisfying a constraint such as ">= 1.2, < 2.0"
+	SemverRange Operator = "semver"
+)
+
+var comparisons = map[Operator]string{
+	Equal:              "=",
+	NotEqual:           "<>",
+	Like:               "LIKE",
+	GreaterThan:        ">",
+	GreaterThanOrEqual: ">=",
+	LessThan:           "<",
+	LessThanOrEqual:    "<=",
+}
+
+// jsonPathRegex restricts JSONPath predicates to dotted member access, e.g. $.capabilities.kind
+var jsonPathRegex = regexp.MustCompile(`^\$(\.[A-Za-z0-9_]+)*$`)
+
+// Expression is a node in a filter expression tree.
+// A node is either a combination of other expressions (And, Or, Not) or a predicate on a single field.
+//
+// Example, components of the kubernetes model for versions 1.29 and above which are not annotations:
+//
+//	{
+//	  "and": [
+//	    {"field": "modelName", "op": "eq", "value": "kubernetes"},
+//	    {"field": "version", "op": "semver", "value": ">= 1.29"},
+//	    {"field": "metadata", "path": "$.isAnnotation", "op": "eq", "value": false}
+//	  ]
+//	}
+type Expression struct {
+	And []Expression `json:"and,omitempty"`
+	Or  []Expression `json:"or,omitempty"`
+	Not *Expression  `json:"not,omitempty"`
+
+	Field string `json:"field,omitempty"`
+	// Path selects a value nested inside a JSON field, e.g. $.isAnnotation
+	Path     string      `json:"path,omitempty"`
+	Operator Operator    `json:"op,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+}
+
+// Query holds the parts of a filter which are common to every registry entity.
+type Query struct {
+	Where *Expression `json:"where,omitempty"`
+	// Fields restricts the returned attributes of the entity, identifiers are always returned.
+	Fields []string `json:"fields,omitempty"`
+	// Cursor is the NextCursor returned with the previous page.
+	// When Cursor is set or Paginate is true, results are ordered by id and returned a page (Limit) at a time.
+	Cursor   string `json:"cursor,omitempty"`
+	Paginate bool   `json:"paginate,omitempty"`
+}
+
+// Field describes where a field which can be used in a filter expression is stored.
+type Field struct {
+	Table  string // table the column belongs to
+	Column string // column, qualified by the table name
+	// Path is set for values which are stored inside a JSON column, e.g. $.kind
+	Path string
+	// JSON is true for JSON columns which accept JSONPath predicates
+	JSON bool
+}
+
+// FieldSet maps the field names accepted by a filter to their storage.
+type FieldSet map[string]Field
+
+// DecodeFilter populates a filter from the given map. Keys are matched case insensitively against the filter's fields,
+// `query` is decoded into the Query of the filter.
+func DecodeFilter(m map[string]interface{}, filter interface{}) error {
+	if m == nil {
+		return nil
+	}
+	byt, err := json.Marshal(m)
+	if err != nil {
+		return ErrInvalidQuery(err)
+	}
+	if err := json.Unmarshal(byt, filter); err != nil {
+		return ErrInvalidQuery(err)
+	}
+	return nil
+}
+
+// CursorPagination reports whether results should be paginated with cursors instead of offsets.
+func (q *Query) CursorPagination() bool {
+	return q != nil && (q.Cursor != "" || q.Paginate)
+}
+
+// Filter adds the Where expression of the query to db.
+func (q *Query) Filter(db *gorm.DB, fields FieldSet) (*gorm.DB, error) {
+	if q == nil || q.Where == nil {
+		return db, nil
+	}
+	expr, err := q.Where.build(db, fields)
+	if err != nil {
+		return db, err
+	}
+	return db.Where(expr), nil
+}
+
+// Select returns the columns selected by the projection of the query, followed by the required columns.
+// Only columns of table, the table of the entity, can be selected: the columns of joined tables would not be mapped to the entity.
+// It returns nil when the query does not restrict the fields.
+func (q *Query) Select(table string, fields FieldSet, required ...string) ([]string, error) {
+	if q == nil || len(q.Fields) == 0 {
+		return nil, nil
+	}
+	columns := append([]string{}, required...)
+	for _, name := range q.Fields {
+		f, ok := fields[name]
+		if !ok || f.Path != "" || f.Table != table {
+			return nil, ErrInvalidQuery(fmt.Errorf("field %q cannot be selected", name))
+		}
+		columns = append(columns, f.Column)
+	}
+	return columns, nil
+}
+
+// Page orders db by idColumn and, if a cursor is set, restricts it to the entities after the cursor.
+func (q *Query) Page(db *gorm.DB, idColumn string) (*gorm.DB, error) {
+	db = db.Order(idColumn)
+	if q.Cursor == "" {
+		return db, nil
+	}
+	id, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return db, err
+	}
+	return db.Where(idColumn+" > ?", id), nil
+}
+
+// NextCursor returns the cursor of the page after the one ending with lastID.
+// An empty cursor is returned if the page is not full, as there are no more results.
+func NextCursor(lastID uuid.UUID, pageLen, limit int) string {
+	if limit == 0 || pageLen < limit {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID.String()))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	byt, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidQuery(fmt.Errorf("invalid cursor: %w", err))
+	}
+	id, err := uuid.FromString(string(byt))
+	if err != nil {
+		return "", ErrInvalidQuery(fmt.Errorf("invalid cursor: %w", err))
+	}
+	return id.String(), nil
+}
+
+func (e Expression) build(db *gorm.DB, fields FieldSet) (clause.Expression, error) {
+	switch {
+	case len(e.And) > 0:
+		exprs, err := buildAll(db, e.And, fields)
+		if err != nil {
+			return nil, err
+		}
+		return clause.And(exprs...), nil
+	case len(e.Or) > 0:
+		exprs, err := buildAll(db, e.Or, fields)
+		if err != nil {
+			return nil, err
+		}
+		return clause.Or(exprs...), nil
+	case e.Not != nil:
+		expr, err := e.Not.build(db, fields)
+		if err != nil {
+			return nil, err
+		}
+		return clause.Not(expr), nil
+	}
+	return e.predicate(db, fields)
+}
+
+func buildAll(db *gorm.DB, exprs []Expression, fields FieldSet) ([]clause.Expression, error) {
+	built := make([]clause.Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		b, err := expr.build(db, fields)
+		if err != nil {
+			return nil, err
+		}
+		built = append(built, b)
+	}
+	return built, nil
+}
+
+func (e Expression) predicate(db *gorm.DB, fields FieldSet) (clause.Expression, error) {
+	f, ok := fields[e.Field]
+	if !ok {
+		return nil, ErrInvalidQuery(fmt.Errorf("unknown field %q", e.Field))
+	}
+	path := f.Path
+	if e.Path != "" {
+		if !f.JSON {
+			return nil, ErrInvalidQuery(fmt.Errorf("field %q does not support JSONPath predicates", e.Field))
+		}
+		path = e.Path
+	}
+	if path != "" && !jsonPathRegex.MatchString(path) {
+		return nil, ErrInvalidQuery(fmt.Errorf("unsupported JSONPath %q", path))
+	}
+
+	dialect := db.Dialector.Name()
+	column := f.Column
+	if path != "" {
+		column = jsonExtract(dialect, f.Column, path, e.Value)
+	}
+	value := e.Value
+	if path != "" {
+		value = jsonValue(dialect, e.Value)
+	}
+
+	switch e.Operator {
+	case In, NotIn:
+		values, ok := e.Value.([]interface{})
+		if !ok {
+			return nil, ErrInvalidQuery(fmt.Errorf("%s expects a list of values for field %q", e.Operator, e.Field))
+		}
+		if path != "" {
+			numbers := numeric(values)
+			for i := range values {
+				values[i] = jsonValue(dialect, values[i])
+				// Postgres extracts text, which is compared with the list as text unless the list holds numbers only
+				if n, ok := values[i].(float64); ok && dialect == "postgres" && !numbers {
+					values[i] = strconv.FormatFloat(n, 'f', -1, 64)
+				}
+			}
+		}
+		op := "IN"
+		if e.Operator == NotIn {
+			op = "NOT IN"
+		}
+		return clause.Expr{SQL: fmt.Sprintf("%s %s ?", column, op), Vars: []interface{}{values}}, nil
+	case Exists:
+		if exists, _ := e.Value.(bool); !exists && e.Value != nil {
+			return clause.Expr{SQL: column + " IS NULL"}, nil
+		}
+		return clause.Expr{SQL: column + " IS NOT NULL"}, nil
+	case SemverRange:
+		constraint, ok := e.Value.(string)
+		if !ok {
+			return nil, ErrInvalidQuery(fmt.Errorf("semver expects a version constraint for field %q", e.Field))
+		}
+		versions, err := matchingVersions(db, f.Table, column, constraint)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return clause.Expr{SQL: "1 = 0"}, nil
+		}
+		return clause.Expr{SQL: column + " IN ?", Vars: []interface{}{versions}}, nil
+	}
+
+	op, ok := comparisons[e.Operator]
+	if !ok {
+		return nil, ErrInvalidQuery(fmt.Errorf("unsupported operator %q", e.Operator))
+	}
+	return clause.Expr{SQL: fmt.Sprintf("%s %s ?", column, op), Vars: []interface{}{value}}, nil
+}
+
+// jsonExtract returns the SQL expression selecting the value at path inside a JSON column.
+// Registry JSON columns are stored as bytes, hence the conversion on Postgres.
+func jsonExtract(dialect, column, path string, value interface{}) string {
+	if dialect == "postgres" {
+		keys := strings.Split(strings.TrimPrefix(path, "$."), ".")
+		expr := fmt.Sprintf("(convert_from(%s, 'UTF8')::jsonb #>> '{%s}')", column, strings.Join(keys, ","))
+		if numeric(value) {
+			expr = fmt.Sprintf("CAST(%s AS numeric)", expr)
+		}
+		return expr
+	}
+	return fmt.Sprintf("json_extract(%s, '%s')", column, path)
+}
+
+// numeric reports whether the value, or every value of the list, is a number.
+func numeric(value interface{}) bool {
+	switch v := value.(type) {
+	case float64:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(float64); !ok {
+				return false
+			}
+		}
+		return len(v) > 0
+	}
+	return false
+}
+
+// jsonValue converts a value compared with an extracted JSON value to the representation used by the database.
+// SQLite extracts booleans as integers, while Postgres extracts every value as text.
+func jsonValue(dialect string, value interface{}) interface{} {
+	b, ok := value.(bool)
+	if !ok {
+		return value
+	}
+	if dialect == "postgres" {
+		return fmt.Sprint(b)
+	}
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// matchingVersions returns the distinct values of column which satisfy the semver constraint.
+// Values which are not valid semantic versions never match.
+func matchingVersions(db *gorm.DB, table, column, constraint string) ([]string, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, ErrInvalidQuery(fmt.Errorf("invalid version constraint %q: %w", constraint, err))
+	}
+	var candidates []sql.NullString
+	err = db.Session(&gorm.Session{NewDB: true}).
+		Raw(fmt.Sprintf("SELECT DISTINCT %s FROM %s", column, table)).
+		Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	for _, candidate := range candidates {
+		if !candidate.Valid {
+			continue
+		}
+		v, err := semver.NewVersion(candidate.String)
+		if err != nil {
+			continue
+		}
+		if c.Check(v) {
+			versions = append(versions, candidate.String)
+		}
+	}
+	return versions, nil
+}
@@ -0,0 +1,305 @@
+package registry
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshkit/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testFields = FieldSet{
+	"id":        {Table: "entities", Column: "entities.id"},
+	"name":      {Table: "entities", Column: "entities.name"},
+	"version":   {Table: "entities", Column: "entities.version"},
+	"metadata":  {Table: "entities", Column: "entities.metadata", JSON: true},
+	"kind":      {Table: "entities", Column: "entities.metadata", Path: "$.kind"},
+	"modelName": {Table: "models", Column: "models.name"},
+}
+
+type testEntity struct {
+	ID       string
+	Name     string
+	Version  *string
+	Metadata string
+}
+
+func newTestDB(t *testing.T, entities ...testEntity) *gorm.DB {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := h.DB.Session(&gorm.Session{Logger: logger.Discard})
+	if err := db.Exec("CREATE TABLE entities (id text, name text, version text, metadata text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entities {
+		if err := db.Table("entities").Create(&e).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func decodeExpression(t *testing.T, s string) *Expression {
+	t.Helper()
+	e := &Expression{}
+	if err := json.Unmarshal([]byte(s), e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestQueryFilter(t *testing.T) {
+	db := newTestDB(t)
+	tests := []struct {
+		name     string
+		where    string
+		wantSQL  string
+		wantVars []interface{}
+		wantErr  bool
+	}{
+		{"equal", `{"field": "name", "op": "eq", "value": "kubernetes"}`, "entities.name = ?", []interface{}{"kubernetes"}, false},
+		{"like", `{"field": "name", "op": "like", "value": "kube%"}`, "entities.name LIKE ?", []interface{}{"kube%"}, false},
+		{"in", `{"field": "name", "op": "in", "value": ["a", "b"]}`, "entities.name IN (?,?)", []interface{}{"a", "b"}, false},
+		{"not in", `{"field": "name", "op": "nin", "value": ["a"]}`, "entities.name NOT IN (?)", []interface{}{"a"}, false},
+		{"exists", `{"field": "version", "op": "exists", "value": true}`, "entities.version IS NOT NULL", nil, false},
+		{"not exists", `{"field": "version", "op": "exists", "value": false}`, "entities.version IS NULL", nil, false},
+		{"field with path", `{"field": "kind", "op": "ne", "value": "Pod"}`, "json_extract(entities.metadata, '$.kind') <> ?", []interface{}{"Pod"}, false},
+		{"json path", `{"field": "metadata", "path": "$.isAnnotation", "op": "eq", "value": true}`, "json_extract(entities.metadata, '$.isAnnotation') = ?", []interface{}{1}, false},
+		{
+			"combination",
+			`{"and": [{"field": "name", "op": "eq", "value": "a"}, {"or": [{"field": "kind", "op": "eq", "value": "Pod"}, {"not": {"field": "version", "op": "exists"}}]}]}`,
+			"entities.name = ? AND (json_extract(entities.metadata, '$.kind') = ? OR NOT entities.version IS NOT NULL)",
+			[]interface{}{"a", "Pod"},
+			false,
+		},
+		{"unknown field", `{"field": "size", "op": "eq", "value": 1}`, "", nil, true},
+		{"json path on plain column", `{"field": "name", "path": "$.x", "op": "eq", "value": 1}`, "", nil, true},
+		{"unsupported json path", `{"field": "metadata", "path": "$.a[0]", "op": "eq", "value": 1}`, "", nil, true},
+		{"unsupported operator", `{"field": "name", "op": "regex", "value": "a"}`, "", nil, true},
+		{"in without a list", `{"field": "name", "op": "in", "value": "a"}`, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Where: decodeExpression(t, tt.where)}
+			dryRun := db.Session(&gorm.Session{DryRun: true})
+			finder, err := q.Filter(dryRun.Table("entities"), testFields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Filter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			stmt := finder.Find(&[]testEntity{}).Statement
+			wantSQL := "SELECT * FROM `entities` WHERE " + tt.wantSQL
+			if got := stmt.SQL.String(); got != wantSQL {
+				t.Errorf("Filter() SQL = %s, want %s", got, wantSQL)
+			}
+			if len(stmt.Vars) != len(tt.wantVars) || (len(tt.wantVars) > 0 && !reflect.DeepEqual(stmt.Vars, tt.wantVars)) {
+				t.Errorf("Filter() vars = %v, want %v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestQueryFilterSemverRange(t *testing.T) {
+	version := func(v string) *string { return &v }
+	db := newTestDB(t,
+		testEntity{ID: "1", Version: version("1.2.0")},
+		testEntity{ID: "2", Version: version("1.10.0")},
+		testEntity{ID: "3", Version: version("v1.29.1")},
+		testEntity{ID: "4", Version: version("2.0.0")},
+		testEntity{ID: "5", Version: version("1.0.0")},
+		testEntity{ID: "6", Version: version("latest")},
+		testEntity{ID: "7"},
+	)
+	tests := []struct {
+		constraint string
+		wantIDs    []string
+		wantErr    bool
+	}{
+		{">= 1.2, < 2.0", []string{"1", "2", "3"}, false},
+		{"^1.10", []string{"2", "3"}, false},
+		{"~2", []string{"4"}, false},
+		{"> 3", []string{}, false},
+		{"not a constraint", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			q := &Query{Where: &Expression{Field: "version", Operator: SemverRange, Value: tt.constraint}}
+			finder, err := q.Filter(db.Table("entities"), testFields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Filter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			ids := []string{}
+			if err := finder.Order("id").Pluck("id", &ids).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("semver %q matched %v, want %v", tt.constraint, ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestQueryCursorPagination(t *testing.T) {
+	entities := []testEntity{}
+	want := []string{}
+	for i := 0; i < 5; i++ {
+		id := uuid.Must(uuid.NewV4()).String()
+		entities = append(entities, testEntity{ID: id})
+		want = append(want, id)
+	}
+	sort.Strings(want)
+	db := newTestDB(t, entities...)
+
+	const limit = 2
+	q := &Query{Paginate: true}
+	got := []string{}
+	pages := 0
+	for {
+		finder, err := q.Page(db.Table("entities"), "entities.id")
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		page := []testEntity{}
+		if err := finder.Limit(limit).Find(&page).Error; err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, e := range page {
+			got = append(got, e.ID)
+		}
+		if len(page) == 0 {
+			break
+		}
+		q.Cursor = NextCursor(uuid.FromStringOrNil(page[len(page)-1].ID), len(page), limit)
+		if q.Cursor == "" {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paginated ids = %v, want %v", got, want)
+	}
+	if pages != 3 {
+		t.Errorf("paginated %d pages, want 3", pages)
+	}
+	if !q.CursorPagination() {
+		t.Error("CursorPagination() = false for a paginated query")
+	}
+
+	q.Cursor = "not a cursor"
+	if _, err := q.Page(db.Table("entities"), "entities.id"); err == nil {
+		t.Error("Page() accepted an invalid cursor")
+	}
+}
+
+func TestQuerySelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    []string
+		wantErr bool
+	}{
+		{"no projection", nil, nil, false},
+		{"columns", []string{"name", "metadata"}, []string{"entities.id", "entities.name", "entities.metadata"}, false},
+		{"joined table", []string{"modelName"}, nil, true},
+		{"nested value", []string{"kind"}, nil, true},
+		{"unknown field", []string{"size"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Fields: tt.fields}
+			got, err := q.Select("entities", testFields, "entities.id")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Select() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFilter(t *testing.T) {
+	type filter struct {
+		Name  string
+		Limit int
+		Query *Query
+	}
+	f := &filter{}
+	err := DecodeFilter(map[string]interface{}{
+		"name":  "kubernetes",
+		"LIMIT": 10,
+		"query": map[string]interface{}{"where": map[string]interface{}{"field": "name", "op": "eq", "value": "a"}},
+	}, f)
+	if err != nil {
+		t.Fatalf("DecodeFilter() error = %v", err)
+	}
+	if f.Name != "kubernetes" || f.Limit != 10 || f.Query == nil || f.Query.Where.Field != "name" {
+		t.Errorf("DecodeFilter() = %+v", f)
+	}
+
+	if err := DecodeFilter(map[string]interface{}{"limit": "ten"}, &filter{}); err == nil {
+		t.Error("DecodeFilter() accepted an invalid value")
+	}
+}
+
+func TestQueryFilterPostgresJSONValues(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		where    string
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			"numbers",
+			`{"field": "metadata", "path": "$.replicas", "op": "in", "value": [1, 2.5]}`,
+			`CAST((convert_from(entities.metadata, 'UTF8')::jsonb #>> '{replicas}') AS numeric) IN ($1,$2)`,
+			[]interface{}{1.0, 2.5},
+		},
+		{
+			"mixed values are compared as text",
+			`{"field": "metadata", "path": "$.replicas", "op": "nin", "value": [1, "auto", true]}`,
+			`(convert_from(entities.metadata, 'UTF8')::jsonb #>> '{replicas}') NOT IN ($1,$2,$3)`,
+			[]interface{}{"1", "auto", "true"},
+		},
+		{
+			"strings",
+			`{"field": "kind", "op": "in", "value": ["Pod", "Service"]}`,
+			`(convert_from(entities.metadata, 'UTF8')::jsonb #>> '{kind}') IN ($1,$2)`,
+			[]interface{}{"Pod", "Service"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Where: decodeExpression(t, tt.where)}
+			finder, err := q.Filter(db.Table("entities"), testFields)
+			if err != nil {
+				t.Fatalf("Filter() error = %v", err)
+			}
+			stmt := finder.Find(&[]testEntity{}).Statement
+			if want := "SELECT * FROM \"entities\" WHERE " + tt.wantSQL; stmt.SQL.String() != want {
+				t.Errorf("SQL = %s, want %s", stmt.SQL.String(), want)
+			}
+			if !reflect.DeepEqual(stmt.Vars, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
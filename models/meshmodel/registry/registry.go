@@ -3,17 +3,20 @@ package registry
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/layer5io/meshkit/database"
 	models "github.com/layer5io/meshkit/models/meshmodel/core/v1beta1"
 	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/utils"
 	"github.com/meshery/schemas/models/v1alpha3/relationship"
 	"github.com/meshery/schemas/models/v1beta1/category"
 	"github.com/meshery/schemas/models/v1beta1/component"
 	"github.com/meshery/schemas/models/v1beta1/connection"
 	"github.com/meshery/schemas/models/v1beta1/model"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"gorm.io/gorm/clause"
@@ -36,13 +39,27 @@ type Registry struct {
 	RegistrantID uuid.UUID
 	Entity       uuid.UUID
 	Type         entity.EntityType
+	ContentHash  string `gorm:"index"` // Canonical hash of the registered definition, see ContentHash
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
 
+// DuplicateDefinition groups the registry entries of an entity whose content has been published by more than one registrant.
+type DuplicateDefinition struct {
+	Type        entity.EntityType
+	ContentHash string
+	Registrants []uuid.UUID
+	Entities    []uuid.UUID
+}
+
 // RegistryManager instance will expose methods for registry operations & sits between the database level operations and user facing API handlers.
 type RegistryManager struct {
-	db *database.Handler //This database handler will be used to perform queries inside the database
+	db     *database.Handler //This database handler will be used to perform queries inside the database
+	search searchBackend
+
+	listenersMx  sync.RWMutex
+	listeners    map[int]func(entity.Entity)
+	nextListener int
 }
 
 // NewRegistryManager initializes the registry manager by creating appropriate tables.
@@ -66,6 +83,10 @@ func NewRegistryManager(db *database.Handler) (*RegistryManager, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = rm.setupSearchIndex()
+	if err != nil {
+		return nil, err
+	}
 	return &rm, nil
 }
 func (rm *RegistryManager) Cleanup() {
@@ -76,14 +97,36 @@ func (rm *RegistryManager) Cleanup() {
 		&model.ModelDefinition{},
 		&category.CategoryDefinition{},
 		&relationship.RelationshipDefinition{},
+		searchIndexTable,
 	)
+	// entities are not indexed until the index is created again by RebuildSearchIndex
+	rm.search = ""
 }
+
+// RegisterEntity registers the entity under the given registrant.
+// If the registrant has already registered an entity with the same content hash, nothing is written,
+// the ids of the entity are set to the ones of the registered entity.
 func (rm *RegistryManager) RegisterEntity(h connection.Connection, en entity.Entity) (bool, bool, error) {
 	registrantID, err := h.Create(rm.db)
 	if err != nil {
 		return true, false, err
 	}
 
+	hash, err := ContentHash(en)
+	if err != nil {
+		return false, true, err
+	}
+	registeredID, unchanged, err := rm.registeredEntity(registrantID, en.Type(), hash)
+	if err != nil {
+		return false, true, err
+	}
+	if unchanged {
+		if err := rm.setRegisteredIDs(en, registeredID); err != nil {
+			return false, true, err
+		}
+		return false, false, nil
+	}
+
 	entityID, err := en.Create(rm.db, registrantID)
 	if err != nil {
 		return false, true, err
@@ -94,6 +137,7 @@ func (rm *RegistryManager) RegisterEntity(h connection.Connection, en entity.Ent
 		RegistrantID: registrantID,
 		Entity:       entityID,
 		Type:         en.Type(),
+		ContentHash:  hash,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -101,9 +145,132 @@ func (rm *RegistryManager) RegisterEntity(h connection.Connection, en entity.Ent
 	if err != nil {
 		return false, false, err
 	}
+	if entityID != uuid.Nil {
+		// The entity is registered at this point, a failure to index it only affects search results
+		// and is recovered by RebuildSearchIndex.
+		if err := rm.indexEntity(entityID, en); err != nil {
+			logrus.Error(err)
+		}
+	}
+	rm.notifyRegistered(en)
 	return false, false, nil
 }
 
+// OnRegister calls fn with every entity registered from now on, entities whose content is already registered are not notified.
+// fn is called synchronously by RegisterEntity and must not block. The returned function removes the listener.
+func (rm *RegistryManager) OnRegister(fn func(entity.Entity)) func() {
+	rm.listenersMx.Lock()
+	defer rm.listenersMx.Unlock()
+	if rm.listeners == nil {
+		rm.listeners = map[int]func(entity.Entity){}
+	}
+	id := rm.nextListener
+	rm.nextListener++
+	rm.listeners[id] = fn
+	return func() {
+		rm.listenersMx.Lock()
+		defer rm.listenersMx.Unlock()
+		delete(rm.listeners, id)
+	}
+}
+
+func (rm *RegistryManager) notifyRegistered(en entity.Entity) {
+	rm.listenersMx.RLock()
+	defer rm.listenersMx.RUnlock()
+	for _, fn := range rm.listeners {
+		fn(en)
+	}
+}
+
+// registeredEntity returns the id of the entity of the given type and content hash the registrant has already registered, if any.
+// The id is uuid.Nil for entities which are registered without being stored, e.g. components without a schema.
+func (rm *RegistryManager) registeredEntity(registrantID uuid.UUID, entityType entity.EntityType, hash string) (uuid.UUID, bool, error) {
+	var entries []Registry
+	err := rm.db.
+		Where("registrant_id = ? AND type = ? AND content_hash = ?", registrantID, entityType, hash).
+		Order("created_at").
+		Limit(1).
+		Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return uuid.Nil, false, err
+	}
+	return entries[0].Entity, true, nil
+}
+
+// setRegisteredIDs sets the ids of the entity, and of the entities it references, to the ones stored for the registered entity with the given id.
+func (rm *RegistryManager) setRegisteredIDs(en entity.Entity, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return nil
+	}
+	switch e := en.(type) {
+	case *model.ModelDefinition:
+		var stored model.ModelDefinition
+		if err := rm.db.First(&stored, "id = ?", id).Error; err != nil {
+			return err
+		}
+		e.Id = stored.Id
+		e.CategoryId = stored.CategoryId
+		e.Category.Id = stored.CategoryId
+		e.RegistrantId = stored.RegistrantId
+	case *component.ComponentDefinition:
+		var stored component.ComponentDefinition
+		if err := rm.db.First(&stored, "id = ?", id).Error; err != nil {
+			return err
+		}
+		e.Id = stored.Id
+		e.ModelId = stored.ModelId
+		e.Model.Id = stored.ModelId
+	case *relationship.RelationshipDefinition:
+		var stored relationship.RelationshipDefinition
+		if err := rm.db.First(&stored, "id = ?", id).Error; err != nil {
+			return err
+		}
+		e.Id = stored.Id
+		e.ModelId = stored.ModelId
+		e.Model.Id = stored.ModelId
+	case *models.PolicyDefinition:
+		var stored models.PolicyDefinition
+		if err := rm.db.First(&stored, "id = ?", id).Error; err != nil {
+			return err
+		}
+		e.ID = stored.ID
+		e.ModelID = stored.ModelID
+		e.Model.Id = stored.ModelID
+	}
+	return nil
+}
+
+// GetDuplicateDefinitions returns the definitions that have been published with identical content by more than one registrant.
+func (rm *RegistryManager) GetDuplicateDefinitions() ([]DuplicateDefinition, error) {
+	var entries []Registry
+	duplicateHashes := rm.db.Model(&Registry{}).
+		Select("content_hash").
+		Where("content_hash <> ''").
+		Group("content_hash").
+		Having("COUNT(DISTINCT registrant_id) > 1")
+	err := rm.db.Where("content_hash IN (?)", duplicateHashes).
+		Order("content_hash").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []DuplicateDefinition{}
+	for _, entry := range entries {
+		n := len(duplicates)
+		if n == 0 || duplicates[n-1].ContentHash != entry.ContentHash {
+			duplicates = append(duplicates, DuplicateDefinition{Type: entry.Type, ContentHash: entry.ContentHash})
+			n++
+		}
+		dup := &duplicates[n-1]
+		dup.Entities = append(dup.Entities, entry.Entity)
+		if !utils.Contains(dup.Registrants, entry.RegistrantID) {
+			dup.Registrants = append(dup.Registrants, entry.RegistrantID)
+		}
+	}
+	return duplicates, nil
+}
+
 // UpdateEntityStatus updates the ignore status of an entity based on the provided parameters.
 // By default during models generation ignore is set to false
 func (rm *RegistryManager) UpdateEntityStatus(ID string, status string, entityType string) error {
@@ -0,0 +1,187 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/meshery/schemas/models/v1alpha3"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/connection"
+	"github.com/meshery/schemas/models/v1beta1/model"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRegistryManager(t *testing.T) *RegistryManager {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	rm, err := NewRegistryManager(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rm
+}
+
+func testModel() model.ModelDefinition {
+	return model.ModelDefinition{
+		SchemaVersion: v1beta1.ModelSchemaVersion,
+		Name:          "kubernetes",
+		DisplayName:   "Kubernetes",
+		Status:        "enabled",
+		Category:      category.CategoryDefinition{Name: "Orchestration"},
+		Model:         model.Model{Version: "1.29.0"},
+		Registrant:    connection.Connection{Kind: "github"},
+	}
+}
+
+func testComponent(m model.ModelDefinition, kind string) component.ComponentDefinition {
+	return component.ComponentDefinition{
+		SchemaVersion: v1beta1.ComponentSchemaVersion,
+		DisplayName:   kind,
+		Component:     component.Component{Kind: kind, Version: "v1", Schema: `{"type": "object"}`},
+		Model:         m,
+	}
+}
+
+func countRegistryEntries(t *testing.T, rm *RegistryManager, entityType entity.EntityType) int64 {
+	t.Helper()
+	var count int64
+	if err := rm.db.Model(&Registry{}).Where("type = ?", entityType).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestRegisterEntityUnchangedContent(t *testing.T) {
+	rm := newTestRegistryManager(t)
+
+	m := testModel()
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatalf("RegisterEntity(model) error = %v", err)
+	}
+	c := testComponent(m, "Pod")
+	if _, _, err := rm.RegisterEntity(m.Registrant, &c); err != nil {
+		t.Fatalf("RegisterEntity(component) error = %v", err)
+	}
+	rel := relationship.RelationshipDefinition{SchemaVersion: v1alpha3.RelationshipSchemaVersion, Kind: "edge", RelationshipType: "network", SubType: "service", Model: m}
+	if _, _, err := rm.RegisterEntity(m.Registrant, &rel); err != nil {
+		t.Fatalf("RegisterEntity(relationship) error = %v", err)
+	}
+
+	// registering the same content again, e.g. on the next import, must not write anything and fill the ids
+	again := testModel()
+	if _, _, err := rm.RegisterEntity(again.Registrant, &again); err != nil {
+		t.Fatalf("RegisterEntity(model) again error = %v", err)
+	}
+	if again.Id != m.Id || again.CategoryId != m.CategoryId || again.Category.Id != m.CategoryId {
+		t.Errorf("model ids = %s/%s, want %s/%s", again.Id, again.CategoryId, m.Id, m.CategoryId)
+	}
+	cAgain := testComponent(again, "Pod")
+	if _, _, err := rm.RegisterEntity(again.Registrant, &cAgain); err != nil {
+		t.Fatalf("RegisterEntity(component) again error = %v", err)
+	}
+	if cAgain.Id != c.Id || cAgain.ModelId != c.ModelId || cAgain.Model.Id != c.ModelId {
+		t.Errorf("component ids = %s/%s, want %s/%s", cAgain.Id, cAgain.ModelId, c.Id, c.ModelId)
+	}
+	relAgain := relationship.RelationshipDefinition{SchemaVersion: v1alpha3.RelationshipSchemaVersion, Kind: "edge", RelationshipType: "network", SubType: "service", Model: again}
+	if _, _, err := rm.RegisterEntity(again.Registrant, &relAgain); err != nil {
+		t.Fatalf("RegisterEntity(relationship) again error = %v", err)
+	}
+	if relAgain.Id != rel.Id || relAgain.ModelId != rel.ModelId {
+		t.Errorf("relationship ids = %s/%s, want %s/%s", relAgain.Id, relAgain.ModelId, rel.Id, rel.ModelId)
+	}
+	for _, entityType := range []entity.EntityType{entity.Model, entity.ComponentDefinition, entity.RelationshipDefinition} {
+		if n := countRegistryEntries(t, rm, entityType); n != 1 {
+			t.Errorf("%d registry entries of %s, want 1", n, entityType)
+		}
+	}
+
+	// changed content is registered as a new entity
+	changed := testComponent(again, "Pod")
+	changed.Component.Schema = `{"type": "object", "properties": {"spec": {"type": "object"}}}`
+	if _, _, err := rm.RegisterEntity(again.Registrant, &changed); err != nil {
+		t.Fatalf("RegisterEntity(changed component) error = %v", err)
+	}
+	if changed.Id == c.Id {
+		t.Error("changed component has the id of the registered one")
+	}
+	if n := countRegistryEntries(t, rm, entity.ComponentDefinition); n != 2 {
+		t.Errorf("%d registry entries of components, want 2", n)
+	}
+}
+
+func TestDiffImports(t *testing.T) {
+	m := testModel()
+	pod, service, secret := testComponent(m, "Pod"), testComponent(m, "Service"), testComponent(m, "Secret")
+	fingerprints := func(entities ...entity.Entity) []EntityFingerprint {
+		fps := []EntityFingerprint{}
+		for _, en := range entities {
+			fp, err := NewEntityFingerprint(en)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fps = append(fps, fp)
+		}
+		return fps
+	}
+	previous := fingerprints(&m, &pod, &service)
+
+	// ids and status are assigned by the registry and do not change the content hash
+	reregistered := testModel()
+	reregistered.Id = m.Id
+	reregistered.Status = "ignored"
+	changedService := testComponent(m, "Service")
+	changedService.Component.Schema = `{"type": "object", "required": ["spec"]}`
+	current := fingerprints(&reregistered, &changedService, &secret)
+
+	changes := DiffImports(previous, current)
+	want := map[string]ChangeType{
+		EntityKey(&pod):     EntityRemoved,
+		EntityKey(&service): EntityModified,
+		EntityKey(&secret):  EntityAdded,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("DiffImports() = %+v, want %d changes", changes, len(want))
+	}
+	for _, change := range changes {
+		if want[change.Key] != change.Change {
+			t.Errorf("change of %s = %s, want %s", change.Key, change.Change, want[change.Key])
+		}
+	}
+}
+
+func TestCheckModelCompatibilityRemovedComponent(t *testing.T) {
+	rm := newTestRegistryManager(t)
+	m := testModel()
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{"Pod", "Service"} {
+		c := testComponent(m, kind)
+		if _, _, err := rm.RegisterEntity(m.Registrant, &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := testModel()
+	next.Model.Version = "1.30.0"
+	result, err := rm.CheckModelCompatibility(next, []component.ComponentDefinition{testComponent(next, "Pod")})
+	if err != nil {
+		t.Fatalf("CheckModelCompatibility() error = %v", err)
+	}
+	if result == nil || !result.Breaking || len(result.Components) != 1 {
+		t.Fatalf("CheckModelCompatibility() = %+v, want the removed Service only", result)
+	}
+	cc := result.Components[0]
+	if cc.Kind != "Service" || len(cc.Changes) != 1 || cc.Changes[0].Kind != ComponentRemoved || !cc.Changes[0].Breaking {
+		t.Errorf("compatibility of the removed component = %+v", cc)
+	}
+}
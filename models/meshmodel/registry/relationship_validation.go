@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+)
+
+// RelationshipValidationPolicy decides what happens when the selectors of a relationship reference components which are not registered.
+type RelationshipValidationPolicy string
+
+const (
+	// RelationshipValidationReport registers the relationship and reports the dangling references.
+	RelationshipValidationReport RelationshipValidationPolicy = ""
+	// RelationshipValidationReject refuses to register a relationship with dangling references.
+	RelationshipValidationReject RelationshipValidationPolicy = "reject"
+	// RelationshipValidationSkip does not resolve the selectors.
+	RelationshipValidationSkip RelationshipValidationPolicy = "skip"
+)
+
+// wildcard matches any component kind or model in selectors.
+const wildcard = "*"
+
+// SelectorReference is a component referenced by a selector of a relationship.
+// Empty fields match any value.
+type SelectorReference struct {
+	// Selector locates the selector in the relationship, e.g. selectors[0].allow.from[1]
+	Selector     string `json:"selector"`
+	Kind         string `json:"kind"`
+	ModelName    string `json:"modelName"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+func (r SelectorReference) String() string {
+	kind := r.Kind
+	if kind == "" {
+		kind = wildcard
+	}
+	model := r.ModelName
+	if model == "" {
+		model = wildcard
+	}
+	if r.ModelVersion != "" {
+		model += "@" + r.ModelVersion
+	}
+	return fmt.Sprintf("%s: %s/%s", r.Selector, model, kind)
+}
+
+// SelectorReferences returns the components referenced by the from and to selectors, allow and deny, of the relationship.
+// Selectors matching any component of any model are omitted.
+func SelectorReferences(rel relationship.RelationshipDefinition) []SelectorReference {
+	refs := []SelectorReference{}
+	if rel.Selectors == nil {
+		return refs
+	}
+	add := func(prefix string, items []relationship.SelectorItem) {
+		for i, item := range items {
+			ref := SelectorReference{Selector: fmt.Sprintf("%s[%d]", prefix, i)}
+			if item.Kind != nil && *item.Kind != wildcard {
+				ref.Kind = *item.Kind
+			}
+			if item.Model != nil {
+				if item.Model.Name != wildcard {
+					ref.ModelName = item.Model.Name
+				}
+				if item.Model.Model.Version != wildcard {
+					ref.ModelVersion = item.Model.Model.Version
+				}
+			}
+			if ref.Kind == "" && ref.ModelName == "" {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+	}
+	for i, set := range *rel.Selectors {
+		add(fmt.Sprintf("selectors[%d].allow.from", i), set.Allow.From)
+		add(fmt.Sprintf("selectors[%d].allow.to", i), set.Allow.To)
+		if set.Deny != nil {
+			add(fmt.Sprintf("selectors[%d].deny.from", i), set.Deny.From)
+			add(fmt.Sprintf("selectors[%d].deny.to", i), set.Deny.To)
+		}
+	}
+	return refs
+}
+
+/*
+DanglingSelectorReferences resolves the selectors of the relationship against the registered components
+and returns the references which do not match any component.
+*/
+func (rm *RegistryManager) DanglingSelectorReferences(rel relationship.RelationshipDefinition) ([]SelectorReference, error) {
+	dangling := []SelectorReference{}
+	// selectors often repeat the same component, e.g. in allow and deny
+	resolved := map[string]bool{}
+	for _, ref := range SelectorReferences(rel) {
+		key := strings.Join([]string{ref.Kind, ref.ModelName, ref.ModelVersion}, "\x00")
+		found, ok := resolved[key]
+		if !ok {
+			var err error
+			found, err = rm.componentExists(ref)
+			if err != nil {
+				return nil, ErrResolveSelector(err, ref.String())
+			}
+			resolved[key] = found
+		}
+		if !found {
+			dangling = append(dangling, ref)
+		}
+	}
+	return dangling, nil
+}
+
+func (rm *RegistryManager) componentExists(ref SelectorReference) (bool, error) {
+	finder := rm.db.Model(&component.ComponentDefinition{}).
+		Joins("JOIN model_dbs ON component_definition_dbs.model_id = model_dbs.id")
+	if ref.Kind != "" {
+		finder = finder.Where("component_definition_dbs.component->>'kind' = ?", ref.Kind)
+	}
+	if ref.ModelName != "" {
+		finder = finder.Where("model_dbs.name = ?", ref.ModelName)
+	}
+	if ref.ModelVersion != "" {
+		finder = finder.Where("model_dbs.model->>'version' = ?", ref.ModelVersion)
+	}
+	var count int64
+	if err := finder.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/model"
+)
+
+func testSelectorItem(kind, modelName, modelVersion string) relationship.SelectorItem {
+	item := relationship.SelectorItem{Kind: &kind}
+	if modelName != "" {
+		item.Model = &model.ModelDefinition{Name: modelName, Model: model.Model{Version: modelVersion}}
+	}
+	return item
+}
+
+func TestDanglingSelectorReferences(t *testing.T) {
+	rm := newTestRegistryManager(t)
+	m := testModel()
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatal(err)
+	}
+	pod := testComponent(m, "Pod")
+	if _, _, err := rm.RegisterEntity(m.Registrant, &pod); err != nil {
+		t.Fatal(err)
+	}
+
+	rel := relationship.RelationshipDefinition{Selectors: &relationship.SelectorSet{{
+		Allow: relationship.Selector{
+			From: []relationship.SelectorItem{
+				testSelectorItem("Pod", "kubernetes", ""),
+				testSelectorItem("Pod", "kubernetes", "1.29.0"),
+				testSelectorItem("*", "*", "*"),
+			},
+			To: []relationship.SelectorItem{
+				testSelectorItem("Service", "kubernetes", ""),
+				testSelectorItem("Pod", "kubernetes", "1.30.0"),
+				testSelectorItem("Pod", "istio", ""),
+				testSelectorItem("*", "kubernetes", "*"),
+			},
+		},
+		Deny: &relationship.Selector{
+			From: []relationship.SelectorItem{testSelectorItem("Service", "kubernetes", "")},
+		},
+	}}}
+	dangling, err := rm.DanglingSelectorReferences(rel)
+	if err != nil {
+		t.Fatalf("DanglingSelectorReferences() error = %v", err)
+	}
+	got := []string{}
+	for _, ref := range dangling {
+		got = append(got, ref.String())
+	}
+	want := []string{
+		"selectors[0].allow.to[0]: kubernetes/Service",
+		"selectors[0].allow.to[1]: kubernetes@1.30.0/Pod",
+		"selectors[0].allow.to[2]: istio/Pod",
+		"selectors[0].deny.from[0]: kubernetes/Service",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DanglingSelectorReferences() = %q, want %q", got, want)
+	}
+
+	if dangling, err := rm.DanglingSelectorReferences(relationship.RelationshipDefinition{}); err != nil || len(dangling) != 0 {
+		t.Errorf("DanglingSelectorReferences() without selectors = %v, %v", dangling, err)
+	}
+}
@@ -0,0 +1,506 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/model"
+)
+
+const searchIndexTable = "registry_search_index"
+
+// searchBackend is the implementation of the search index used by the database.
+type searchBackend string
+
+const (
+	searchTSVector searchBackend = "tsvector" // Postgres
+	searchFTS5     searchBackend = "fts5"     // SQLite built with the sqlite_fts5 tag
+	searchLike     searchBackend = "like"     // SQLite without FTS5, matches with LIKE and ranks in Go
+)
+
+// maxIndexedProperties caps the number of schema property names indexed for a component.
+const maxIndexedProperties = 500
+
+var searchTermRegex = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// searchColumns are the indexed text columns along with the weight of a match in each of them.
+var searchColumns = []struct {
+	name   string
+	weight float64
+}{
+	{"display_name", 10},
+	{"kind", 10},
+	{"category", 2},
+	{"model", 2},
+	{"description", 1},
+	{"properties", 0.5},
+}
+
+// SearchOptions narrows down and paginates the results of a search.
+type SearchOptions struct {
+	Types  []entity.EntityType // When empty components, models and relationships are searched
+	Limit  int                 // If 0 or unspecified then 20 results are returned
+	Offset int
+}
+
+// SearchResult is a single entity matching a search, ordered by Rank.
+type SearchResult struct {
+	ID          uuid.UUID         `json:"id"`
+	Type        entity.EntityType `json:"type"`
+	DisplayName string            `json:"displayName"`
+	Kind        string            `json:"kind"`
+	Model       string            `json:"model"`
+	Category    string            `json:"category"`
+	Rank        float64           `json:"rank"` // higher is more relevant
+	// Highlights holds, for every indexed field which matched, an HTML escaped excerpt with the matched terms wrapped in <mark></mark>.
+	Highlights map[string]string `json:"highlights"`
+}
+
+type searchDocument struct {
+	EntityID    string
+	EntityType  string
+	Model       string
+	DisplayName string
+	Description string
+	Kind        string
+	Category    string
+	Properties  string
+	Score       float64
+}
+
+func (d searchDocument) fields() map[string]string {
+	return map[string]string{
+		"display_name": d.DisplayName,
+		"kind":         d.Kind,
+		"category":     d.Category,
+		"model":        d.Model,
+		"description":  d.Description,
+		"properties":   d.Properties,
+	}
+}
+
+// setupSearchIndex creates the search index table if it does not exist and detects the backend to use.
+func (rm *RegistryManager) setupSearchIndex() error {
+	if rm.db.Dialector.Name() == "postgres" {
+		rm.search = searchTSVector
+		err := rm.db.Exec(`CREATE TABLE IF NOT EXISTS ` + searchIndexTable + ` (
+			entity_id text PRIMARY KEY, entity_type text, model text, display_name text, description text, kind text, category text, properties text,
+			document tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', coalesce(display_name, '') || ' ' || coalesce(kind, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(category, '') || ' ' || coalesce(model, '')), 'B') ||
+				setweight(to_tsvector('simple', coalesce(description, '')), 'C') ||
+				setweight(to_tsvector('simple', coalesce(properties, '')), 'D')
+			) STORED)`).Error
+		if err != nil {
+			return ErrSearchIndex(err)
+		}
+		err = rm.db.Exec(`CREATE INDEX IF NOT EXISTS idx_registry_search_document ON ` + searchIndexTable + ` USING GIN (document)`).Error
+		if err != nil {
+			return ErrSearchIndex(err)
+		}
+		return nil
+	}
+
+	// An existing index keeps the backend it was created with.
+	var ddl string
+	_ = rm.db.Raw("SELECT sql FROM sqlite_master WHERE name = ?", searchIndexTable).Scan(&ddl).Error
+	if ddl != "" {
+		rm.search = searchLike
+		if strings.Contains(strings.ToLower(ddl), "fts5") {
+			rm.search = searchFTS5
+		}
+		return nil
+	}
+
+	err := rm.db.Exec(`CREATE VIRTUAL TABLE ` + searchIndexTable + ` USING fts5(entity_id UNINDEXED, entity_type UNINDEXED, model, display_name, description, kind, category, properties)`).Error
+	if err == nil {
+		rm.search = searchFTS5
+		return nil
+	}
+	// FTS5 is not compiled into the SQLite driver
+	rm.search = searchLike
+	err = rm.db.Exec(`CREATE TABLE ` + searchIndexTable + ` (entity_id text PRIMARY KEY, entity_type text, model text, display_name text, description text, kind text, category text, properties text)`).Error
+	if err != nil {
+		return ErrSearchIndex(err)
+	}
+	return nil
+}
+
+// indexEntity adds the entity to the search index, replacing a previous entry of the entity.
+// Entities other than components, models and relationships are not indexed.
+func (rm *RegistryManager) indexEntity(entityID uuid.UUID, en entity.Entity) error {
+	doc, ok := newSearchDocument(entityID, en)
+	if !ok || rm.search == "" {
+		return nil
+	}
+	err := rm.db.Exec("DELETE FROM "+searchIndexTable+" WHERE entity_id = ?", doc.EntityID).Error
+	if err != nil {
+		return ErrSearchIndex(err)
+	}
+	err = rm.db.Exec("INSERT INTO "+searchIndexTable+" (entity_id, entity_type, model, display_name, description, kind, category, properties) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		doc.EntityID, doc.EntityType, doc.Model, doc.DisplayName, doc.Description, doc.Kind, doc.Category, doc.Properties).Error
+	if err != nil {
+		return ErrSearchIndex(err)
+	}
+	return nil
+}
+
+// RebuildSearchIndex indexes every registered model, component and relationship.
+// Use it to index entities registered before search was available. The index is created if it has been removed by Cleanup.
+func (rm *RegistryManager) RebuildSearchIndex() error {
+	if rm.search == "" {
+		if err := rm.setupSearchIndex(); err != nil {
+			return err
+		}
+	}
+	var models []model.ModelDefinition
+	if err := rm.db.Preload("Category").Find(&models).Error; err != nil {
+		return ErrSearchIndex(err)
+	}
+	modelsByID := make(map[uuid.UUID]model.ModelDefinition, len(models))
+	for i := range models {
+		modelsByID[models[i].Id] = models[i]
+		if err := rm.indexEntity(models[i].Id, &models[i]); err != nil {
+			return err
+		}
+	}
+
+	var components []component.ComponentDefinition
+	if err := rm.db.Find(&components).Error; err != nil {
+		return ErrSearchIndex(err)
+	}
+	for i := range components {
+		components[i].Model = modelsByID[components[i].ModelId]
+		if err := rm.indexEntity(components[i].Id, &components[i]); err != nil {
+			return err
+		}
+	}
+
+	var relationships []relationship.RelationshipDefinition
+	if err := rm.db.Find(&relationships).Error; err != nil {
+		return ErrSearchIndex(err)
+	}
+	for i := range relationships {
+		relationships[i].Model = modelsByID[relationships[i].ModelId]
+		if err := rm.indexEntity(relationships[i].Id, &relationships[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search finds the components, models and relationships matching the given text.
+// Every term of the text must match the prefix of a word in one of the indexed fields: display name, kind,
+// category, model, description or the property names of the component schema.
+// Results of all entity types are ranked together, display names and kinds weigh the most.
+func (rm *RegistryManager) Search(text string, opts SearchOptions) ([]SearchResult, error) {
+	if rm.search == "" {
+		return nil, ErrSearchIndex(fmt.Errorf("the search index has been removed, use RebuildSearchIndex to create it"))
+	}
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+	types := opts.Types
+	if len(types) == 0 {
+		types = []entity.EntityType{entity.ComponentDefinition, entity.Model, entity.RelationshipDefinition}
+	}
+
+	var docs []searchDocument
+	var err error
+	switch rm.search {
+	case searchTSVector:
+		docs, err = rm.searchTSVector(terms, types, opts)
+	case searchFTS5:
+		docs, err = rm.searchFTS5(terms, types, opts)
+	default:
+		docs, err = rm.searchLike(terms, types, opts)
+	}
+	if err != nil {
+		return nil, ErrSearchIndex(err)
+	}
+
+	results := make([]SearchResult, 0, len(docs))
+	for _, doc := range docs {
+		id, _ := uuid.FromString(doc.EntityID)
+		res := SearchResult{
+			ID:          id,
+			Type:        entity.EntityType(doc.EntityType),
+			DisplayName: doc.DisplayName,
+			Kind:        doc.Kind,
+			Model:       doc.Model,
+			Category:    doc.Category,
+			Rank:        doc.Score,
+			Highlights:  map[string]string{},
+		}
+		for field, value := range doc.fields() {
+			if h, ok := highlight(value, terms); ok {
+				res.Highlights[field] = h
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (rm *RegistryManager) searchTSVector(terms []string, types []entity.EntityType, opts SearchOptions) ([]searchDocument, error) {
+	prefixes := make([]string, 0, len(terms))
+	for _, t := range terms {
+		prefixes = append(prefixes, t+":*")
+	}
+	tsquery := strings.Join(prefixes, " & ")
+
+	var docs []searchDocument
+	err := rm.db.Raw(`SELECT entity_id, entity_type, model, display_name, description, kind, category, properties,
+			ts_rank(document, to_tsquery('simple', ?)) AS score
+		FROM `+searchIndexTable+`
+		WHERE document @@ to_tsquery('simple', ?) AND entity_type IN ?
+		ORDER BY score DESC, display_name LIMIT ? OFFSET ?`,
+		tsquery, tsquery, types, opts.Limit, opts.Offset).Scan(&docs).Error
+	return docs, err
+}
+
+func (rm *RegistryManager) searchFTS5(terms []string, types []entity.EntityType, opts SearchOptions) ([]searchDocument, error) {
+	prefixes := make([]string, 0, len(terms))
+	for _, t := range terms {
+		prefixes = append(prefixes, fmt.Sprintf(`"%s"*`, t))
+	}
+	// bm25 takes a weight per column of the table, including the unindexed ones.
+	weights := []string{"0", "0"}
+	for _, col := range []string{"model", "display_name", "description", "kind", "category", "properties"} {
+		for _, sc := range searchColumns {
+			if sc.name == col {
+				weights = append(weights, fmt.Sprint(sc.weight))
+			}
+		}
+	}
+
+	var docs []searchDocument
+	// bm25 is lower for better matches, it is negated so that higher is better on every backend.
+	err := rm.db.Raw(`SELECT entity_id, entity_type, model, display_name, description, kind, category, properties,
+			-bm25(`+searchIndexTable+`, `+strings.Join(weights, ", ")+`) AS score
+		FROM `+searchIndexTable+`
+		WHERE `+searchIndexTable+` MATCH ? AND entity_type IN ?
+		ORDER BY score DESC, display_name LIMIT ? OFFSET ?`,
+		strings.Join(prefixes, " "), types, opts.Limit, opts.Offset).Scan(&docs).Error
+	return docs, err
+}
+
+func (rm *RegistryManager) searchLike(terms []string, types []entity.EntityType, opts SearchOptions) ([]searchDocument, error) {
+	finder := rm.db.Table(searchIndexTable).Where("entity_type IN ?", types)
+	for _, t := range terms {
+		conditions := make([]string, 0, len(searchColumns))
+		args := make([]interface{}, 0, len(searchColumns))
+		for _, sc := range searchColumns {
+			conditions = append(conditions, sc.name+" LIKE ?")
+			args = append(args, "%"+t+"%")
+		}
+		finder = finder.Where(strings.Join(conditions, " OR "), args...)
+	}
+
+	var docs []searchDocument
+	if err := finder.Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		fields := docs[i].fields()
+		for _, sc := range searchColumns {
+			for _, t := range terms {
+				docs[i].Score += sc.weight * float64(countPrefixMatches(fields[sc.name], t))
+			}
+		}
+	}
+	// LIKE matches substrings, keep only documents where every term prefixes a word.
+	matched := docs[:0]
+	for _, doc := range docs {
+		if doc.Score > 0 && matchesAllTerms(doc, terms) {
+			matched = append(matched, doc)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].Score != matched[j].Score {
+			return matched[i].Score > matched[j].Score
+		}
+		return matched[i].DisplayName < matched[j].DisplayName
+	})
+
+	if opts.Offset >= len(matched) {
+		return []searchDocument{}, nil
+	}
+	end := opts.Offset + opts.Limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[opts.Offset:end], nil
+}
+
+func newSearchDocument(entityID uuid.UUID, en entity.Entity) (searchDocument, bool) {
+	doc := searchDocument{EntityID: entityID.String(), EntityType: string(en.Type())}
+	switch e := en.(type) {
+	case *component.ComponentDefinition:
+		doc.DisplayName = e.DisplayName
+		doc.Description = e.Description
+		doc.Kind = e.Component.Kind
+		doc.Model = e.Model.Name
+		doc.Category = e.Model.Category.Name
+		doc.Properties = strings.Join(schemaPropertyNames(e.Component.Schema), " ")
+	case *model.ModelDefinition:
+		doc.DisplayName = e.DisplayName
+		doc.Description = e.Description
+		doc.Kind = e.Name
+		doc.Model = e.Name
+		doc.Category = strings.TrimSpace(e.Category.Name + " " + e.SubCategory)
+	case *relationship.RelationshipDefinition:
+		doc.DisplayName = fmt.Sprintf("%s %s %s", e.Kind, e.RelationshipType, e.SubType)
+		if e.Metadata != nil && e.Metadata.Description != nil {
+			doc.Description = *e.Metadata.Description
+		}
+		doc.Kind = string(e.Kind)
+		doc.Model = e.Model.Name
+		doc.Category = e.Model.Category.Name
+	default:
+		return doc, false
+	}
+	return doc, true
+}
+
+// schemaPropertyNames returns the sorted names of all properties declared in a JSON schema, at any depth.
+func schemaPropertyNames(schema string) []string {
+	var root interface{}
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil
+	}
+	names := map[string]struct{}{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				if props, ok := child.(map[string]interface{}); ok && k == "properties" {
+					for name := range props {
+						names[name] = struct{}{}
+					}
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	if len(result) > maxIndexedProperties {
+		result = result[:maxIndexedProperties]
+	}
+	return result
+}
+
+// searchTerms splits the text of a search into lowercase words, dropping any query syntax.
+func searchTerms(text string) []string {
+	return searchTermRegex.FindAllString(strings.ToLower(text), -1)
+}
+
+// matchingWords returns the location of the words in text which are prefixed by any of the terms.
+func matchingWords(text string, terms []string) [][]int {
+	matches := [][]int{}
+	for _, loc := range searchTermRegex.FindAllStringIndex(text, -1) {
+		word := strings.ToLower(text[loc[0]:loc[1]])
+		for _, t := range terms {
+			if strings.HasPrefix(word, t) {
+				matches = append(matches, loc)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func countPrefixMatches(text, term string) int {
+	return len(matchingWords(text, []string{term}))
+}
+
+func matchesAllTerms(doc searchDocument, terms []string) bool {
+	fields := doc.fields()
+	for _, t := range terms {
+		found := false
+		for _, value := range fields {
+			if countPrefixMatches(value, t) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight wraps the words of text prefixed by any of the terms in <mark></mark>, the text is HTML escaped.
+// Long texts are cut down to an excerpt around the first match.
+func highlight(text string, terms []string) (string, bool) {
+	const excerptLen = 120
+	matches := matchingWords(text, terms)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if len(text) > excerptLen {
+		start = matches[0][0] - excerptLen/4
+		if start < 0 {
+			start = 0
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		end = start + excerptLen
+		if end > len(text) {
+			end = len(text)
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := start
+	for _, loc := range matches {
+		if loc[0] >= end {
+			break
+		}
+		if loc[1] > end {
+			end = loc[1]
+		}
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[loc[0]:loc[1]]) + "</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,185 @@
+package registry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/meshery/schemas/models/v1beta1/component"
+)
+
+func newTestSearchRegistry(t *testing.T) *RegistryManager {
+	t.Helper()
+	rm := newTestRegistryManager(t)
+	m := testModel()
+	m.Description = "Production-grade container orchestration"
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatal(err)
+	}
+	components := []component.ComponentDefinition{testComponent(m, "Deployment"), testComponent(m, "Pod"), testComponent(m, "Service")}
+	components[0].Description = "Manages replicated pods"
+	components[0].Component.Schema = `{"type": "object", "properties": {"spec": {"type": "object", "properties": {"replicas": {"type": "integer"}}}}}`
+	components[1].Description = "Smallest deployable unit"
+	components[2].Component.Schema = `{"type": "object", "properties": {"deploymentName": {"type": "string"}}}`
+	for i := range components {
+		if _, _, err := rm.RegisterEntity(m.Registrant, &components[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rm
+}
+
+func searchKinds(results []SearchResult) []string {
+	kinds := []string{}
+	for _, r := range results {
+		kinds = append(kinds, r.Kind)
+	}
+	return kinds
+}
+
+func TestSearch(t *testing.T) {
+	rm := newTestSearchRegistry(t)
+	backend := rm.search
+	tests := []struct {
+		name string
+		text string
+		opts SearchOptions
+		want []string
+	}{
+		{"ranked by field weight", "deploy", SearchOptions{}, []string{"Deployment", "Pod", "Service"}},
+		{"every term matches", "deploy replicas", SearchOptions{}, []string{"Deployment"}},
+		{"terms match word prefixes only", "ploy", SearchOptions{}, []string{}},
+		{"case insensitive", "SERVICE", SearchOptions{}, []string{"Service"}},
+		{"query syntax is dropped", `"pod" OR *`, SearchOptions{}, []string{"Pod", "Deployment"}},
+		{"entity types", "kube", SearchOptions{Types: []entity.EntityType{entity.Model}}, []string{"kubernetes"}},
+		{"limit", "deploy", SearchOptions{Limit: 2}, []string{"Deployment", "Pod"}},
+		{"offset", "deploy", SearchOptions{Limit: 1, Offset: 1}, []string{"Pod"}},
+		{"offset past the results", "deploy", SearchOptions{Offset: 10}, []string{}},
+		{"no terms", "  ", SearchOptions{}, []string{}},
+	}
+	// searchLike also runs on an FTS5 table, so the fallback is tested whichever backend was detected.
+	for _, b := range []searchBackend{backend, searchLike} {
+		rm.search = b
+		for _, tt := range tests {
+			t.Run(string(b)+"/"+tt.name, func(t *testing.T) {
+				results, err := rm.Search(tt.text, tt.opts)
+				if err != nil {
+					t.Fatalf("Search(%q) error = %v", tt.text, err)
+				}
+				if got := searchKinds(results); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Search(%q) = %v, want %v", tt.text, got, tt.want)
+				}
+				for i := 1; i < len(results); i++ {
+					if results[i].Rank > results[i-1].Rank {
+						t.Errorf("result %d ranked %v above %v", i, results[i].Rank, results[i-1].Rank)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	rm := newTestSearchRegistry(t)
+	results, err := rm.Search("deploy", SearchOptions{Types: []entity.EntityType{entity.ComponentDefinition}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		"Deployment": {"display_name": "<mark>Deployment</mark>", "kind": "<mark>Deployment</mark>"},
+		"Pod":        {"description": "Smallest <mark>deployable</mark> unit"},
+		"Service":    {"properties": "<mark>deploymentName</mark>"},
+	}
+	for _, r := range results {
+		if !reflect.DeepEqual(r.Highlights, want[r.Kind]) {
+			t.Errorf("highlights of %s = %v, want %v", r.Kind, r.Highlights, want[r.Kind])
+		}
+		if r.Type != entity.ComponentDefinition || r.Model != "kubernetes" || r.Category != "Orchestration" || r.ID.IsNil() {
+			t.Errorf("result %+v", r)
+		}
+	}
+}
+
+func TestRebuildSearchIndex(t *testing.T) {
+	rm := newTestSearchRegistry(t)
+	if err := rm.db.Exec("DELETE FROM " + searchIndexTable).Error; err != nil {
+		t.Fatal(err)
+	}
+	if results, _ := rm.Search("pod", SearchOptions{}); len(results) != 0 {
+		t.Fatalf("Search() on an empty index = %v", searchKinds(results))
+	}
+	if err := rm.RebuildSearchIndex(); err != nil {
+		t.Fatalf("RebuildSearchIndex() error = %v", err)
+	}
+	results, err := rm.Search("pod", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchKinds(results); !reflect.DeepEqual(got, []string{"Pod", "Deployment"}) {
+		t.Errorf("Search() after rebuild = %v", got)
+	}
+	if results[0].Category != "Orchestration" {
+		t.Errorf("rebuilt category = %q, want the category of the model", results[0].Category)
+	}
+
+	rm.Cleanup()
+	if _, err := rm.Search("pod", SearchOptions{}); err == nil {
+		t.Error("Search() succeeded after Cleanup removed the index")
+	}
+	c := testComponent(testModel(), "Pod")
+	if err := rm.indexEntity(c.Id, &c); err != nil {
+		t.Errorf("indexEntity() after Cleanup error = %v", err)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 20) + "replicas" + strings.Repeat(" dolor sit", 20)
+	tests := []struct {
+		name   string
+		text   string
+		terms  []string
+		want   string
+		wantOk bool
+	}{
+		{"no match", "Pod", []string{"svc"}, "", false},
+		{"prefix", "Replica Set", []string{"rep"}, "<mark>Replica</mark> Set", true},
+		{"inside a word", "Deployment", []string{"ploy"}, "", false},
+		{"several terms", "a pod in a node", []string{"pod", "no"}, "a <mark>pod</mark> in a <mark>node</mark>", true},
+		{"multibyte", "Übersicht über Pods", []string{"über"}, "<mark>Übersicht</mark> <mark>über</mark> Pods", true},
+		{"escaped", `<img src=x onerror="alert(1)"> & pods`, []string{"pod", "img"}, `&lt;<mark>img</mark> src=x onerror=&#34;alert(1)&#34;&gt; &amp; <mark>pods</mark>`, true},
+		{"excerpt", long, []string{"replicas"}, "…" + long[210:240] + "<mark>replicas</mark>" + long[248:330] + "…", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := highlight(tt.text, tt.terms)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("highlight() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSchemaPropertyNames(t *testing.T) {
+	schema := `{"properties": {"spec": {"properties": {"replicas": {}, "template": {"items": [{"properties": {"name": {}}}]}}}, "status": {}}}`
+	if got, want := schemaPropertyNames(schema), []string{"name", "replicas", "spec", "status", "template"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("schemaPropertyNames() = %v, want %v", got, want)
+	}
+	if got := schemaPropertyNames("not json"); got != nil {
+		t.Errorf("schemaPropertyNames() of invalid json = %v", got)
+	}
+
+	props := make([]string, 0, maxIndexedProperties+10)
+	for i := 0; i < maxIndexedProperties+10; i++ {
+		props = append(props, `"p`+strings.Repeat("x", i%7)+string(rune('a'+i%26))+`_`+strings.Repeat("0", i/26)+`": {}`)
+	}
+	if got := schemaPropertyNames(`{"properties": {` + strings.Join(props, ",") + `}}`); len(got) != maxIndexedProperties {
+		t.Errorf("schemaPropertyNames() returned %d names, want %d", len(got), maxIndexedProperties)
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	if got, want := searchTerms(`Pod* AND "Replica-Set" über_alles`), []string{"pod", "and", "replica", "set", "über_alles"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("searchTerms() = %v, want %v", got, want)
+	}
+}
@@ -25,17 +25,34 @@ type RelationshipFilter struct {
 	Limit            int    //If 0 or  unspecified then all records are returned and limit is not used
 	Offset           int
 	Status           string
+	Query            *registry.Query // expression, projection and cursor pagination shared by all filters
+	NextCursor       string          // set by Get when the results are paginated using Query.Cursor
+	decodeErr        error           // set by Create when the map cannot be decoded, returned by Get and GetById
+}
+
+var relationshipFields = registry.FieldSet{
+	"id":           {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.id"},
+	"kind":         {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.kind"},
+	"type":         {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.type"},
+	"subType":      {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.sub_type"},
+	"metadata":     {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.metadata", JSON: true},
+	"selectors":    {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.selectors", JSON: true},
+	"capabilities": {Table: "relationship_definition_dbs", Column: "relationship_definition_dbs.capabilities", JSON: true},
+	"modelName":    {Table: "model_dbs", Column: "model_dbs.name"},
+	"version":      {Table: "model_dbs", Column: "model_dbs.model", Path: "$.version"},
+	"category":     {Table: "category_dbs", Column: "category_dbs.name"},
+	"status":       {Table: "model_dbs", Column: "model_dbs.status"},
 }
 
 // Create the filter from map[string]interface{}
 func (rf *RelationshipFilter) Create(m map[string]interface{}) {
-	if m == nil {
-		return
-	}
-	rf.Kind = m["kind"].(string)
+	rf.decodeErr = registry.DecodeFilter(m, rf)
 }
 
 func (rf *RelationshipFilter) GetById(db *database.Handler) (entity.Entity, error) {
+	if rf.decodeErr != nil {
+		return nil, rf.decodeErr
+	}
 	r := &relationship.RelationshipDefinition{}
 	err := db.First(r, "id = ?", rf.Id).Error
 	if err != nil {
@@ -45,12 +62,23 @@ func (rf *RelationshipFilter) GetById(db *database.Handler) (entity.Entity, erro
 }
 
 func (relationshipFilter *RelationshipFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, error) {
+	if relationshipFilter.decodeErr != nil {
+		return nil, 0, 0, relationshipFilter.decodeErr
+	}
 
 	var relationshipDefinitionsWithModel []relationship.RelationshipDefinition
 	finder := db.Model(&relationship.RelationshipDefinition{}).Preload("Model").Preload("Model.Category").
 		Joins("JOIN model_dbs ON relationship_definition_dbs.model_id = model_dbs.id").
 		Joins("JOIN category_dbs ON model_dbs.category_id = category_dbs.id")
 
+	projection, err := relationshipFilter.Query.Select("relationship_definition_dbs", relationshipFields, "relationship_definition_dbs.id", "relationship_definition_dbs.model_id")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if projection != nil {
+		finder = finder.Select(projection)
+	}
+
 	status := "enabled"
 
 	if relationshipFilter.Status != "" {
@@ -80,7 +108,21 @@ func (relationshipFilter *RelationshipFilter) Get(db *database.Handler) ([]entit
 	if relationshipFilter.Version != "" {
 		finder = finder.Where("model_dbs.model->>'version' = ?", relationshipFilter.Version)
 	}
-	if relationshipFilter.OrderOn != "" {
+	finder, err = relationshipFilter.Query.Filter(finder, relationshipFields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// the count covers every page, it is taken before the cursor restricts the results
+	var count int64
+	finder.Count(&count)
+
+	if relationshipFilter.Query.CursorPagination() {
+		finder, err = relationshipFilter.Query.Page(finder, "relationship_definition_dbs.id")
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	} else if relationshipFilter.OrderOn != "" {
 		if relationshipFilter.Sort == "desc" {
 			finder = finder.Order(clause.OrderByColumn{Column: clause.Column{Name: relationshipFilter.OrderOn}, Desc: true})
 		} else {
@@ -88,14 +130,13 @@ func (relationshipFilter *RelationshipFilter) Get(db *database.Handler) ([]entit
 		}
 	}
 
-	var count int64
-	finder.Count(&count)
-
-	finder = finder.Offset(relationshipFilter.Offset)
+	if !relationshipFilter.Query.CursorPagination() {
+		finder = finder.Offset(relationshipFilter.Offset)
+	}
 	if relationshipFilter.Limit != 0 {
 		finder = finder.Limit(relationshipFilter.Limit)
 	}
-	err := finder.
+	err = finder.
 		Find(&relationshipDefinitionsWithModel).Error
 	if err != nil {
 		return nil, 0, 0, err
@@ -108,6 +149,13 @@ func (relationshipFilter *RelationshipFilter) Get(db *database.Handler) ([]entit
 
 		defs = append(defs, &_rd)
 	}
+
+	if relationshipFilter.Query.CursorPagination() {
+		relationshipFilter.NextCursor = ""
+		if n := len(relationshipDefinitionsWithModel); n > 0 {
+			relationshipFilter.NextCursor = registry.NextCursor(relationshipDefinitionsWithModel[n-1].Id, n, relationshipFilter.Limit)
+		}
+	}
 	// Should have count unique relationships (by model version, model name, and relationship's kind, type, subtype, version)
 	return defs, count, int(count), nil
 }
@@ -9,24 +9,33 @@ import (
 )
 
 type CategoryFilter struct {
-	Id      string
-	Name    string
-	OrderOn string
-	Greedy  bool
-	Sort    string //asc or desc. Default behavior is asc
-	Limit   int    //If 0 or  unspecified then all records are returned and limit is not used
-	Offset  int
+	Id         string
+	Name       string
+	OrderOn    string
+	Greedy     bool
+	Sort       string //asc or desc. Default behavior is asc
+	Limit      int    //If 0 or  unspecified then all records are returned and limit is not used
+	Offset     int
+	Query      *registry.Query // expression, projection and cursor pagination shared by all filters
+	NextCursor string          // set by Get when the results are paginated using Query.Cursor
+	decodeErr  error           // set by Create when the map cannot be decoded, returned by Get and GetById
+}
+
+var categoryFields = registry.FieldSet{
+	"id":       {Table: "category_dbs", Column: "category_dbs.id"},
+	"name":     {Table: "category_dbs", Column: "category_dbs.name"},
+	"metadata": {Table: "category_dbs", Column: "category_dbs.metadata", JSON: true},
 }
 
 // Create the filter from map[string]interface{}
 func (cf *CategoryFilter) Create(m map[string]interface{}) {
-	if m == nil {
-		return
-	}
-	cf.Name = m["name"].(string)
+	cf.decodeErr = registry.DecodeFilter(m, cf)
 }
 
 func (cf *CategoryFilter) GetById(db *database.Handler) (entity.Entity, error) {
+	if cf.decodeErr != nil {
+		return nil, cf.decodeErr
+	}
 	c := &category.CategoryDefinition{}
 	err := db.First(c, "id = ?", cf.Id).Error
 	if err != nil {
@@ -36,6 +45,9 @@ func (cf *CategoryFilter) GetById(db *database.Handler) (entity.Entity, error) {
 }
 
 func (cf *CategoryFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, error) {
+	if cf.decodeErr != nil {
+		return nil, 0, 0, cf.decodeErr
+	}
 	var catdb []category.CategoryDefinition
 	var cat []entity.Entity
 	finder := db.Model(&catdb).Debug()
@@ -50,7 +62,25 @@ func (cf *CategoryFilter) Get(db *database.Handler) ([]entity.Entity, int64, int
 			finder = finder.Where("name = ?", cf.Name)
 		}
 	}
-	if cf.OrderOn != "" {
+	projection, err := cf.Query.Select("category_dbs", categoryFields, "category_dbs.id")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if projection != nil {
+		finder = finder.Select(projection)
+	}
+
+	finder, err = cf.Query.Filter(finder, categoryFields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if cf.Query.CursorPagination() {
+		finder, err = cf.Query.Page(finder, "category_dbs.id")
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	} else if cf.OrderOn != "" {
 		if cf.Sort == "desc" {
 			finder = finder.Order(clause.OrderByColumn{Column: clause.Column{Name: cf.OrderOn}, Desc: true})
 		} else {
@@ -63,7 +93,7 @@ func (cf *CategoryFilter) Get(db *database.Handler) ([]entity.Entity, int64, int
 	if cf.Limit != 0 {
 		finder = finder.Limit(cf.Limit)
 	}
-	if cf.Offset != 0 {
+	if cf.Offset != 0 && !cf.Query.CursorPagination() {
 		finder = finder.Offset(cf.Offset)
 	}
 
@@ -71,11 +101,18 @@ func (cf *CategoryFilter) Get(db *database.Handler) ([]entity.Entity, int64, int
 		finder.Count(&count)
 	}
 
-	err := finder.Find(&catdb).Error
+	err = finder.Find(&catdb).Error
 	if err != nil {
 		return cat, count, int(count), nil
 	}
 
+	if cf.Query.CursorPagination() {
+		cf.NextCursor = ""
+		if n := len(catdb); n > 0 {
+			cf.NextCursor = registry.NextCursor(catdb[n-1].Id, n, cf.Limit)
+		}
+	}
+
 	for _, c := range catdb {
 		// resolve for loop scope
 		_c := c
@@ -1,6 +1,8 @@
 package v1beta1
 
 import (
+	"strings"
+
 	"github.com/layer5io/meshkit/database"
 	"github.com/layer5io/meshkit/models/meshmodel/entity"
 	"github.com/layer5io/meshkit/models/meshmodel/registry"
@@ -27,6 +29,26 @@ type ComponentFilter struct {
 	Offset       int
 	Annotations  string //When this query parameter is "true", only components with the "isAnnotation" property set to true are returned. When this query parameter is "false", all components except those considered to be annotation components are returned. Any other value of the query parameter results in both annotations as well as non-annotation models being returned.
 	Status       string
+	Query        *registry.Query // expression, projection and cursor pagination shared by all filters
+	NextCursor   string          // set by Get when the results are paginated using Query.Cursor
+	decodeErr    error           // set by Create when the map cannot be decoded, returned by Get and GetById
+}
+
+var componentFields = registry.FieldSet{
+	"id":           {Table: "component_definition_dbs", Column: "component_definition_dbs.id"},
+	"kind":         {Table: "component_definition_dbs", Column: "component_definition_dbs.component", Path: "$.kind"},
+	"apiVersion":   {Table: "component_definition_dbs", Column: "component_definition_dbs.component", Path: "$.version"},
+	"displayName":  {Table: "component_definition_dbs", Column: "component_definition_dbs.display_name"},
+	"description":  {Table: "component_definition_dbs", Column: "component_definition_dbs.description"},
+	"component":    {Table: "component_definition_dbs", Column: "component_definition_dbs.component", JSON: true},
+	"metadata":     {Table: "component_definition_dbs", Column: "component_definition_dbs.metadata", JSON: true},
+	"styles":       {Table: "component_definition_dbs", Column: "component_definition_dbs.styles", JSON: true},
+	"capabilities": {Table: "component_definition_dbs", Column: "component_definition_dbs.capabilities", JSON: true},
+	"modelName":    {Table: "model_dbs", Column: "model_dbs.name"},
+	"version":      {Table: "model_dbs", Column: "model_dbs.model", Path: "$.version"},
+	"category":     {Table: "category_dbs", Column: "category_dbs.name"},
+	"registrant":   {Table: "connections", Column: "connections.kind"},
+	"status":       {Table: "model_dbs", Column: "model_dbs.status"},
 }
 
 type componentDefinitionWithModel struct {
@@ -37,6 +59,9 @@ type componentDefinitionWithModel struct {
 }
 
 func (cf *ComponentFilter) GetById(db *database.Handler) (entity.Entity, error) {
+	if cf.decodeErr != nil {
+		return nil, cf.decodeErr
+	}
 	c := &component.ComponentDefinition{}
 	err := db.First(c, "id = ?", cf.Id).Error
 	if err != nil {
@@ -47,10 +72,7 @@ func (cf *ComponentFilter) GetById(db *database.Handler) (entity.Entity, error)
 
 // Create the filter from map[string]interface{}
 func (cf *ComponentFilter) Create(m map[string]interface{}) {
-	if m == nil {
-		return
-	}
-	cf.Name = m["name"].(string)
+	cf.decodeErr = registry.DecodeFilter(m, cf)
 }
 
 func countUniqueComponents(components []componentDefinitionWithModel) int {
@@ -65,9 +87,20 @@ func countUniqueComponents(components []componentDefinitionWithModel) int {
 }
 
 func (componentFilter *ComponentFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, error) {
+	if componentFilter.decodeErr != nil {
+		return nil, 0, 0, componentFilter.decodeErr
+	}
 	var componentDefinitionsWithModel []componentDefinitionWithModel
+	selectComponent := "component_definition_dbs.*"
+	projection, err := componentFilter.Query.Select("component_definition_dbs", componentFields, "component_definition_dbs.id", "component_definition_dbs.model_id")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if projection != nil {
+		selectComponent = strings.Join(projection, ", ")
+	}
 	finder := db.Model(&component.ComponentDefinition{}).
-		Select("component_definition_dbs.*, model_dbs.*,category_dbs.*, connections.*").
+		Select(selectComponent + ", model_dbs.*,category_dbs.*, connections.*").
 		Joins("JOIN model_dbs ON component_definition_dbs.model_id = model_dbs.id").
 		Joins("JOIN category_dbs ON model_dbs.category_id = category_dbs.id").
 		Joins("JOIN connections ON connections.id = model_dbs.connection_id")
@@ -117,7 +150,21 @@ func (componentFilter *ComponentFilter) Get(db *database.Handler) ([]entity.Enti
 		finder = finder.Where("model_dbs.model->>'version' = ?", componentFilter.Version)
 	}
 
-	if componentFilter.OrderOn != "" {
+	finder, err = componentFilter.Query.Filter(finder, componentFields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// the count covers every page, it is taken before the cursor restricts the results
+	var count int64
+	finder.Count(&count)
+
+	if componentFilter.Query.CursorPagination() {
+		finder, err = componentFilter.Query.Page(finder, "component_definition_dbs.id")
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	} else if componentFilter.OrderOn != "" {
 		if componentFilter.Sort == "desc" {
 			finder = finder.Order(clause.OrderByColumn{Column: clause.Column{Name: componentFilter.OrderOn}, Desc: true})
 		} else {
@@ -127,14 +174,13 @@ func (componentFilter *ComponentFilter) Get(db *database.Handler) ([]entity.Enti
 		finder = finder.Order("display_name")
 	}
 
-	var count int64
-	finder.Count(&count)
-
-	finder = finder.Offset(componentFilter.Offset)
+	if !componentFilter.Query.CursorPagination() {
+		finder = finder.Offset(componentFilter.Offset)
+	}
 	if componentFilter.Limit != 0 {
 		finder = finder.Limit(componentFilter.Limit)
 	}
-	err := finder.
+	err = finder.
 		Scan(&componentDefinitionsWithModel).Error
 	if err != nil {
 		return nil, 0, 0, err
@@ -157,5 +203,12 @@ func (componentFilter *ComponentFilter) Get(db *database.Handler) ([]entity.Enti
 
 	uniqueCount := countUniqueComponents(componentDefinitionsWithModel)
 
+	if componentFilter.Query.CursorPagination() {
+		componentFilter.NextCursor = ""
+		if n := len(componentDefinitionsWithModel); n > 0 {
+			componentFilter.NextCursor = registry.NextCursor(componentDefinitionsWithModel[n-1].ComponentDefinitionDB.Id, n, componentFilter.Limit)
+		}
+	}
+
 	return defs, count, uniqueCount, nil
 }
@@ -0,0 +1,116 @@
+package v1beta1
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/models/meshmodel/registry"
+	"github.com/meshery/schemas/models/v1beta1"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/connection"
+	"github.com/meshery/schemas/models/v1beta1/model"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRegistry registers the models with a component per kind in each.
+func newTestRegistry(t *testing.T, models []string, kinds []string) *registry.RegistryManager {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	rm, err := registry.NewRegistryManager(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range models {
+		m := model.ModelDefinition{
+			SchemaVersion: v1beta1.ModelSchemaVersion,
+			Name:          name,
+			DisplayName:   name,
+			Status:        "enabled",
+			Category:      category.CategoryDefinition{Name: "Orchestration"},
+			Model:         model.Model{Version: "1.0.0"},
+			Registrant:    connection.Connection{Kind: "github"},
+		}
+		if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+			t.Fatal(err)
+		}
+		for _, kind := range kinds {
+			c := component.ComponentDefinition{
+				SchemaVersion: v1beta1.ComponentSchemaVersion,
+				DisplayName:   kind,
+				Component:     component.Component{Kind: kind, Version: "v1", Schema: `{"type": "object"}`},
+				Model:         m,
+			}
+			if _, _, err := rm.RegisterEntity(m.Registrant, &c); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return rm
+}
+
+// paginate fetches every page of the filter, it returns the ids of the entities and the count returned with each page.
+func paginate(t *testing.T, rm *registry.RegistryManager, limit int, filter func(q *registry.Query) entity.Filter) ([]string, []int64) {
+	t.Helper()
+	q := &registry.Query{Paginate: true}
+	ids, counts := []string{}, []int64{}
+	for {
+		entities, count, _, err := rm.GetEntities(filter(q))
+		if err != nil {
+			t.Fatalf("GetEntities() error = %v", err)
+		}
+		counts = append(counts, count)
+		var last uuid.UUID
+		for _, e := range entities {
+			last = e.GetID()
+			ids = append(ids, last.String())
+		}
+		if q.Cursor = registry.NextCursor(last, len(entities), limit); q.Cursor == "" {
+			return ids, counts
+		}
+	}
+}
+
+func TestCursorPaginationCount(t *testing.T) {
+	rm := newTestRegistry(t, []string{"kubernetes", "istio", "linkerd"}, []string{"Pod", "Service", "Deployment"})
+	tests := []struct {
+		name      string
+		limit     int
+		filter    func(q *registry.Query) entity.Filter
+		wantCount int64
+	}{
+		{"components", 4, func(q *registry.Query) entity.Filter { return &ComponentFilter{Limit: 4, Query: q} }, 9},
+		{"components of a model", 2, func(q *registry.Query) entity.Filter { return &ComponentFilter{ModelName: "istio", Limit: 2, Query: q} }, 3},
+		{"models", 2, func(q *registry.Query) entity.Filter { return &ModelFilter{Limit: 2, Query: q} }, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, counts := paginate(t, rm, tt.limit, tt.filter)
+			if int64(len(ids)) != tt.wantCount {
+				t.Errorf("paginated %d entities, want %d", len(ids), tt.wantCount)
+			}
+			seen := map[string]bool{}
+			for _, id := range ids {
+				if seen[id] {
+					t.Errorf("entity %s returned twice", id)
+				}
+				seen[id] = true
+			}
+			for i, count := range counts {
+				if count != tt.wantCount {
+					t.Errorf("count of page %d = %d, want %d", i+1, count, tt.wantCount)
+				}
+			}
+			if len(counts) < 2 {
+				t.Errorf("%d pages, want several", len(counts))
+			}
+		})
+	}
+}
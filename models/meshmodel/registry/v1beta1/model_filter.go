@@ -29,14 +29,28 @@ type ModelFilter struct {
 	Components    bool
 	Relationships bool
 	Status        string
+	Query         *registry.Query // expression, projection and cursor pagination shared by all filters
+	NextCursor    string          // set by Get when the results are paginated using Query.Cursor
+	decodeErr     error           // set by Create when the map cannot be decoded, returned by Get and GetById
+}
+
+var modelFields = registry.FieldSet{
+	"id":          {Table: "model_dbs", Column: "model_dbs.id"},
+	"name":        {Table: "model_dbs", Column: "model_dbs.name"},
+	"displayName": {Table: "model_dbs", Column: "model_dbs.display_name"},
+	"description": {Table: "model_dbs", Column: "model_dbs.description"},
+	"subCategory": {Table: "model_dbs", Column: "model_dbs.sub_category"},
+	"version":     {Table: "model_dbs", Column: "model_dbs.model", Path: "$.version"},
+	"model":       {Table: "model_dbs", Column: "model_dbs.model", JSON: true},
+	"metadata":    {Table: "model_dbs", Column: "model_dbs.metadata", JSON: true},
+	"status":      {Table: "model_dbs", Column: "model_dbs.status"},
+	"category":    {Table: "category_dbs", Column: "category_dbs.name"},
+	"registrant":  {Table: "connections", Column: "connections.kind"},
 }
 
 // Create the filter from map[string]interface{}
 func (mf *ModelFilter) Create(m map[string]interface{}) {
-	if m == nil {
-		return
-	}
-	mf.Name = m["name"].(string)
+	mf.decodeErr = registry.DecodeFilter(m, mf)
 }
 
 func countUniqueModels(models []model.ModelDefinition) int {
@@ -50,6 +64,9 @@ func countUniqueModels(models []model.ModelDefinition) int {
 	return len(set)
 }
 func (mf *ModelFilter) GetById(db *database.Handler) (entity.Entity, error) {
+	if mf.decodeErr != nil {
+		return nil, mf.decodeErr
+	}
 	m := &model.ModelDefinition{}
 
 	// Retrieve the model by ID
@@ -86,6 +103,9 @@ func (mf *ModelFilter) GetById(db *database.Handler) (entity.Entity, error) {
 }
 
 func (mf *ModelFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, error) {
+	if mf.decodeErr != nil {
+		return nil, 0, 0, mf.decodeErr
+	}
 
 	var modelWithCategories []model.ModelDefinition
 
@@ -94,6 +114,14 @@ func (mf *ModelFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, e
 		Joins("JOIN registries ON registries.entity = model_dbs.id").
 		Joins("JOIN connections ON connections.id = registries.registrant_id")
 
+	projection, err := mf.Query.Select("model_dbs", modelFields, "model_dbs.id", "model_dbs.category_id", "model_dbs.connection_id")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if projection != nil {
+		finder = finder.Select(projection)
+	}
+
 	// total count before pagination
 	var count int64
 
@@ -138,14 +166,9 @@ func (mf *ModelFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, e
 	} else if mf.Annotations == "false" {
 		finder = finder.Where("model_dbs.metadata->>'isAnnotation' = false")
 	}
-	if mf.OrderOn != "" {
-		if mf.Sort == "desc" {
-			finder = finder.Order(clause.OrderByColumn{Column: clause.Column{Name: mf.OrderOn}, Desc: true})
-		} else {
-			finder = finder.Order(mf.OrderOn)
-		}
-	} else {
-		finder = finder.Order("display_name")
+	finder, err = mf.Query.Filter(finder, modelFields)
+	if err != nil {
+		return nil, 0, 0, err
 	}
 
 	status := "enabled"
@@ -156,19 +179,35 @@ func (mf *ModelFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, e
 
 	finder = finder.Where("model_dbs.status = ?", status)
 
+	// the count covers every page, it is taken before the cursor restricts the results
 	finder.Count(&count)
 
+	if mf.Query.CursorPagination() {
+		finder, err = mf.Query.Page(finder, "model_dbs.id")
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	} else if mf.OrderOn != "" {
+		if mf.Sort == "desc" {
+			finder = finder.Order(clause.OrderByColumn{Column: clause.Column{Name: mf.OrderOn}, Desc: true})
+		} else {
+			finder = finder.Order(mf.OrderOn)
+		}
+	} else {
+		finder = finder.Order("display_name")
+	}
+
 	if mf.Limit != 0 {
 		finder = finder.Limit(mf.Limit)
 	}
-	if mf.Offset != 0 {
+	if mf.Offset != 0 && !mf.Query.CursorPagination() {
 		finder = finder.Offset(mf.Offset)
 	}
 
 	includeComponents = mf.Components
 	includeRelationships = mf.Relationships
 
-	err := finder.
+	err = finder.
 		Find(&modelWithCategories).Error
 	if err != nil {
 		return nil, 0, 0, err
@@ -201,5 +240,12 @@ func (mf *ModelFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, e
 		}
 		defs = append(defs, &_modelDB)
 	}
+
+	if mf.Query.CursorPagination() {
+		mf.NextCursor = ""
+		if n := len(modelWithCategories); n > 0 {
+			mf.NextCursor = registry.NextCursor(modelWithCategories[n-1].Id, n, mf.Limit)
+		}
+	}
 	return defs, count, countUniqueModels(modelWithCategories), nil
 }
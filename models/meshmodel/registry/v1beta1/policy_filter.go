@@ -17,15 +17,28 @@ type PolicyFilter struct {
 	Sort      string
 	Limit     int
 	Offset    int
+	Query     *registry.Query // expression and projection shared by all filters
+	decodeErr error           // set by Create when the map cannot be decoded, returned by Get and GetById
 }
 
+var policyFields = registry.FieldSet{
+	"id":         {Table: "policy_definition_dbs", Column: "policy_definition_dbs.id"},
+	"kind":       {Table: "policy_definition_dbs", Column: "policy_definition_dbs.kind"},
+	"subType":    {Table: "policy_definition_dbs", Column: "policy_definition_dbs.sub_type"},
+	"expression": {Table: "policy_definition_dbs", Column: "policy_definition_dbs.expression", JSON: true},
+	"modelName":  {Table: "model_dbs", Column: "model_dbs.name"},
+	"version":    {Table: "model_dbs", Column: "model_dbs.model", Path: "$.version"},
+}
+
+// Create the filter from map[string]interface{}
 func (pf *PolicyFilter) Create(m map[string]interface{}) {
-	if m == nil {
-		return
-	}
+	pf.decodeErr = registry.DecodeFilter(m, pf)
 }
 
 func (pf *PolicyFilter) GetById(db *database.Handler) (entity.Entity, error) {
+	if pf.decodeErr != nil {
+		return nil, pf.decodeErr
+	}
 	p := &v1beta1.PolicyDefinition{}
 	err := db.First(p, "id = ?", pf.Id).Error
 	if err != nil {
@@ -35,10 +48,21 @@ func (pf *PolicyFilter) GetById(db *database.Handler) (entity.Entity, error) {
 }
 
 func (pf *PolicyFilter) Get(db *database.Handler) ([]entity.Entity, int64, int, error) {
+	if pf.decodeErr != nil {
+		return nil, 0, 0, pf.decodeErr
+	}
 	pl := []entity.Entity{}
 	var policyDefinitionWithModel []v1beta1.PolicyDefinition
+	selectPolicy := []string{"policy_definition_dbs.*"}
+	projection, err := pf.Query.Select("policy_definition_dbs", policyFields, "policy_definition_dbs.id")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if projection != nil {
+		selectPolicy = projection
+	}
 	finder := db.Model(&v1beta1.PolicyDefinition{}).
-		Select("policy_definition_dbs.*").
+		Select(selectPolicy).
 		Joins("JOIN model_dbs ON model_dbs.id = policy_definition_dbs.model_id")
 	if pf.Kind != "" {
 		finder = finder.Where("policy_definition_dbs.kind = ?", pf.Kind)
@@ -50,10 +74,15 @@ func (pf *PolicyFilter) Get(db *database.Handler) ([]entity.Entity, int64, int,
 		finder = finder.Where("model_dbs.name = ?", pf.ModelName)
 	}
 
+	finder, err = pf.Query.Filter(finder, policyFields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
 	var count int64
 	finder.Count(&count)
 
-	err := finder.Scan(&policyDefinitionWithModel).Error
+	err = finder.Scan(&policyDefinitionWithModel).Error
 	if err != nil {
 		return pl, 0, 0, err
 	}
@@ -0,0 +1,59 @@
+package oci
+
+import (
+	"time"
+
+	"github.com/meshery/schemas/models/v1beta1/model"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Media types of meshery model artifacts.
+const (
+	// MediaTypeModelArtifact is the artifact type of the manifest of a meshery model.
+	MediaTypeModelArtifact = "application/vnd.meshery.model.v1"
+	// MediaTypeModelConfig is the media type of the config blob, the model definition.
+	MediaTypeModelConfig = "application/vnd.meshery.model.config.v1+json"
+	// MediaTypeModelLayer is the media type of a layer holding the gzipped tarball of a model package.
+	MediaTypeModelLayer = "application/vnd.meshery.model.layer.v1.tar+gzip"
+	// MediaTypeModelContentPrefix prefixes the media type of layers holding a single file, e.g. application/vnd.meshery.model.content.v1.json
+	MediaTypeModelContentPrefix = "application/vnd.meshery.model.content.v1"
+)
+
+// Annotations specific to meshery models, in addition to the standard OCI annotations.
+const (
+	AnnotationModelName       = "io.meshery.model.name"
+	AnnotationModelRegistrant = "io.meshery.model.registrant"
+	AnnotationModelCategory   = "io.meshery.model.category"
+)
+
+// ModelAnnotations returns the manifest annotations describing the model.
+// Title, version, source, revision and created are the standard OCI annotations, source is the source_uri of the model
+// and revision the version of the model definition.
+func ModelAnnotations(m model.ModelDefinition) map[string]string {
+	annotations := map[string]string{
+		v1.AnnotationCreated: time.Now().UTC().Format(time.RFC3339),
+	}
+	set := func(key, value string) {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+
+	title := m.DisplayName
+	if title == "" {
+		title = m.Name
+	}
+	set(v1.AnnotationTitle, title)
+	set(v1.AnnotationDescription, m.Description)
+	set(v1.AnnotationVersion, m.Model.Version)
+	set(v1.AnnotationRevision, m.Version)
+	if m.Metadata != nil {
+		if source, ok := m.Metadata.AdditionalProperties["source_uri"].(string); ok {
+			set(v1.AnnotationSource, source)
+		}
+	}
+	set(AnnotationModelName, m.Name)
+	set(AnnotationModelRegistrant, m.Registrant.Kind)
+	set(AnnotationModelCategory, m.Category.Name)
+	return annotations
+}
@@ -25,6 +25,12 @@ var (
 	ErrSeekFailedCode               = "replace_me"
 	ErrCreateLayerCode              = "replace_me"
 	ErrSavingImageCode              = "replace_me"
+	ErrInvalidKeyCode               = "replace_me"
+	ErrSigningArtifactCode          = "replace_me"
+	ErrVerifyingSignatureCode       = "replace_me"
+	ErrStoreCode                    = "replace_me"
+	ErrArtifactNotCachedCode        = "replace_me"
+	ErrInvalidCACertCode            = "replace_me"
 )
 
 func ErrAppendingLayer(err error) error {
@@ -107,3 +113,27 @@ func ErrPushingPackage(err error) error {
 func ErrSeekFailed(err error) error {
 	return errors.New(ErrSeekFailedCode, errors.Alert, []string{"Unable to reset the position within the OCI data."}, []string{err.Error()}, []string{"The function attempted to move to the start of the data but failed. This could happen if the data is corrupted or not in the expected format."}, []string{"Ensure the input data is a valid OCI archive and try again. Check if the data is compressed correctly and is not corrupted."})
 }
+
+func ErrInvalidKey(err error) error {
+	return errors.New(ErrInvalidKeyCode, errors.Alert, []string{"invalid signing key"}, []string{err.Error()}, []string{"The key is not PEM encoded", "The key is encrypted or of an unsupported type"}, []string{"Use an unencrypted PEM encoded ECDSA, RSA or Ed25519 key", "Private keys must be PKCS#8, EC or PKCS#1 encoded, public keys PKIX encoded"})
+}
+
+func ErrSigningArtifact(err error) error {
+	return errors.New(ErrSigningArtifactCode, errors.Alert, []string{"signing the artifact failed"}, []string{err.Error()}, []string{"The artifact does not exist in the repository", "Insufficient permissions to push to the repository"}, []string{"Make sure the artifact has been pushed", "Verify the credentials used to connect to the registry"})
+}
+
+func ErrVerifyingSignature(err error) error {
+	return errors.New(ErrVerifyingSignatureCode, errors.Alert, []string{"signature verification of the artifact failed"}, []string{err.Error()}, []string{"The artifact is not signed or not signed by a trusted key", "The signature does not belong to the artifact"}, []string{"Sign the artifact with a trusted key", "Add the public key of the signer to the trust policy"})
+}
+
+func ErrStore(err error) error {
+	return errors.New(ErrStoreCode, errors.Alert, []string{"accessing the local OCI store failed"}, []string{err.Error()}, []string{"The store directory is not writable", "The store directory is not a valid OCI image layout"}, []string{"Check the permissions of the store directory", "Remove the store directory so that it is recreated"})
+}
+
+func ErrArtifactNotCached(reference string) error {
+	return errors.New(ErrArtifactNotCachedCode, errors.Alert, []string{"artifact not found in the local OCI store"}, []string{fmt.Sprintf("no artifact is tagged %s", reference)}, []string{"The artifact has not been pulled or added to the store", "The artifact has been removed from the store"}, []string{"Pull the artifact while the registry is reachable", "Copy a preloaded store to the server"})
+}
+
+func ErrInvalidCACert(host string) error {
+	return errors.New(ErrInvalidCACertCode, errors.Alert, []string{"invalid CA certificates"}, []string{fmt.Sprintf("no PEM encoded certificate found in the CA certificates configured for %s", host)}, []string{"The CA certificates are not PEM encoded"}, []string{"Configure the PEM encoded certificates of the certificate authorities of the registry"})
+}
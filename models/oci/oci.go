@@ -1,13 +1,18 @@
 package oci
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/fluxcd/pkg/oci"
 	"github.com/fluxcd/pkg/oci/client"
 	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
@@ -15,12 +20,14 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/meshery/schemas/models/v1beta1/model"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	oras "oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content/file"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
 	"oras.land/oras-go/v2/registry/remote/retry"
 )
 
@@ -38,9 +45,9 @@ const (
 
 // BuildOptions are options for configuring the Push operation.
 type BuildOptions struct {
-	layerType LayerType
-	layerOpts layerOptions
-	meta      client.Metadata
+	layerType   LayerType
+	layerOpts   layerOptions
+	annotations map[string]string
 }
 
 // layerOptions are options for configuring a layer.
@@ -52,6 +59,30 @@ type layerOptions struct {
 // BuildOption is a function for configuring BuildOptions.
 type BuildOption func(o *BuildOptions)
 
+// WithLayerType sets the type of the layer created from the source path.
+func WithLayerType(layerType LayerType) BuildOption {
+	return func(o *BuildOptions) {
+		o.layerType = layerType
+	}
+}
+
+// WithModel annotates the image with the details of the model it contains.
+func WithModel(m model.ModelDefinition) BuildOption {
+	return WithAnnotations(ModelAnnotations(m))
+}
+
+// WithAnnotations adds annotations to the manifest of the image.
+func WithAnnotations(annotations map[string]string) BuildOption {
+	return func(o *BuildOptions) {
+		if o.annotations == nil {
+			o.annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			o.annotations[k] = v
+		}
+	}
+}
+
 // Builds OCI Img for the artifacts in the given path. Returns v1.Image manifest.
 func BuildImage(sourcePath string, opts ...BuildOption) (gcrv1.Image, error) {
 	o := &BuildOptions{
@@ -67,16 +98,18 @@ func BuildImage(sourcePath string, opts ...BuildOption) (gcrv1.Image, error) {
 		return nil, ErrCreateLayer(err)
 	}
 
-	if o.meta.Created == "" {
-		ct := time.Now().UTC()
-		o.meta.Created = ct.Format(time.RFC3339)
+	annotations := map[string]string{v1.AnnotationCreated: time.Now().UTC().Format(time.RFC3339)}
+	for k, v := range o.annotations {
+		annotations[k] = v
 	}
 
+	// the config is the empty image config, not the model definition, it has the default media type of OCI images
 	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
-	img = mutate.ConfigMediaType(img, oci.CanonicalConfigMediaType)
-	img = mutate.Annotations(img, o.meta.ToAnnotations()).(gcrv1.Image)
+	img = mutate.ConfigMediaType(img, types.OCIConfigJSON)
+	img = mutate.Annotations(img, annotations).(gcrv1.Image)
 
-	img, err = mutate.Append(img, mutate.Addendum{Layer: layer})
+	// layer annotations must not be empty, the manifest would not match its serialized form otherwise
+	img, err = mutate.Append(img, mutate.Addendum{Layer: layer, Annotations: map[string]string{v1.AnnotationTitle: filepath.Base(sourcePath)}})
 	if err != nil {
 		return nil, ErrAppendingLayer(err)
 	}
@@ -88,7 +121,7 @@ func BuildImage(sourcePath string, opts ...BuildOption) (gcrv1.Image, error) {
 func createLayer(path string, layerType LayerType, opts layerOptions) (gcrv1.Layer, error) {
 	switch layerType {
 	case LayerTypeTarball:
-		var ociMediaType = oci.CanonicalContentMediaType
+		var ociMediaType types.MediaType = MediaTypeModelLayer
 		var tmpDir string
 		tmpDir, err := os.MkdirTemp("", "oci")
 		if err != nil {
@@ -101,7 +134,14 @@ func createLayer(path string, layerType LayerType, opts layerOptions) (gcrv1.Lay
 		if err := ociClient.Build(tmpFile, path, opts.ignorePaths); err != nil {
 			return nil, err
 		}
-		return tarball.LayerFromFile(tmpFile, tarball.WithMediaType(ociMediaType), tarball.WithCompressedCaching)
+		// the temporary directory is removed on return, keep the layer content in memory
+		content, err := os.ReadFile(tmpFile)
+		if err != nil {
+			return nil, ErrReadingFile(err)
+		}
+		return tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		}, tarball.WithMediaType(ociMediaType))
 	case LayerTypeStatic:
 		var ociMediaType = getLayerMediaType(opts.mediaTypeExt)
 		content, err := os.ReadFile(path)
@@ -116,63 +156,147 @@ func createLayer(path string, layerType LayerType, opts layerOptions) (gcrv1.Lay
 
 func getLayerMediaType(extension string) types.MediaType {
 	if extension == "" {
-		return oci.CanonicalMediaTypePrefix
+		return MediaTypeModelContentPrefix
+	}
+	return types.MediaType(fmt.Sprintf("%s.%s", MediaTypeModelContentPrefix, extension))
+}
+
+/*
+packageMediaType returns the media type of the layer holding the model package at path.
+Directories are packed as gzipped tarballs by the file store, as are gzipped tarballs themselves,
+other files are single file layers typed by their extension, see getLayerMediaType.
+*/
+func packageMediaType(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", ErrFileNotFound(err, path)
+	}
+	if info.IsDir() {
+		return MediaTypeModelLayer, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ErrReadingFile(err)
+	}
+	defer f.Close()
+	if gz, err := gzip.NewReader(f); err == nil {
+		_, err = tar.NewReader(gz).Next()
+		gz.Close()
+		if err == nil {
+			return MediaTypeModelLayer, nil
+		}
 	}
-	return types.MediaType(fmt.Sprintf("%s.%s", oci.CanonicalMediaTypePrefix, extension))
+	return string(getLayerMediaType(strings.TrimPrefix(filepath.Ext(path), "."))), nil
 }
 
-// function to pull models from any OCI-compatible repository
-func PushToOCIRegistry(dirPath, registryAdd, repositoryAdd, imageTag, username, password string) error {
+// RegistryOptions identify an artifact in an OCI registry.
+type RegistryOptions struct {
+	// Registry is the host of the registry, e.g. docker.io
+	Registry string
+	// Repository is the repository within the registry, e.g. meshery/models/kubernetes
+	Repository string
+	Tag        string
+	// Username and Password are used to authenticate to the registry, anonymous access is used if they are empty.
+	Username string
+	Password string
+	// Token is a bearer token sent to the registry, e.g. a registry access token, it takes precedence over Username and Password.
+	Token string
+	// Credentials looks up the credentials of the registry, when none are given explicitly, and of its mirrors, e.g. DockerCredentials.
+	Credentials credentials.Store
+	// PlainHTTP connects to the registry without TLS, e.g. to a local registry
+	PlainHTTP bool
+	// Config holds the mirror, TLS and retry settings of registries.
+	Config *RegistryConfig
+}
+
+// PushOptions configures PushToOCIRegistry.
+type PushOptions struct {
+	RegistryOptions
+	// Path is the model package to push: a directory, a gzipped tarball or a single file.
+	Path string
+	// Model is the definition of the pushed model, it is the config of the artifact and annotates the manifest.
+	Model *model.ModelDefinition
+	// Annotations are added to the annotations of the manifest.
+	Annotations map[string]string
+	// Signer signs the pushed artifact, the signature is pushed as a referrer of the artifact.
+	Signer *Signer
+}
 
-	fs, fileErr := file.New(".")
+// PullOptions configures PullFromOCIRegistry.
+type PullOptions struct {
+	RegistryOptions
+	// Path is the directory the model package is written to.
+	Path string
+	// TrustPolicy verifies the signatures of the artifact before it is pulled.
+	TrustPolicy *TrustPolicy
+}
+
+// PushToOCIRegistry packs the model package as a meshery model artifact and pushes it to an OCI-compatible repository.
+func PushToOCIRegistry(opts PushOptions) error {
+	fs, fileErr := file.New(filepath.Dir(opts.Path))
 	if fileErr != nil {
 		return ErrWriteFile(fileErr)
 	}
+	defer fs.Close()
 
 	ctx := context.Background()
 
-	mediaType := "application/vnd.test.folder"
-	fileNames := []string{dirPath}
-	fileDescriptors := make([]v1.Descriptor, 0, len(fileNames))
-	for _, name := range fileNames {
-		fileDescriptor, err := fs.Add(ctx, name, mediaType, "")
+	mediaType, err := packageMediaType(opts.Path)
+	if err != nil {
+		return err
+	}
+	layer, err := fs.Add(ctx, filepath.Base(opts.Path), mediaType, opts.Path)
+	if err != nil {
+		return ErrAddLayer(err)
+	}
+
+	annotations := map[string]string{v1.AnnotationCreated: time.Now().UTC().Format(time.RFC3339)}
+	config := []byte("{}")
+	if opts.Model != nil {
+		annotations = ModelAnnotations(*opts.Model)
+		config, err = json.Marshal(opts.Model)
 		if err != nil {
 			return ErrAddLayer(err)
 		}
-		fileDescriptors = append(fileDescriptors, fileDescriptor)
+	}
+	for k, v := range opts.Annotations {
+		annotations[k] = v
 	}
 
-	// Pack the folder and tag the packed manifest
-	artifactType := "application/vnd.test.artifact"
-	opts := oras.PackManifestOptions{
-		Layers: fileDescriptors,
+	configDescriptor, err := oras.PushBytes(ctx, fs, MediaTypeModelConfig, config)
+	if err != nil {
+		return ErrAddLayer(err)
+	}
+
+	// Pack the model package and tag the packed manifest
+	packOpts := oras.PackManifestOptions{
+		Layers:              []v1.Descriptor{layer},
+		ConfigDescriptor:    &configDescriptor,
+		ManifestAnnotations: annotations,
 	}
-	manifestDescriptor, packageErr := oras.PackManifest(ctx, fs, oras.PackManifestVersion1_1_RC4, artifactType, opts)
+	manifestDescriptor, packageErr := oras.PackManifest(ctx, fs, oras.PackManifestVersion1_1_RC4, MediaTypeModelArtifact, packOpts)
 	if packageErr != nil {
 		return ErrGettingLayer(packageErr)
 	}
 
-	if tagErr := fs.Tag(ctx, manifestDescriptor, imageTag); tagErr != nil {
-		return ErrWriteFile(tagErr)
+	if tagErr := fs.Tag(ctx, manifestDescriptor, opts.Tag); tagErr != nil {
+		return ErrTaggingPackage(tagErr)
 	}
 
-	// Connect to a remote repository
-	repo, connectErr := remote.NewRepository(registryAdd + "/" + repositoryAdd)
-	if connectErr != nil {
-		return ErrConnectingToRegistry(connectErr)
-	}
-
-	// Authenticate to the registry
-	authErr := AuthToOCIRegistry(repo, registryAdd, username, password)
-	if authErr != nil {
-		return ErrAuthenticatingToRegistry(authErr)
+	repo, err := connect(opts.RegistryOptions)
+	if err != nil {
+		return err
 	}
 
-	_, pushErr := oras.Copy(ctx, fs, imageTag, repo, imageTag, oras.DefaultCopyOptions)
+	root, pushErr := oras.Copy(ctx, fs, opts.Tag, repo, opts.Tag, oras.DefaultCopyOptions)
 	if pushErr != nil {
 		return ErrPushingPackage(pushErr)
 	}
 
+	if opts.Signer != nil {
+		return sign(ctx, repo, opts.RegistryOptions, root, opts.Signer)
+	}
 	return nil
 }
 
@@ -190,32 +314,29 @@ func AuthToOCIRegistry(repo *remote.Repository, registryURI, username, password
 	return nil
 }
 
-// function to pull images from the public oci repository
-func PullFromOCIRegistry(dirPath, registryAdd, repositoryAdd, imageTag, username, password string) error {
+// PullFromOCIRegistry pulls a model artifact from an OCI-compatible repository and writes the model package to the given path.
+func PullFromOCIRegistry(opts PullOptions) error {
 	// Create a new file store
-	fs, err := file.New(dirPath)
+	fs, err := file.New(opts.Path)
 	if err != nil {
-		return ErrFileNotFound(err, dirPath)
+		return ErrFileNotFound(err, opts.Path)
 	}
-
 	defer fs.Close()
+
 	ctx := context.Background()
 
-	// Connect to remote registry
-	repo, connectErr := remote.NewRepository(registryAdd + "/" + repositoryAdd)
-	if connectErr != nil {
-		return ErrConnectingToRegistry(connectErr)
+	repo, desc, err := resolve(ctx, opts.RegistryOptions)
+	if err != nil {
+		return err
 	}
-
-	// Authenticate to the registry
-	if username != "" && password != "" {
-		authErr := AuthToOCIRegistry(repo, registryAdd, username, password)
-		if authErr != nil {
-			return ErrAuthenticatingToRegistry(authErr)
+	if opts.TrustPolicy != nil {
+		if err := verify(ctx, repo, desc, *opts.TrustPolicy); err != nil {
+			return err
 		}
 	}
 
-	_, pullErr := oras.Copy(ctx, repo, imageTag, fs, imageTag, oras.DefaultCopyOptions)
+	// pull the resolved manifest by digest, the tag could be moved after verification
+	_, pullErr := oras.Copy(ctx, repo, desc.Digest.String(), fs, opts.Tag, oras.DefaultCopyOptions)
 	if pullErr != nil {
 		return ErrGettingImage(pullErr)
 	}
@@ -0,0 +1,254 @@
+package oci
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	gcrremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// DefaultMaxRetries is the number of retries of failed requests to registries.
+// Requests are retried on network timeouts, 408, 429 and 5xx responses, with exponential backoff.
+const DefaultMaxRetries = 5
+
+// RegistryConfig holds the connection settings of registries, it is usually loaded once and shared by all operations.
+type RegistryConfig struct {
+	// Hosts holds the settings of registries by host, e.g. registry.example.com:5000
+	Hosts map[string]HostConfig
+	// CACerts are PEM encoded certificates trusted for all registries, in addition to the system pool.
+	CACerts []byte
+	// MaxRetries is the number of retries of failed requests, DefaultMaxRetries is used when zero and retries are disabled when negative.
+	MaxRetries int
+}
+
+// HostConfig holds the settings of a registry.
+type HostConfig struct {
+	// Mirrors are hosts, optionally followed by a path prefix, tried in order before the registry when pulling.
+	// Pushes always go to the registry.
+	Mirrors []string
+	// PlainHTTP connects to the host without TLS.
+	PlainHTTP bool
+	// InsecureSkipVerify connects to the host without verifying its certificate.
+	InsecureSkipVerify bool
+	// CACerts are PEM encoded certificates trusted for the host.
+	CACerts []byte
+}
+
+func (c *RegistryConfig) host(host string) HostConfig {
+	if c == nil {
+		return HostConfig{}
+	}
+	return c.Hosts[host]
+}
+
+func (c *RegistryConfig) maxRetries() int {
+	if c == nil || c.MaxRetries == 0 {
+		return DefaultMaxRetries
+	}
+	return c.MaxRetries
+}
+
+// transport returns the transport used to connect to the host, with the TLS and retry settings applied.
+func (c *RegistryConfig) transport(host string) (http.RoundTripper, error) {
+	hc := c.host(host)
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	var caCerts [][]byte
+	if c != nil && len(c.CACerts) > 0 {
+		caCerts = append(caCerts, c.CACerts)
+	}
+	if len(hc.CACerts) > 0 {
+		caCerts = append(caCerts, hc.CACerts)
+	}
+	if len(caCerts) > 0 || hc.InsecureSkipVerify {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, pem := range caCerts {
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, ErrInvalidCACert(host)
+			}
+		}
+		base.TLSClientConfig = &tls.Config{
+			RootCAs:            pool,
+			InsecureSkipVerify: hc.InsecureSkipVerify,
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
+
+	maxRetries := c.maxRetries()
+	if maxRetries < 0 {
+		return base, nil
+	}
+	transport := retry.NewTransport(base)
+	transport.Policy = func() retry.Policy {
+		return &retry.GenericPolicy{
+			Retryable: retry.DefaultPredicate,
+			Backoff:   retry.DefaultBackoff,
+			MinWait:   200 * time.Millisecond,
+			MaxWait:   3 * time.Second,
+			MaxRetry:  maxRetries,
+		}
+	}
+	return transport, nil
+}
+
+/*
+DockerCredentials returns the credentials configured for docker, read from the config.json at configPath or the default docker config if empty.
+Credentials stored in the config, credential helpers (credHelpers) and the credential store (credsStore) are supported, as well as identity tokens.
+*/
+func DockerCredentials(configPath string) (credentials.Store, error) {
+	var store credentials.Store
+	var err error
+	if configPath == "" {
+		store, err = credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	} else {
+		store, err = credentials.NewStore(configPath, credentials.StoreOptions{})
+	}
+	if err != nil {
+		return nil, ErrAuthenticatingToRegistry(err)
+	}
+	return store, nil
+}
+
+// credential returns the credentials used for host, the registry or one of its mirrors.
+// Explicit credentials only apply to the registry, mirrors are authenticated using the credential store.
+func (opts RegistryOptions) credential(host string) auth.CredentialFunc {
+	return func(ctx context.Context, hostport string) (auth.Credential, error) {
+		if host == opts.Registry {
+			if opts.Token != "" {
+				return auth.Credential{AccessToken: opts.Token}, nil
+			}
+			if opts.Username != "" && opts.Password != "" {
+				return auth.Credential{Username: opts.Username, Password: opts.Password}, nil
+			}
+		}
+		if opts.Credentials != nil {
+			return credentials.Credential(opts.Credentials)(ctx, hostport)
+		}
+		return auth.EmptyCredential, nil
+	}
+}
+
+// endpoints returns the hosts to pull from, the mirrors of the registry followed by the registry.
+func (opts RegistryOptions) endpoints() []string {
+	mirrors := opts.Config.host(opts.Registry).Mirrors
+	endpoints := make([]string, 0, len(mirrors)+1)
+	endpoints = append(endpoints, mirrors...)
+	return append(endpoints, opts.Registry)
+}
+
+func (opts RegistryOptions) plainHTTP(host string) bool {
+	return opts.Config.host(hostname(host)).PlainHTTP || (host == opts.Registry && opts.PlainHTTP)
+}
+
+// hostname strips the path prefix of a mirror.
+func hostname(host string) string {
+	hostname, _, _ := strings.Cut(host, "/")
+	return hostname
+}
+
+// connect returns the repository in the registry.
+func connect(opts RegistryOptions) (*remote.Repository, error) {
+	return connectHost(opts, opts.Registry)
+}
+
+// connectHost returns the repository in host, the registry or one of its mirrors.
+func connectHost(opts RegistryOptions, host string) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(host + "/" + opts.Repository)
+	if err != nil {
+		return nil, ErrConnectingToRegistry(err)
+	}
+	repo.PlainHTTP = opts.plainHTTP(host)
+
+	transport, err := opts.Config.transport(repo.Reference.Registry)
+	if err != nil {
+		return nil, err
+	}
+	repo.Client = &auth.Client{
+		Client:     &http.Client{Transport: transport},
+		Cache:      auth.NewCache(),
+		Credential: opts.credential(host),
+	}
+	return repo, nil
+}
+
+// resolve resolves the tag in the mirrors of the registry, in order, and then in the registry.
+// It returns the first repository the tag is found in, the artifact must be fetched from it by digest.
+func resolve(ctx context.Context, opts RegistryOptions) (*remote.Repository, v1.Descriptor, error) {
+	var errs []error
+	for _, host := range opts.endpoints() {
+		repo, err := connectHost(opts, host)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		desc, err := repo.Resolve(ctx, opts.Tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", host, err))
+			continue
+		}
+		return repo, desc, nil
+	}
+	return nil, v1.Descriptor{}, ErrGettingImage(errors.Join(errs...))
+}
+
+/*
+PullImage returns the image with the given digest, fetched from the first mirror of the registry, or the registry, that has it.
+Layers are fetched lazily, as they are read.
+*/
+func PullImage(opts RegistryOptions, digest string) (gcrv1.Image, error) {
+	ctx := context.Background()
+	var errs []error
+	for _, host := range opts.endpoints() {
+		img, err := pullImage(ctx, opts, host, digest)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", host, err))
+			continue
+		}
+		return img, nil
+	}
+	return nil, ErrGettingImage(errors.Join(errs...))
+}
+
+func pullImage(ctx context.Context, opts RegistryOptions, host, digest string) (gcrv1.Image, error) {
+	nameOpts := []name.Option{}
+	if opts.plainHTTP(host) {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	ref, err := name.NewDigest(fmt.Sprintf("%s/%s@%s", host, opts.Repository, digest), nameOpts...)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := opts.Config.transport(hostname(host))
+	if err != nil {
+		return nil, err
+	}
+	cred, err := opts.credential(host)(ctx, registry.Reference{Registry: hostname(host)}.Host())
+	if err != nil {
+		return nil, err
+	}
+	authenticator := authn.FromConfig(authn.AuthConfig{
+		Username:      cred.Username,
+		Password:      cred.Password,
+		IdentityToken: cred.RefreshToken,
+		RegistryToken: cred.AccessToken,
+	})
+	return gcrremote.Image(ref, gcrremote.WithContext(ctx), gcrremote.WithTransport(transport), gcrremote.WithAuth(authenticator))
+}
@@ -0,0 +1,280 @@
+package oci
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+// testRegistry is a registry counting the requests it serves.
+type testRegistry struct {
+	server   *httptest.Server
+	host     string
+	requests atomic.Int32
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+	r := &testRegistry{}
+	handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requests.Add(1)
+		handler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(r.server.Close)
+	r.host = strings.TrimPrefix(r.server.URL, "http://")
+	return r
+}
+
+// testModelPackage returns the path of a model package to push.
+func testModelPackage(t *testing.T) string {
+	t.Helper()
+	pkg := filepath.Join(t.TempDir(), "model")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkg, "model.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestMirrorFallback(t *testing.T) {
+	ctx := context.Background()
+	upstream := newTestRegistry(t)
+	mirror := newTestRegistry(t)
+	unreachable := newTestRegistry(t)
+	unreachable.server.Close()
+
+	pkg := testModelPackage(t)
+	push := RegistryOptions{Registry: upstream.host, Repository: "models/test", Tag: "mirrored", PlainHTTP: true}
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: push, Path: pkg}); err != nil {
+		t.Fatal(err)
+	}
+	upstreamOnly := push
+	upstreamOnly.Tag = "upstream"
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: upstreamOnly, Path: pkg, Annotations: map[string]string{"upstream": "true"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mirror serves the repositories of the registry under the cache path prefix
+	config := &RegistryConfig{
+		Hosts: map[string]HostConfig{
+			upstream.host:    {Mirrors: []string{unreachable.host, mirror.host + "/cache"}},
+			mirror.host:      {PlainHTTP: true},
+			unreachable.host: {PlainHTTP: true},
+		},
+		MaxRetries: -1,
+	}
+	opts := RegistryOptions{Registry: upstream.host, Repository: "models/test", Tag: "mirrored", PlainHTTP: true, Config: config}
+
+	// the tag is not in the mirror yet
+	repo, desc, err := resolve(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Reference.Registry != upstream.host {
+		t.Errorf("resolved in %s before the mirror has the tag, want %s", repo.Reference.Registry, upstream.host)
+	}
+	if mirror.requests.Load() == 0 {
+		t.Error("the mirror was not tried")
+	}
+
+	mirrorRepo, err := connectHost(opts, mirror.host+"/cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oras.Copy(ctx, repo, opts.Tag, mirrorRepo, opts.Tag, oras.DefaultCopyOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	upstreamRequests := upstream.requests.Load()
+	repo, mirroredDesc, err := resolve(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Reference.Registry != mirror.host || repo.Reference.Repository != "cache/models/test" {
+		t.Errorf("resolved in %s/%s, want %s/cache/models/test", repo.Reference.Registry, repo.Reference.Repository, mirror.host)
+	}
+	if mirroredDesc.Digest != desc.Digest {
+		t.Errorf("resolved %s in the mirror, want %s", mirroredDesc.Digest, desc.Digest)
+	}
+	if _, err := PullImage(opts, desc.Digest.String()); err != nil {
+		t.Errorf("PullImage() of mirrored digest error = %v", err)
+	}
+	if n := upstream.requests.Load(); n != upstreamRequests {
+		t.Errorf("%d requests to the registry for a mirrored artifact, want none", n-upstreamRequests)
+	}
+
+	// digests missing in the mirrors are pulled from the registry
+	upstreamOnly.Config = config
+	_, upstreamDesc, err := resolve(ctx, upstreamOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PullImage(upstreamOnly, upstreamDesc.Digest.String()); err != nil {
+		t.Errorf("PullImage() of digest missing in the mirrors error = %v", err)
+	}
+	if upstream.requests.Load() == upstreamRequests {
+		t.Error("the registry was not tried after the mirrors")
+	}
+
+	missing := opts
+	missing.Tag = "missing"
+	if _, _, err := resolve(ctx, missing); err == nil {
+		t.Error("resolve() of a missing tag succeeded, want error")
+	}
+}
+
+func TestCredential(t *testing.T) {
+	ctx := context.Background()
+	const registryHost, mirrorHost = "registry.example.com", "mirror.example.com"
+	store := credentials.NewMemoryStore()
+	stored := auth.Credential{Username: "stored", Password: "stored"}
+	storedMirror := auth.Credential{Username: "mirror", Password: "mirror"}
+	if err := store.Put(ctx, registryHost, stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, mirrorHost, storedMirror); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name string
+		opts RegistryOptions
+		host string
+		want auth.Credential
+	}{
+		{"token", RegistryOptions{Token: "token", Username: "user", Password: "password", Credentials: store}, registryHost, auth.Credential{AccessToken: "token"}},
+		{"username and password", RegistryOptions{Username: "user", Password: "password", Credentials: store}, registryHost, auth.Credential{Username: "user", Password: "password"}},
+		{"username without password", RegistryOptions{Username: "user", Credentials: store}, registryHost, stored},
+		{"credential store", RegistryOptions{Credentials: store}, registryHost, stored},
+		{"anonymous", RegistryOptions{}, registryHost, auth.EmptyCredential},
+		{"mirror with token", RegistryOptions{Token: "token", Credentials: store}, mirrorHost, storedMirror},
+		{"mirror without credential store", RegistryOptions{Token: "token", Username: "user", Password: "password"}, mirrorHost, auth.EmptyCredential},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Registry = registryHost
+			got, err := tt.opts.credential(tt.host)(ctx, tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("credential() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryAuthentication(t *testing.T) {
+	ctx := context.Background()
+	handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "password" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	opts := RegistryOptions{Registry: host, Repository: "models/test", Tag: "v1", Username: "user", Password: "password", PlainHTTP: true}
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: opts, Path: testModelPackage(t)}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := credentials.NewMemoryStore()
+	if err := store.Put(ctx, host, auth.Credential{Username: "user", Password: "password"}); err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name     string
+		username string
+		password string
+		store    credentials.Store
+		wantErr  bool
+	}{
+		{"username and password", "user", "password", nil, false},
+		{"credential store", "", "", store, false},
+		{"username and password over credential store", "user", "wrong", store, true},
+		{"anonymous", "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := RegistryOptions{Registry: host, Repository: "models/test", Tag: "v1", Username: tt.username, Password: tt.password, Credentials: tt.store, PlainHTTP: true, Config: &RegistryConfig{MaxRetries: -1}}
+			_, desc, err := resolve(ctx, opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := PullImage(opts, desc.Digest.String()); err != nil {
+				t.Errorf("PullImage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistryTLS(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewUnstartedServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	// the handshakes failing on purpose are logged by the server
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	push := RegistryOptions{Registry: host, Repository: "models/test", Tag: "v1", Config: &RegistryConfig{CACerts: caCert}}
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: push, Path: testModelPackage(t)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name    string
+		config  *RegistryConfig
+		wantErr bool
+	}{
+		{"system certificates", nil, true},
+		{"CA certificates of all registries", &RegistryConfig{CACerts: caCert}, false},
+		{"CA certificates of the registry", &RegistryConfig{Hosts: map[string]HostConfig{host: {CACerts: caCert}}}, false},
+		{"CA certificates of another registry", &RegistryConfig{Hosts: map[string]HostConfig{"registry.example.com": {CACerts: caCert}}}, true},
+		{"insecure skip verify", &RegistryConfig{Hosts: map[string]HostConfig{host: {InsecureSkipVerify: true}}}, false},
+		{"invalid CA certificates", &RegistryConfig{Hosts: map[string]HostConfig{host: {CACerts: []byte("not a certificate")}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.config != nil {
+				tt.config.MaxRetries = -1
+			}
+			opts := RegistryOptions{Registry: host, Repository: "models/test", Tag: "v1", Config: tt.config}
+			_, desc, err := resolve(ctx, opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := PullImage(opts, desc.Digest.String()); err != nil {
+				t.Errorf("PullImage() error = %v", err)
+			}
+		})
+	}
+}
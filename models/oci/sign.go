@@ -0,0 +1,284 @@
+package oci
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// Signatures use the cosign simple signing format and are stored as OCI referrers of the signed manifest.
+const (
+	// ArtifactTypeSignature is the artifact type of the manifests holding signatures.
+	ArtifactTypeSignature = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// MediaTypeSimpleSigning is the media type of the signed payload.
+	MediaTypeSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// AnnotationSignature holds the base64 encoded signature of the payload layer.
+	AnnotationSignature = "dev.cosignproject.cosign/signature"
+
+	simpleSigningType = "cosign container image signature"
+)
+
+// simpleSigningPayload is the payload of a cosign signature, it binds the signature to the digest of the manifest.
+type simpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// Signer signs model artifacts with a private key.
+type Signer struct {
+	key crypto.Signer
+}
+
+// NewSigner returns a signer for the PEM encoded, unencrypted, PKCS#8, EC or PKCS#1 private key.
+// Keys generated by cosign can be converted to this format, their public keys can be used as is.
+func NewSigner(privateKeyPEM []byte) (*Signer, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, ErrInvalidKey(fmt.Errorf("no PEM data found"))
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, ErrInvalidKey(err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrInvalidKey(fmt.Errorf("unsupported private key type %T", key))
+	}
+	return &Signer{key: signer}, nil
+}
+
+// GenerateKeyPair generates an ECDSA P-256 key pair, returned as PEM encoded PKCS#8 private key and PKIX public key.
+func GenerateKeyPair() (privateKeyPEM, publicKeyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, ErrInvalidKey(err)
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, ErrInvalidKey(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, nil, ErrInvalidKey(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), nil
+}
+
+func (s *Signer) sign(payload []byte) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(payload)
+	return s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// TrustPolicy decides which artifacts are accepted when pulling or registering models.
+type TrustPolicy struct {
+	// PublicKeys are the PEM encoded public keys trusted to sign artifacts.
+	PublicKeys [][]byte
+	// RequireSignature rejects artifacts without a valid signature by one of the trusted keys.
+	// Otherwise unsigned artifacts are accepted, while artifacts whose signatures do not match their content are rejected.
+	RequireSignature bool
+}
+
+func (p TrustPolicy) keys() ([]crypto.PublicKey, error) {
+	keys := make([]crypto.PublicKey, 0, len(p.PublicKeys))
+	for _, keyPEM := range p.PublicKeys {
+		block, _ := pem.Decode(keyPEM)
+		if block == nil {
+			return nil, ErrInvalidKey(fmt.Errorf("no PEM data found"))
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, ErrInvalidKey(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// SignArtifact signs the artifact tagged opts.Tag and pushes the signature to the repository as a referrer of the artifact.
+func SignArtifact(opts RegistryOptions, signer *Signer) error {
+	repo, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	desc, err := repo.Resolve(ctx, opts.Tag)
+	if err != nil {
+		return ErrSigningArtifact(err)
+	}
+	return sign(ctx, repo, opts, desc, signer)
+}
+
+func sign(ctx context.Context, repo *remote.Repository, opts RegistryOptions, desc v1.Descriptor, signer *Signer) error {
+	var payload simpleSigningPayload
+	payload.Critical.Identity.DockerReference = opts.Registry + "/" + opts.Repository
+	payload.Critical.Image.DockerManifestDigest = desc.Digest.String()
+	payload.Critical.Type = simpleSigningType
+	byt, err := json.Marshal(payload)
+	if err != nil {
+		return ErrSigningArtifact(err)
+	}
+
+	signature, err := signer.sign(byt)
+	if err != nil {
+		return ErrSigningArtifact(err)
+	}
+
+	layer, err := oras.PushBytes(ctx, repo, MediaTypeSimpleSigning, byt)
+	if err != nil {
+		return ErrSigningArtifact(err)
+	}
+	layer.Annotations = map[string]string{AnnotationSignature: base64.StdEncoding.EncodeToString(signature)}
+
+	_, err = oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1_RC4, ArtifactTypeSignature, oras.PackManifestOptions{
+		Subject: &desc,
+		Layers:  []v1.Descriptor{layer},
+	})
+	if err != nil {
+		return ErrSigningArtifact(err)
+	}
+	return nil
+}
+
+// VerifyArtifact checks the signatures of the artifact tagged opts.Tag against the trust policy.
+// It returns the descriptor of the verified manifest, which should be used to fetch the artifact so that it cannot change after verification.
+func VerifyArtifact(opts RegistryOptions, policy TrustPolicy) (v1.Descriptor, error) {
+	ctx := context.Background()
+	repo, desc, err := resolve(ctx, opts)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	return desc, verify(ctx, repo, desc, policy)
+}
+
+func verify(ctx context.Context, repo *remote.Repository, desc v1.Descriptor, policy TrustPolicy) error {
+	keys, err := policy.keys()
+	if err != nil {
+		return err
+	}
+
+	var signatures []v1.Descriptor
+	err = repo.Referrers(ctx, desc, ArtifactTypeSignature, func(referrers []v1.Descriptor) error {
+		signatures = append(signatures, referrers...)
+		return nil
+	})
+	if err != nil {
+		return ErrVerifyingSignature(err)
+	}
+	if len(signatures) == 0 {
+		if policy.RequireSignature {
+			return ErrVerifyingSignature(fmt.Errorf("artifact %s is not signed", desc.Digest))
+		}
+		return nil
+	}
+
+	// anyone who can push to the repository can attach referrers, invalid signatures are skipped rather than failing the verification
+	invalid := []string{}
+	for _, signature := range signatures {
+		trusted, err := verifySignature(ctx, repo, desc, signature, keys)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		if trusted {
+			return nil
+		}
+	}
+	if policy.RequireSignature {
+		err := fmt.Errorf("artifact %s is not signed by a trusted key", desc.Digest)
+		if len(invalid) > 0 {
+			err = fmt.Errorf("%w, invalid signatures: %s", err, strings.Join(invalid, "; "))
+		}
+		return ErrVerifyingSignature(err)
+	}
+	return nil
+}
+
+// verifySignature reports whether the signature was made by one of the keys.
+// An error is returned if the signature cannot be read or does not belong to the artifact.
+func verifySignature(ctx context.Context, repo *remote.Repository, subject, signature v1.Descriptor, keys []crypto.PublicKey) (bool, error) {
+	byt, err := content.FetchAll(ctx, repo, signature)
+	if err != nil {
+		return false, err
+	}
+	var manifest v1.Manifest
+	if err := json.Unmarshal(byt, &manifest); err != nil {
+		return false, err
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypeSimpleSigning {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(layer.Annotations[AnnotationSignature])
+		if err != nil {
+			return false, err
+		}
+		payloadBytes, err := content.FetchAll(ctx, repo, layer)
+		if err != nil {
+			return false, err
+		}
+		var payload simpleSigningPayload
+		if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+			return false, err
+		}
+		if payload.Critical.Image.DockerManifestDigest != subject.Digest.String() {
+			return false, fmt.Errorf("signature %s is for %s, not %s", signature.Digest, payload.Critical.Image.DockerManifestDigest, subject.Digest)
+		}
+		for _, key := range keys {
+			if verifyWithKey(key, payloadBytes, sig) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func verifyWithKey(key crypto.PublicKey, payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+)
+
+// signBogus attaches a signature of the payload of another digest to the artifact, as anyone with write access to the repository could.
+func signBogus(t *testing.T, opts RegistryOptions, signer *Signer, otherDigest string) {
+	t.Helper()
+	ctx := context.Background()
+	repo, desc, err := resolve(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload simpleSigningPayload
+	payload.Critical.Image.DockerManifestDigest = otherDigest
+	payload.Critical.Type = simpleSigningType
+	byt, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := signer.sign(byt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer, err := oras.PushBytes(ctx, repo, MediaTypeSimpleSigning, byt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer.Annotations = map[string]string{AnnotationSignature: base64.StdEncoding.EncodeToString(signature)}
+	_, err = oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1_RC4, ArtifactTypeSignature, oras.PackManifestOptions{Subject: &desc, Layers: []v1.Descriptor{layer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignAndVerifyArtifact(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	dir := t.TempDir()
+	pkg := filepath.Join(dir, "model")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkg, "model.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, publicKey, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPublicKey, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSigner(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := RegistryOptions{Registry: host, Repository: "models/test", Tag: "signed", PlainHTTP: true}
+	unsigned := RegistryOptions{Registry: host, Repository: "models/test", Tag: "unsigned", PlainHTTP: true}
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: signed, Path: pkg, Signer: signer}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: unsigned, Path: pkg, Annotations: map[string]string{"unsigned": "true"}}); err != nil {
+		t.Fatal(err)
+	}
+	// the bogus signatures are made by the trusted key for another artifact
+	bogus := RegistryOptions{Registry: host, Repository: "models/test", Tag: "bogus", PlainHTTP: true}
+	resigned := RegistryOptions{Registry: host, Repository: "models/test", Tag: "resigned", PlainHTTP: true}
+	for i, opts := range []RegistryOptions{bogus, resigned} {
+		if err := PushToOCIRegistry(PushOptions{RegistryOptions: opts, Path: pkg, Annotations: map[string]string{"bogus": fmt.Sprint(i)}}); err != nil {
+			t.Fatal(err)
+		}
+		signBogus(t, opts, signer, "sha256:"+strings.Repeat("0", 64))
+	}
+	if err := SignArtifact(resigned, signer); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name    string
+		opts    RegistryOptions
+		policy  TrustPolicy
+		wantErr bool
+	}{
+		{"signed by trusted key", signed, TrustPolicy{PublicKeys: [][]byte{publicKey}, RequireSignature: true}, false},
+		{"signed by untrusted key", signed, TrustPolicy{PublicKeys: [][]byte{otherPublicKey}, RequireSignature: true}, true},
+		{"unsigned with required signature", unsigned, TrustPolicy{PublicKeys: [][]byte{publicKey}, RequireSignature: true}, true},
+		{"unsigned with optional signature", unsigned, TrustPolicy{PublicKeys: [][]byte{publicKey}}, false},
+		{"bogus signature with required signature", bogus, TrustPolicy{PublicKeys: [][]byte{publicKey}, RequireSignature: true}, true},
+		{"bogus signature with optional signature", bogus, TrustPolicy{PublicKeys: [][]byte{publicKey}}, false},
+		{"bogus and trusted signatures", resigned, TrustPolicy{PublicKeys: [][]byte{publicKey}, RequireSignature: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyArtifact(tt.opts, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyArtifact() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	policy := TrustPolicy{PublicKeys: [][]byte{publicKey}, RequireSignature: true}
+	if err := PullFromOCIRegistry(PullOptions{RegistryOptions: signed, Path: t.TempDir(), TrustPolicy: &policy}); err != nil {
+		t.Errorf("PullFromOCIRegistry() of signed artifact error = %v", err)
+	}
+	if err := PullFromOCIRegistry(PullOptions{RegistryOptions: unsigned, Path: t.TempDir(), TrustPolicy: &policy}); err == nil {
+		t.Errorf("PullFromOCIRegistry() of unsigned artifact succeeded, want error")
+	}
+}
@@ -0,0 +1,219 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/layer5io/meshkit/utils"
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// Store caches model artifacts in a local directory using the OCI image layout.
+// Artifacts are stored by digest and tagged with their full reference, e.g. ghcr.io/meshery/models/kubernetes:v1.31.0,
+// so that they can be pulled again without access to the registry. The directory can be copied to preload air-gapped servers.
+type Store struct {
+	root   string
+	layout *oci.Store
+}
+
+// CacheOptions configures Store.Pull.
+type CacheOptions struct {
+	RegistryOptions
+	// TrustPolicy verifies the signatures of the artifact before it is cached, it is not used for offline pulls.
+	TrustPolicy *TrustPolicy
+	// Offline resolves the reference from the store only, without contacting the registry.
+	Offline bool
+}
+
+// DefaultStorePath returns the directory of the store shared by meshery components.
+func DefaultStorePath() string {
+	return filepath.Join(utils.GetHome(), ".meshery", "models", "oci")
+}
+
+// NewStore opens the store at root, it is created if it does not exist.
+func NewStore(root string) (*Store, error) {
+	layout, err := oci.New(root)
+	if err != nil {
+		return nil, ErrStore(err)
+	}
+	return &Store{root: root, layout: layout}, nil
+}
+
+// Root returns the directory of the store, an OCI image layout.
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Reference returns the reference an artifact is tagged with in the store.
+func Reference(opts RegistryOptions) string {
+	return fmt.Sprintf("%s/%s:%s", opts.Registry, opts.Repository, opts.Tag)
+}
+
+// Pull caches the artifact and returns the descriptor of its manifest.
+// Only the tag is resolved against the registry when an artifact with the same digest is already cached.
+func (s *Store) Pull(opts CacheOptions) (v1.Descriptor, error) {
+	ctx := context.Background()
+	reference := Reference(opts.RegistryOptions)
+
+	if opts.Offline {
+		return s.Resolve(reference)
+	}
+
+	repo, desc, err := resolve(ctx, opts.RegistryOptions)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	if opts.TrustPolicy != nil {
+		if err := verify(ctx, repo, desc, *opts.TrustPolicy); err != nil {
+			return v1.Descriptor{}, err
+		}
+	}
+
+	cached, err := s.layout.Exists(ctx, desc)
+	if err != nil {
+		return v1.Descriptor{}, ErrStore(err)
+	}
+	if !cached {
+		err = oras.CopyGraph(ctx, repo, s.layout, desc, oras.DefaultCopyGraphOptions)
+		if err != nil {
+			return v1.Descriptor{}, ErrGettingImage(err)
+		}
+	}
+	if err := s.layout.Tag(ctx, desc, reference); err != nil {
+		return v1.Descriptor{}, ErrStore(err)
+	}
+	return desc, nil
+}
+
+// Add stores an image, e.g. one built with BuildImage, and tags it with reference.
+func (s *Store) Add(img gcrv1.Image, reference string) (v1.Descriptor, error) {
+	ctx := context.Background()
+
+	layers, err := img.Layers()
+	if err != nil {
+		return v1.Descriptor{}, ErrGettingLayer(err)
+	}
+	for _, layer := range layers {
+		desc, err := layerDescriptor(layer)
+		if err != nil {
+			return v1.Descriptor{}, ErrGettingLayer(err)
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return v1.Descriptor{}, ErrGettingLayer(err)
+		}
+		err = s.push(ctx, desc, rc)
+		rc.Close()
+		if err != nil {
+			return v1.Descriptor{}, err
+		}
+	}
+
+	config, err := img.RawConfigFile()
+	if err != nil {
+		return v1.Descriptor{}, ErrGettingImage(err)
+	}
+	manifest, err := img.RawManifest()
+	if err != nil {
+		return v1.Descriptor{}, ErrGettingImage(err)
+	}
+	parsed, err := img.Manifest()
+	if err != nil {
+		return v1.Descriptor{}, ErrGettingImage(err)
+	}
+	mediaType, err := img.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, ErrGettingImage(err)
+	}
+
+	if err := s.push(ctx, content.NewDescriptorFromBytes(string(parsed.Config.MediaType), config), bytes.NewReader(config)); err != nil {
+		return v1.Descriptor{}, err
+	}
+	desc := content.NewDescriptorFromBytes(string(mediaType), manifest)
+	if err := s.push(ctx, desc, bytes.NewReader(manifest)); err != nil {
+		return v1.Descriptor{}, err
+	}
+	if err := s.layout.Tag(ctx, desc, reference); err != nil {
+		return v1.Descriptor{}, ErrStore(err)
+	}
+	return desc, nil
+}
+
+func (s *Store) push(ctx context.Context, desc v1.Descriptor, r io.Reader) error {
+	err := s.layout.Push(ctx, desc, r)
+	if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return ErrStore(err)
+	}
+	return nil
+}
+
+func layerDescriptor(layer gcrv1.Layer) (v1.Descriptor, error) {
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	hash, err := layer.Digest()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	size, err := layer.Size()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	return v1.Descriptor{MediaType: string(mediaType), Digest: digest.Digest(hash.String()), Size: size}, nil
+}
+
+// Resolve returns the descriptor of the cached artifact tagged with reference.
+func (s *Store) Resolve(reference string) (v1.Descriptor, error) {
+	desc, err := s.layout.Resolve(context.Background(), reference)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return v1.Descriptor{}, ErrArtifactNotCached(reference)
+	}
+	if err != nil {
+		return v1.Descriptor{}, ErrStore(err)
+	}
+	return desc, nil
+}
+
+// References returns the references of the cached artifacts.
+func (s *Store) References() ([]string, error) {
+	references := []string{}
+	err := s.layout.Tags(context.Background(), "", func(tags []string) error {
+		references = append(references, tags...)
+		return nil
+	})
+	if err != nil {
+		return nil, ErrStore(err)
+	}
+	return references, nil
+}
+
+// Remove untags the artifact, its content is deleted by the next GC if no other reference points to it.
+func (s *Store) Remove(reference string) error {
+	err := s.layout.Untag(context.Background(), reference)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return ErrArtifactNotCached(reference)
+	}
+	if err != nil {
+		return ErrStore(err)
+	}
+	return nil
+}
+
+// GC deletes the blobs which are not reachable from any reference.
+func (s *Store) GC() error {
+	if err := s.layout.GC(context.Background()); err != nil {
+		return ErrStore(err)
+	}
+	return nil
+}
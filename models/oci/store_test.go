@@ -0,0 +1,72 @@
+package oci
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestStore(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	pkg := filepath.Join(t.TempDir(), "model")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkg, "model.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := RegistryOptions{Registry: host, Repository: "models/test", Tag: "v1.0.0", PlainHTTP: true}
+	if err := PushToOCIRegistry(PushOptions{RegistryOptions: opts, Path: pkg}); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Pull(CacheOptions{RegistryOptions: opts, Offline: true}); err == nil {
+		t.Errorf("offline Pull() of uncached artifact succeeded, want error")
+	}
+	pulled, err := store.Pull(CacheOptions{RegistryOptions: opts})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	server.Close()
+	cached, err := store.Pull(CacheOptions{RegistryOptions: opts, Offline: true})
+	if err != nil {
+		t.Fatalf("offline Pull() error = %v", err)
+	}
+	if cached.Digest != pulled.Digest {
+		t.Errorf("offline Pull() digest = %s, want %s", cached.Digest, pulled.Digest)
+	}
+
+	img, err := BuildImage(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(img, "local/test:dev"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := store.Remove(Reference(opts)); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := store.GC(); err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+	if _, err := store.Resolve(Reference(opts)); err == nil {
+		t.Errorf("Resolve() of removed artifact succeeded, want error")
+	}
+	if _, err := store.Resolve("local/test:dev"); err != nil {
+		t.Errorf("Resolve() of added artifact error = %v", err)
+	}
+}
@@ -77,7 +77,65 @@ func ValidateOCIArtifact(tarballPath string) error {
 		return err
 	}
 
-	return validate.Image(img)
+	return ValidateOCIImage(img)
+}
+
+// ValidateOCIImage validates the manifest, config and layers of the image.
+// Artifacts, such as the ones pushed by PushToOCIRegistry, do not have an image config and only the digests of their content are verified.
+func ValidateOCIImage(img gcrv1.Image) error {
+	raw, err := img.RawManifest()
+	if err != nil {
+		return err
+	}
+	var artifact struct {
+		ArtifactType string `json:"artifactType"`
+	}
+	if err := json.Unmarshal(raw, &artifact); err != nil {
+		return err
+	}
+	if artifact.ArtifactType == "" {
+		return validate.Image(img)
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	config, err := img.RawConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := verifyDigest(bytes.NewReader(config), manifest.Config.Digest); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	for i, layer := range layers {
+		rc, err := layer.Compressed()
+		if err != nil {
+			return err
+		}
+		err = verifyDigest(rc, manifest.Layers[i].Digest)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("layer %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func verifyDigest(r io.Reader, expected gcrv1.Hash) error {
+	actual, _, err := gcrv1.SHA256(r)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("digest mismatch, expected %s got %s", expected, actual)
+	}
+	return nil
 }
 
 // IsOCIArtifact checks if the tarball is an OCI artifact by looking for manifest.json or index.json
@@ -0,0 +1,376 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "modified"
+)
+
+// Change is a change of a value of a component or relationship, located by a JSON pointer, e.g. /configuration/spec/replicas
+type Change struct {
+	Path string      `json:"path"`
+	Type ChangeType  `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+type ComponentChange struct {
+	Type ChangeType `json:"type"`
+	// ID is the id of the component in the new design, or in the old design if it has been removed.
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Kind string    `json:"kind"`
+	// Changes are the changes of the fields of modified components.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+type RelationshipChange struct {
+	Type ChangeType `json:"type"`
+	// ID is the id of the relationship in the new design, or in the old design if it has been removed.
+	ID               uuid.UUID `json:"id"`
+	Kind             string    `json:"kind"`
+	RelationshipType string    `json:"relationshipType"`
+	SubType          string    `json:"subType"`
+	// Changes are the changes of the fields of modified relationships.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+type DesignDiff struct {
+	Components    []ComponentChange    `json:"components"`
+	Relationships []RelationshipChange `json:"relationships"`
+}
+
+func (d DesignDiff) Empty() bool {
+	return len(d.Components) == 0 && len(d.Relationships) == 0
+}
+
+/*
+Diff compares two versions of a design.
+Components are matched by id first, then by kind and display name, so that components which have been recreated are reported as modified.
+Relationships are matched by id first, then by kind, type, sub type and the components they relate.
+A nil design is compared as an empty design, null components and relationships are ignored.
+*/
+func Diff(old, new *pattern.PatternFile) (DesignDiff, error) {
+	diff := DesignDiff{Components: []ComponentChange{}, Relationships: []RelationshipChange{}}
+	if old == nil {
+		old = &pattern.PatternFile{}
+	}
+	if new == nil {
+		new = &pattern.PatternFile{}
+	}
+
+	components := matchComponents(old.Components, new.Components)
+	for _, m := range components.pairs {
+		oldComp, newComp := old.Components[m.old], new.Components[m.new]
+		changes, err := diffEntities(oldComp, newComp, nil)
+		if err != nil {
+			return diff, ErrDiffDesigns(err)
+		}
+		if len(changes) > 0 {
+			diff.Components = append(diff.Components, ComponentChange{Type: Modified, ID: newComp.Id, Name: newComp.DisplayName, Kind: newComp.Component.Kind, Changes: changes})
+		}
+	}
+	// null entries are never matched, they are neither removed nor added
+	for _, i := range components.removed {
+		c := old.Components[i]
+		if c == nil {
+			continue
+		}
+		diff.Components = append(diff.Components, ComponentChange{Type: Removed, ID: c.Id, Name: c.DisplayName, Kind: c.Component.Kind})
+	}
+	for _, i := range components.added {
+		c := new.Components[i]
+		if c == nil {
+			continue
+		}
+		diff.Components = append(diff.Components, ComponentChange{Type: Added, ID: c.Id, Name: c.DisplayName, Kind: c.Component.Kind})
+	}
+
+	ids := components.ids(old.Components, new.Components)
+	selectorIDs := map[string]string{}
+	for from, to := range ids {
+		selectorIDs[from.String()] = to.String()
+	}
+	relationships := matchRelationships(old.Relationships, new.Relationships, ids)
+	for _, m := range relationships.pairs {
+		oldRel, newRel := old.Relationships[m.old], new.Relationships[m.new]
+		changes, err := diffEntities(oldRel, newRel, selectorIDs)
+		if err != nil {
+			return diff, ErrDiffDesigns(err)
+		}
+		if len(changes) > 0 {
+			diff.Relationships = append(diff.Relationships, relationshipChange(Modified, newRel, changes))
+		}
+	}
+	for _, i := range relationships.removed {
+		if rel := old.Relationships[i]; rel != nil {
+			diff.Relationships = append(diff.Relationships, relationshipChange(Removed, rel, nil))
+		}
+	}
+	for _, i := range relationships.added {
+		if rel := new.Relationships[i]; rel != nil {
+			diff.Relationships = append(diff.Relationships, relationshipChange(Added, rel, nil))
+		}
+	}
+	return diff, nil
+}
+
+func relationshipChange(t ChangeType, rel *relationship.RelationshipDefinition, changes []Change) RelationshipChange {
+	return RelationshipChange{Type: t, ID: rel.Id, Kind: string(rel.Kind), RelationshipType: rel.RelationshipType, SubType: rel.SubType, Changes: changes}
+}
+
+// pair is a pair of matched entities, by index
+type pair struct{ old, new int }
+
+type matches struct {
+	pairs   []pair
+	removed []int
+	added   []int
+}
+
+// ids maps the ids of the matched components of the old design to the ids in the new design.
+func (m matches) ids(old, new []*component.ComponentDefinition) map[uuid.UUID]uuid.UUID {
+	ids := map[uuid.UUID]uuid.UUID{}
+	for _, p := range m.pairs {
+		ids[old[p.old].Id] = new[p.new].Id
+	}
+	return ids
+}
+
+/*
+match pairs the entities by the keys, in order: entities are paired by their first key, the unmatched ones by their second key, and so on.
+Keys which are empty or not unique within the remaining entities are not used.
+*/
+func match(oldCount, newCount int, keys ...func(old bool, i int) string) matches {
+	matchedOld := map[int]bool{}
+	matchedNew := map[int]bool{}
+	result := matches{}
+	for _, key := range keys {
+		index := func(old bool, count int, matched map[int]bool) map[string]int {
+			byKey := map[string]int{}
+			duplicates := map[string]bool{}
+			for i := 0; i < count; i++ {
+				if matched[i] {
+					continue
+				}
+				k := key(old, i)
+				if k == "" {
+					continue
+				}
+				if _, ok := byKey[k]; ok {
+					duplicates[k] = true
+				}
+				byKey[k] = i
+			}
+			for k := range duplicates {
+				delete(byKey, k)
+			}
+			return byKey
+		}
+		oldByKey := index(true, oldCount, matchedOld)
+		newByKey := index(false, newCount, matchedNew)
+		for k, i := range oldByKey {
+			if j, ok := newByKey[k]; ok {
+				result.pairs = append(result.pairs, pair{old: i, new: j})
+				matchedOld[i] = true
+				matchedNew[j] = true
+			}
+		}
+	}
+	sort.Slice(result.pairs, func(i, j int) bool { return result.pairs[i].new < result.pairs[j].new })
+	for i := 0; i < oldCount; i++ {
+		if !matchedOld[i] {
+			result.removed = append(result.removed, i)
+		}
+	}
+	for i := 0; i < newCount; i++ {
+		if !matchedNew[i] {
+			result.added = append(result.added, i)
+		}
+	}
+	return result
+}
+
+func matchComponents(old, new []*component.ComponentDefinition) matches {
+	get := func(isOld bool, i int) *component.ComponentDefinition {
+		if isOld {
+			return old[i]
+		}
+		return new[i]
+	}
+	return match(len(old), len(new),
+		func(isOld bool, i int) string {
+			if c := get(isOld, i); c != nil && c.Id != uuid.Nil {
+				return c.Id.String()
+			}
+			return ""
+		},
+		func(isOld bool, i int) string {
+			if c := get(isOld, i); c != nil {
+				return c.Component.Kind + "/" + c.DisplayName
+			}
+			return ""
+		},
+	)
+}
+
+// matchRelationships pairs the relationships, ids maps the ids of the components of the old design to the ones of the new design.
+func matchRelationships(old, new []*relationship.RelationshipDefinition, ids map[uuid.UUID]uuid.UUID) matches {
+	get := func(isOld bool, i int) *relationship.RelationshipDefinition {
+		if isOld {
+			return old[i]
+		}
+		return new[i]
+	}
+	return match(len(old), len(new),
+		func(isOld bool, i int) string {
+			if r := get(isOld, i); r != nil && r.Id != uuid.Nil {
+				return r.Id.String()
+			}
+			return ""
+		},
+		func(isOld bool, i int) string {
+			r := get(isOld, i)
+			if r == nil {
+				return ""
+			}
+			return fmt.Sprintf("%s/%s/%s/%s", r.Kind, r.RelationshipType, r.SubType, strings.Join(relatedComponents(r, isOld, ids), ","))
+		},
+	)
+}
+
+// relatedComponents returns the ids of the components selected by the from and to selectors of the relationship, mapped to the ids in the new design.
+func relatedComponents(r *relationship.RelationshipDefinition, old bool, ids map[uuid.UUID]uuid.UUID) []string {
+	related := []string{}
+	if r.Selectors == nil {
+		return related
+	}
+	add := func(prefix string, items []relationship.SelectorItem) {
+		for _, item := range items {
+			if item.Id == nil {
+				continue
+			}
+			id := *item.Id
+			if mapped, ok := ids[id]; ok && old {
+				id = mapped
+			}
+			related = append(related, prefix+id.String())
+		}
+	}
+	for _, set := range *r.Selectors {
+		add("from:", set.Allow.From)
+		add("to:", set.Allow.To)
+	}
+	sort.Strings(related)
+	return related
+}
+
+// toJSONValue converts the value to its generic JSON representation.
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+/*
+diffEntities compares the JSON representations of the entities, ignoring their ids.
+The component ids the selectors of old refer to are mapped by ids first, so that recreated components are not reported as changes.
+*/
+func diffEntities(old, new interface{}, ids map[string]string) ([]Change, error) {
+	oldValue, err := toJSONValue(old)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := toJSONValue(new)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := oldValue.(map[string]interface{}); ok {
+		delete(m, "id")
+		remapSelectorIDs(m["selectors"], ids)
+	}
+	if m, ok := newValue.(map[string]interface{}); ok {
+		delete(m, "id")
+	}
+	changes := []Change{}
+	diffValues("", oldValue, newValue, &changes)
+	return changes, nil
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer appends the token to the JSON pointer, escaping it as defined by RFC 6901.
+func pointer(path string, token string) string {
+	return path + "/" + pointerEscaper.Replace(token)
+}
+
+/*
+diffValues records the changes turning old into new.
+Objects are compared key by key, arrays of the same length element by element, other arrays are reported as modified as a whole.
+*/
+func diffValues(path string, old, new interface{}, changes *[]Change) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(n))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ov, inOld := o[k]
+			nv, inNew := n[k]
+			switch {
+			case !inOld:
+				*changes = append(*changes, Change{Path: pointer(path, k), Type: Added, New: nv})
+			case !inNew:
+				*changes = append(*changes, Change{Path: pointer(path, k), Type: Removed, Old: ov})
+			default:
+				diffValues(pointer(path, k), ov, nv, changes)
+			}
+		}
+		return
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok || len(n) != len(o) {
+			break
+		}
+		for i := range o {
+			diffValues(pointer(path, fmt.Sprint(i)), o[i], n[i], changes)
+		}
+		return
+	}
+	*changes = append(*changes, Change{Path: path, Type: Modified, Old: old, New: new})
+}
@@ -0,0 +1,161 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+const (
+	webID     = "00000000-0000-0000-0000-00000000000a"
+	webNewID  = "00000000-0000-0000-0000-00000000000b"
+	svcID     = "00000000-0000-0000-0000-00000000000c"
+	svcNewID  = "00000000-0000-0000-0000-00000000000d"
+	cfgID     = "00000000-0000-0000-0000-00000000000e"
+	edgeID    = "00000000-0000-0000-0000-0000000000f1"
+	edgeNewID = "00000000-0000-0000-0000-0000000000f2"
+	replicas1 = `{"spec": {"replicas": 1, "image": "nginx:1"}}`
+	replicas2 = `{"spec": {"replicas": 2, "image": "nginx:1"}}`
+)
+
+func testComponentJSON(id, kind, name, configuration string) string {
+	if configuration == "" {
+		configuration = "{}"
+	}
+	return fmt.Sprintf(`{"id": %q, "displayName": %q, "component": {"kind": %q, "version": "v1"}, "model": {"name": "kubernetes"}, "configuration": %s}`, id, name, kind, configuration)
+}
+
+func testRelationshipJSON(id, subType, from, to string) string {
+	return fmt.Sprintf(`{"id": %q, "kind": "edge", "type": "non-binding", "subType": %q, "schemaVersion": "relationships.meshery.io/v1alpha3", "version": "v1.0.0", "model": {"name": "kubernetes"},
+		"selectors": [{"allow": {"from": [{"id": %q, "kind": "Deployment"}], "to": [{"id": %q, "kind": "Service"}]}}]}`, id, subType, from, to)
+}
+
+// testDesign returns a design with the given components and relationships, as JSON, null entries are allowed.
+func testDesign(t *testing.T, components []string, relationships ...string) *pattern.PatternFile {
+	t.Helper()
+	data := fmt.Sprintf(`{"name": "test", "schemaVersion": "designs.meshery.io/v1beta1", "version": "0.0.1", "components": [%s], "relationships": [%s]}`,
+		strings.Join(components, ","), strings.Join(relationships, ","))
+	design := &pattern.PatternFile{}
+	if err := json.Unmarshal([]byte(data), design); err != nil {
+		t.Fatal(err)
+	}
+	return design
+}
+
+// summary describes a change as <type> <name or sub type> <id> [paths]
+func (c ComponentChange) summary() string {
+	return fmt.Sprintf("%s %s %s %v", c.Type, c.Name, c.ID, changePaths(c.Changes))
+}
+
+func (c RelationshipChange) summary() string {
+	return fmt.Sprintf("%s %s %s %v", c.Type, c.SubType, c.ID, changePaths(c.Changes))
+}
+
+func changePaths(changes []Change) []string {
+	paths := []string{}
+	for _, c := range changes {
+		paths = append(paths, string(c.Type)+" "+c.Path)
+	}
+	return paths
+}
+
+func TestDiff(t *testing.T) {
+	web := testComponentJSON(webID, "Deployment", "web", replicas1)
+	svc := testComponentJSON(svcID, "Service", "web", "")
+	edge := testRelationshipJSON(edgeID, "network", webID, svcID)
+	tests := []struct {
+		name              string
+		old, new          *pattern.PatternFile
+		wantComponents    []string
+		wantRelationships []string
+	}{
+		{
+			"unchanged",
+			testDesign(t, []string{web, svc}, edge), testDesign(t, []string{svc, web}, edge),
+			[]string{}, []string{},
+		},
+		{
+			"matched by id",
+			testDesign(t, []string{web}), testDesign(t, []string{testComponentJSON(webID, "Deployment", "frontend", replicas2)}),
+			[]string{"modified frontend " + webID + " [modified /configuration/spec/replicas modified /displayName]"}, []string{},
+		},
+		{
+			"matched by kind and name",
+			testDesign(t, []string{web}), testDesign(t, []string{testComponentJSON(webNewID, "Deployment", "web", replicas2)}),
+			[]string{"modified web " + webNewID + " [modified /configuration/spec/replicas]"}, []string{},
+		},
+		{
+			"same name of another kind",
+			testDesign(t, []string{web}), testDesign(t, []string{testComponentJSON(webNewID, "StatefulSet", "web", replicas1)}),
+			[]string{"removed web " + webID + " []", "added web " + webNewID + " []"}, []string{},
+		},
+		{
+			"added and removed",
+			testDesign(t, []string{web, svc}), testDesign(t, []string{web, testComponentJSON(cfgID, "ConfigMap", "config", "")}),
+			[]string{"removed web " + svcID + " []", "added config " + cfgID + " []"}, []string{},
+		},
+		{
+			"recreated components keep their relationships",
+			testDesign(t, []string{web, svc}, edge),
+			testDesign(t, []string{testComponentJSON(webNewID, "Deployment", "web", replicas1), testComponentJSON(svcNewID, "Service", "web", "")},
+				testRelationshipJSON(edgeNewID, "network", webNewID, svcNewID)),
+			[]string{}, []string{},
+		},
+		{
+			"relationship to another component",
+			testDesign(t, []string{web, svc}, edge),
+			testDesign(t, []string{web, svc, testComponentJSON(cfgID, "ConfigMap", "config", "")}, testRelationshipJSON(edgeID, "network", webID, cfgID)),
+			[]string{"added config " + cfgID + " []"},
+			[]string{"modified network " + edgeID + " [modified /selectors/0/allow/to/0/id]"},
+		},
+		{
+			"relationship matched by its components",
+			testDesign(t, []string{web, svc}, edge),
+			testDesign(t, []string{web, svc}, testRelationshipJSON(edgeNewID, "mount", webID, svcID)),
+			[]string{}, []string{"removed network " + edgeID + " []", "added mount " + edgeNewID + " []"},
+		},
+		{
+			"null entries",
+			testDesign(t, []string{"null", web}, "null"), testDesign(t, []string{web, "null"}, "null", edge),
+			[]string{}, []string{"added network " + edgeID + " []"},
+		},
+		{
+			"nil designs",
+			nil, testDesign(t, []string{web}),
+			[]string{"added web " + webID + " []"}, []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := Diff(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			components := []string{}
+			for _, c := range diff.Components {
+				components = append(components, c.summary())
+			}
+			relationships := []string{}
+			for _, r := range diff.Relationships {
+				relationships = append(relationships, r.summary())
+			}
+			if !reflect.DeepEqual(components, tt.wantComponents) {
+				t.Errorf("Diff() components = %q, want %q", components, tt.wantComponents)
+			}
+			if !reflect.DeepEqual(relationships, tt.wantRelationships) {
+				t.Errorf("Diff() relationships = %q, want %q", relationships, tt.wantRelationships)
+			}
+			if diff.Empty() != (len(tt.wantComponents) == 0 && len(tt.wantRelationships) == 0) {
+				t.Errorf("Empty() = %v", diff.Empty())
+			}
+		})
+	}
+
+	if diff, err := Diff(testDesign(t, []string{web}), nil); err != nil || len(diff.Components) != 1 || diff.Components[0].Type != Removed {
+		t.Errorf("Diff() to a nil design = %+v, %v", diff, err)
+	}
+}
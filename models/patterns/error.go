@@ -1,6 +1,11 @@
 package patterns
 
-import "github.com/layer5io/meshkit/errors"
+import (
+	"fmt"
+
+	"github.com/layer5io/meshkit/errors"
+	"github.com/meshery/schemas/models/v1beta1/component"
+)
 
 const (
 	ErrInvalidVersionCode = ""
@@ -9,3 +14,45 @@ const (
 func ErrInvalidVersion(err error) error {
 	return errors.New(ErrInvalidVersionCode, errors.Alert, []string{"invalid/incompatible semver version"}, []string{err.Error()}, []string{"version history for the content has been tampered outside meshery"}, []string{"rolllback to one of the previous version"})
 }
+
+const (
+	ErrDiffDesignsCode  = "replace_me"
+	ErrMergeDesignsCode = "replace_me"
+)
+
+func ErrDiffDesigns(err error) error {
+	return errors.New(ErrDiffDesignsCode, errors.Alert, []string{"failed to compare the designs"}, []string{err.Error()}, []string{"a component or relationship of the design cannot be encoded as JSON"}, []string{"make sure the configuration of the components is valid JSON"})
+}
+
+func ErrMergeDesigns(err error) error {
+	return errors.New(ErrMergeDesignsCode, errors.Alert, []string{"failed to merge the designs"}, []string{err.Error()}, []string{"a component or relationship of the design cannot be encoded as JSON", "the merged design does not match the design schema"}, []string{"make sure the configuration of the components is valid JSON"})
+}
+
+const (
+	ErrComponentNotRegisteredCode        = "replace_me"
+	ErrInvalidComponentConfigurationCode = "replace_me"
+)
+
+func ErrComponentNotRegistered(comp *component.ComponentDefinition) *errors.Error {
+	return errors.New(ErrComponentNotRegisteredCode, errors.Alert, []string{fmt.Sprintf("component %s (%s) is not registered", comp.DisplayName, comp.Component.Kind)}, []string{fmt.Sprintf("no component %s of apiVersion %s is registered in model %s", comp.Component.Kind, comp.Component.Version, comp.Model.Name)}, []string{"the model of the component has not been registered", "the component has been removed from its model"}, []string{"register the model of the component", "replace the component by a registered one"})
+}
+
+func ErrInvalidComponentConfiguration(comp *component.ComponentDefinition, violations []Violation) *errors.Error {
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", v.InstancePath, v.Message))
+	}
+	return errors.New(ErrInvalidComponentConfigurationCode, errors.Alert, []string{fmt.Sprintf("configuration of component %s (%s) is invalid", comp.DisplayName, comp.Component.Kind)}, messages, []string{"the configuration does not match the schema of the component"}, []string{"fix the values at the reported paths of the configuration"})
+}
+
+const ErrVersionHistoryCode = "replace_me"
+
+func ErrVersionHistory(err error) error {
+	return errors.New(ErrVersionHistoryCode, errors.Alert, []string{"invalid version history"}, []string{err.Error()}, []string{"version history of the design has been modified outside meshery"}, []string{"remove the version history from the preferences of the design"})
+}
+
+const ErrInvalidParametersCode = "replace_me"
+
+func ErrInvalidParameters(problems []string) error {
+	return errors.New(ErrInvalidParametersCode, errors.Alert, []string{"invalid design parameters"}, problems, []string{"parameters are declared incorrectly", "configurations reference undeclared parameters", "required parameters are not supplied", "supplied values do not match the types of the parameters"}, []string{"declare every referenced parameter with a valid name and type", "supply the required parameters through the values or the profile"})
+}
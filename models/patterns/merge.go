@@ -0,0 +1,374 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+// MergeConflict is a value changed differently in both designs, located by a JSON pointer within the component, relationship or design.
+type MergeConflict struct {
+	// Entity is "component", "relationship" or "design".
+	Entity string `json:"entity"`
+	// ID is the id of the component or relationship in the merged design, or the one it was removed with.
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	// Path is empty if the entity has been removed in one design and modified in the other.
+	Path   string      `json:"path"`
+	Base   interface{} `json:"base"`
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+type MergeResult struct {
+	Design    *pattern.PatternFile `json:"design"`
+	Conflicts []MergeConflict      `json:"conflicts"`
+}
+
+func (r *MergeResult) HasConflicts() bool {
+	return len(r.Conflicts) > 0
+}
+
+/*
+Merge merges the changes made to base in ours and in theirs.
+Components and relationships are matched as in Diff and merged field by field, a value changed in only one of the designs takes that change.
+Values changed differently in both designs are reported as conflicts and resolved in favour of ours,
+as are components and relationships removed in one design and modified in the other.
+*/
+func Merge(base, ours, theirs *pattern.PatternFile) (*MergeResult, error) {
+	docs := [3]map[string]interface{}{}
+	for i, design := range []*pattern.PatternFile{base, ours, theirs} {
+		v, err := toJSONValue(design)
+		if err != nil {
+			return nil, ErrMergeDesigns(err)
+		}
+		docs[i], _ = v.(map[string]interface{})
+		if docs[i] == nil {
+			docs[i] = map[string]interface{}{}
+		}
+	}
+	result := &MergeResult{Conflicts: []MergeConflict{}}
+
+	entities := func(side int, key string) []map[string]interface{} {
+		items, _ := docs[side][key].([]interface{})
+		list := make([]map[string]interface{}, 0, len(items))
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				list = append(list, m)
+			}
+		}
+		return list
+	}
+
+	components := [3][]map[string]interface{}{entities(0, "components"), entities(1, "components"), entities(2, "components")}
+	componentTriples := matchThree(components, idKey, func(c map[string]interface{}) string {
+		kind := lookupString(c, "component", "kind")
+		name := lookupString(c, "displayName")
+		if kind == "" && name == "" {
+			return ""
+		}
+		return kind + "/" + name
+	})
+	// ids maps the ids of the components in base and theirs to their ids in the merged design.
+	ids := map[string]string{}
+	mergedComponents := []interface{}{}
+	for _, t := range componentTriples {
+		merged, id := result.mergeEntity("component", components, t)
+		for side, i := range t {
+			if i >= 0 && side != 1 {
+				if from := lookupString(components[side][i], "id"); from != "" && id != "" {
+					ids[from] = id
+				}
+			}
+		}
+		if merged != nil {
+			mergedComponents = append(mergedComponents, merged)
+		}
+	}
+
+	relationships := [3][]map[string]interface{}{entities(0, "relationships"), entities(1, "relationships"), entities(2, "relationships")}
+	for side := 0; side < 3; side += 2 {
+		for _, rel := range relationships[side] {
+			remapSelectorIDs(rel["selectors"], ids)
+		}
+	}
+	relationshipTriples := matchThree(relationships, idKey, func(r map[string]interface{}) string {
+		return fmt.Sprintf("%s/%s/%s/%s", lookupString(r, "kind"), lookupString(r, "type"), lookupString(r, "subType"), strings.Join(selectedIDs(r["selectors"]), ","))
+	})
+	mergedRelationships := []interface{}{}
+	for _, t := range relationshipTriples {
+		if merged, _ := result.mergeEntity("relationship", relationships, t); merged != nil {
+			mergedRelationships = append(mergedRelationships, merged)
+		}
+	}
+
+	for i := range docs {
+		delete(docs[i], "components")
+		delete(docs[i], "relationships")
+	}
+	mergedDoc, _ := result.mergeValues("design", "", "", "", docs[0], docs[1], docs[2]).(map[string]interface{})
+	if mergedDoc == nil {
+		mergedDoc = map[string]interface{}{}
+	}
+	mergedDoc["components"] = mergedComponents
+	mergedDoc["relationships"] = mergedRelationships
+
+	data, err := json.Marshal(mergedDoc)
+	if err != nil {
+		return nil, ErrMergeDesigns(err)
+	}
+	result.Design = &pattern.PatternFile{}
+	if err := json.Unmarshal(data, result.Design); err != nil {
+		return nil, ErrMergeDesigns(err)
+	}
+	return result, nil
+}
+
+// absentValue marks a value missing from one of the merged documents.
+type absentValue struct{}
+
+var absent = absentValue{}
+
+func presentOrNil(v interface{}) interface{} {
+	if v == absent {
+		return nil
+	}
+	return v
+}
+
+// triple holds the indexes of an entity in base, ours and theirs, -1 if it is missing from the design.
+type triple [3]int
+
+/*
+matchThree matches the entities of base with the ones of ours and of theirs, then the entities added in ours with the ones added in theirs.
+The triples are ordered as the entities in ours, followed by the entities missing from ours, ordered as in theirs.
+*/
+func matchThree(entities [3][]map[string]interface{}, keys ...func(map[string]interface{}) string) []triple {
+	matchSides := func(a, b []map[string]interface{}) matches {
+		fns := make([]func(bool, int) string, 0, len(keys))
+		for _, key := range keys {
+			key := key
+			fns = append(fns, func(old bool, i int) string {
+				if old {
+					return key(a[i])
+				}
+				return key(b[i])
+			})
+		}
+		return match(len(a), len(b), fns...)
+	}
+
+	withOurs := matchSides(entities[0], entities[1])
+	withTheirs := matchSides(entities[0], entities[2])
+	triples := make([]triple, len(entities[0]))
+	for i := range triples {
+		triples[i] = triple{i, -1, -1}
+	}
+	for _, p := range withOurs.pairs {
+		triples[p.old][1] = p.new
+	}
+	for _, p := range withTheirs.pairs {
+		triples[p.old][2] = p.new
+	}
+
+	subset := func(list []map[string]interface{}, indexes []int) []map[string]interface{} {
+		s := make([]map[string]interface{}, 0, len(indexes))
+		for _, i := range indexes {
+			s = append(s, list[i])
+		}
+		return s
+	}
+	added := matchSides(subset(entities[1], withOurs.added), subset(entities[2], withTheirs.added))
+	for _, p := range added.pairs {
+		triples = append(triples, triple{-1, withOurs.added[p.old], withTheirs.added[p.new]})
+	}
+	for _, i := range added.removed {
+		triples = append(triples, triple{-1, withOurs.added[i], -1})
+	}
+	for _, i := range added.added {
+		triples = append(triples, triple{-1, -1, withTheirs.added[i]})
+	}
+
+	sort.SliceStable(triples, func(i, j int) bool {
+		a, b := triples[i], triples[j]
+		if (a[1] >= 0) != (b[1] >= 0) {
+			return a[1] >= 0
+		}
+		if a[1] >= 0 {
+			return a[1] < b[1]
+		}
+		return a[2] < b[2]
+	})
+	return triples
+}
+
+func idKey(entity map[string]interface{}) string {
+	id := lookupString(entity, "id")
+	if id == "00000000-0000-0000-0000-000000000000" {
+		return ""
+	}
+	return id
+}
+
+/*
+mergeEntity merges the versions of the entity, it returns nil if the entity is removed from the merged design.
+The merged entity keeps its id in ours, or in theirs if it is missing from ours.
+*/
+func (r *MergeResult) mergeEntity(entity string, entities [3][]map[string]interface{}, t triple) (map[string]interface{}, string) {
+	values := [3]interface{}{absent, absent, absent}
+	id, name := "", ""
+	for _, side := range []int{0, 2, 1} {
+		if t[side] < 0 {
+			continue
+		}
+		e := map[string]interface{}{}
+		for k, v := range entities[side][t[side]] {
+			e[k] = v
+		}
+		if s := lookupString(e, "id"); s != "" {
+			id = s
+		}
+		if s := lookupString(e, "displayName"); s != "" {
+			name = s
+		}
+		delete(e, "id")
+		values[side] = e
+	}
+
+	merged, ok := r.mergeValues(entity, id, name, "", values[0], values[1], values[2]).(map[string]interface{})
+	if !ok {
+		return nil, id
+	}
+	if id != "" {
+		merged["id"] = id
+	}
+	return merged, id
+}
+
+/*
+mergeValues merges the value at path: objects are merged key by key, arrays of the same length element by element.
+Values changed differently in ours and theirs are reported as conflicts and resolved in favour of ours.
+*/
+func (r *MergeResult) mergeValues(entity, id, name, path string, base, ours, theirs interface{}) interface{} {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours
+	case reflect.DeepEqual(base, ours):
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return ours
+	}
+
+	oursMap, oursIsMap := ours.(map[string]interface{})
+	theirsMap, theirsIsMap := theirs.(map[string]interface{})
+	baseMap, baseIsMap := base.(map[string]interface{})
+	if oursIsMap && theirsIsMap && (baseIsMap || base == absent) {
+		keys := map[string]bool{}
+		for _, m := range []map[string]interface{}{baseMap, oursMap, theirsMap} {
+			for k := range m {
+				keys[k] = true
+			}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		merged := map[string]interface{}{}
+		for _, k := range sorted {
+			v := r.mergeValues(entity, id, name, pointer(path, k), valueOf(baseMap, k), valueOf(oursMap, k), valueOf(theirsMap, k))
+			if v != absent {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	oursList, oursIsList := ours.([]interface{})
+	theirsList, theirsIsList := theirs.([]interface{})
+	baseList, baseIsList := base.([]interface{})
+	if oursIsList && theirsIsList && baseIsList && len(oursList) == len(baseList) && len(theirsList) == len(baseList) {
+		merged := make([]interface{}, len(baseList))
+		for i := range baseList {
+			merged[i] = r.mergeValues(entity, id, name, pointer(path, fmt.Sprint(i)), baseList[i], oursList[i], theirsList[i])
+		}
+		return merged
+	}
+
+	r.Conflicts = append(r.Conflicts, MergeConflict{
+		Entity: entity,
+		ID:     id,
+		Name:   name,
+		Path:   path,
+		Base:   presentOrNil(base),
+		Ours:   presentOrNil(ours),
+		Theirs: presentOrNil(theirs),
+	})
+	return ours
+}
+
+func valueOf(m map[string]interface{}, key string) interface{} {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return absent
+}
+
+func lookupString(m map[string]interface{}, path ...string) string {
+	var v interface{} = m
+	for _, key := range path {
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = next[key]
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// selectorItems calls fn for every from and to item of the allow and deny selectors.
+func selectorItems(selectors interface{}, fn func(direction string, item map[string]interface{})) {
+	sets, _ := selectors.([]interface{})
+	for _, set := range sets {
+		s, _ := set.(map[string]interface{})
+		for _, selector := range []string{"allow", "deny"} {
+			sel, _ := s[selector].(map[string]interface{})
+			for _, direction := range []string{"from", "to"} {
+				items, _ := sel[direction].([]interface{})
+				for _, item := range items {
+					if m, ok := item.(map[string]interface{}); ok {
+						fn(selector+":"+direction, m)
+					}
+				}
+			}
+		}
+	}
+}
+
+// remapSelectorIDs replaces the component ids the selectors refer to by their ids in the merged design.
+func remapSelectorIDs(selectors interface{}, ids map[string]string) {
+	selectorItems(selectors, func(_ string, item map[string]interface{}) {
+		if id, ok := item["id"].(string); ok {
+			if mapped, ok := ids[id]; ok {
+				item["id"] = mapped
+			}
+		}
+	})
+}
+
+func selectedIDs(selectors interface{}) []string {
+	selected := []string{}
+	selectorItems(selectors, func(direction string, item map[string]interface{}) {
+		if id, ok := item["id"].(string); ok {
+			selected = append(selected, direction+":"+id)
+		}
+	})
+	sort.Strings(selected)
+	return selected
+}
@@ -0,0 +1,140 @@
+package patterns
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+// componentSummary returns the name, id and configuration of every component of the design.
+func componentSummary(t *testing.T, design *pattern.PatternFile) map[string]string {
+	t.Helper()
+	summary := map[string]string{}
+	for _, c := range design.Components {
+		configuration, err := json.Marshal(c.Configuration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		summary[c.DisplayName] = c.Id.String() + " " + string(configuration)
+	}
+	return summary
+}
+
+func conflictPaths(conflicts []MergeConflict) []string {
+	paths := []string{}
+	for _, c := range conflicts {
+		paths = append(paths, c.Entity+" "+c.Name+" "+c.Path)
+	}
+	return paths
+}
+
+func TestMerge(t *testing.T) {
+	web := testComponentJSON(webID, "Deployment", "web", replicas1)
+	svc := testComponentJSON(svcID, "Service", "svc", "")
+	base := testDesign(t, []string{web, svc})
+	tests := []struct {
+		name           string
+		ours, theirs   *pattern.PatternFile
+		wantComponents map[string]string
+		wantConflicts  []string
+	}{
+		{
+			"changes of different values",
+			testDesign(t, []string{testComponentJSON(webID, "Deployment", "web", replicas2), svc}),
+			testDesign(t, []string{testComponentJSON(webID, "Deployment", "web", `{"spec": {"replicas": 1, "image": "nginx:2"}}`), svc}),
+			map[string]string{"web": webID + ` {"spec":{"image":"nginx:2","replicas":2}}`, "svc": svcID + " {}"},
+			[]string{},
+		},
+		{
+			"conflicting changes are resolved in favour of ours",
+			testDesign(t, []string{testComponentJSON(webID, "Deployment", "web", replicas2), svc}),
+			testDesign(t, []string{testComponentJSON(webID, "Deployment", "web", `{"spec": {"replicas": 3, "image": "nginx:1"}}`), svc}),
+			map[string]string{"web": webID + ` {"spec":{"image":"nginx:1","replicas":2}}`, "svc": svcID + " {}"},
+			[]string{"component web /configuration/spec/replicas"},
+		},
+		{
+			"recreated component matched by kind and name",
+			testDesign(t, []string{testComponentJSON(webID, "Deployment", "web", replicas2), svc}),
+			testDesign(t, []string{testComponentJSON(webNewID, "Deployment", "web", `{"spec": {"replicas": 1, "image": "nginx:2"}}`), svc}),
+			map[string]string{"web": webID + ` {"spec":{"image":"nginx:2","replicas":2}}`, "svc": svcID + " {}"},
+			[]string{},
+		},
+		{
+			"added and removed components",
+			testDesign(t, []string{web, svc, testComponentJSON(cfgID, "ConfigMap", "config", "")}),
+			testDesign(t, []string{web}),
+			map[string]string{"web": webID + ` {"spec":{"image":"nginx:1","replicas":1}}`, "config": cfgID + " {}"},
+			[]string{},
+		},
+		{
+			"removed in theirs and modified in ours",
+			testDesign(t, []string{web, testComponentJSON(svcID, "Service", "svc", `{"spec": {"type": "NodePort"}}`)}),
+			testDesign(t, []string{web}),
+			map[string]string{"web": webID + ` {"spec":{"image":"nginx:1","replicas":1}}`, "svc": svcID + ` {"spec":{"type":"NodePort"}}`},
+			[]string{"component svc "},
+		},
+		{
+			"null entries",
+			testDesign(t, []string{web, "null", svc}),
+			testDesign(t, []string{"null", web, svc}),
+			map[string]string{"web": webID + ` {"spec":{"image":"nginx:1","replicas":1}}`, "svc": svcID + " {}"},
+			[]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Merge(base, tt.ours, tt.theirs)
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			if got := componentSummary(t, result.Design); !reflect.DeepEqual(got, tt.wantComponents) {
+				t.Errorf("Merge() components = %v, want %v", got, tt.wantComponents)
+			}
+			if got := conflictPaths(result.Conflicts); !reflect.DeepEqual(got, tt.wantConflicts) {
+				t.Errorf("Merge() conflicts = %q, want %q", got, tt.wantConflicts)
+			}
+			if result.HasConflicts() != (len(tt.wantConflicts) > 0) {
+				t.Errorf("HasConflicts() = %v", result.HasConflicts())
+			}
+		})
+	}
+}
+
+func TestMergeRelationshipRemapping(t *testing.T) {
+	web := testComponentJSON(webID, "Deployment", "web", replicas1)
+	svc := testComponentJSON(svcID, "Service", "svc", "")
+	base := testDesign(t, []string{web, svc})
+	ours := testDesign(t, []string{web, svc})
+	// theirs recreated both components and related them, the relationship must select the components of ours
+	theirs := testDesign(t,
+		[]string{testComponentJSON(webNewID, "Deployment", "web", replicas1), testComponentJSON(svcNewID, "Service", "svc", "")},
+		testRelationshipJSON(edgeID, "network", webNewID, svcNewID))
+
+	result, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if result.HasConflicts() {
+		t.Fatalf("Merge() conflicts = %q", conflictPaths(result.Conflicts))
+	}
+	if len(result.Design.Components) != 2 || len(result.Design.Relationships) != 1 {
+		t.Fatalf("Merge() = %d components and %d relationships, want 2 and 1", len(result.Design.Components), len(result.Design.Relationships))
+	}
+	selectors := *result.Design.Relationships[0].Selectors
+	from, to := selectors[0].Allow.From[0].Id.String(), selectors[0].Allow.To[0].Id.String()
+	if from != webID || to != svcID {
+		t.Errorf("merged relationship selects %s and %s, want %s and %s", from, to, webID, svcID)
+	}
+
+	// the same relationship added to both designs is merged
+	ours = testDesign(t, []string{web, svc}, testRelationshipJSON(edgeNewID, "network", webID, svcID))
+	result, err = Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if len(result.Design.Relationships) != 1 || result.Design.Relationships[0].Id.String() != edgeNewID {
+		t.Errorf("Merge() relationships = %+v, want the relationship of ours only", result.Design.Relationships)
+	}
+}
@@ -0,0 +1,79 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+/*
+designMetadataKey is the key, within the layers of the preferences of a design, of the metadata meshkit keeps for the design:
+the design schema has no metadata of its own, and the key is namespaced so that it does not collide with the layers of the design.
+The value is an object with the fields:
+
+	versionHistory  the VersionHistory of the design, see GetVersionHistory
+	parameters      the DesignParameters of the design, see GetParameters
+*/
+const designMetadataKey = "meshkit.meshery.io/metadata"
+
+// Fields of the design metadata.
+const (
+	versionHistoryField = "versionHistory"
+	parametersField     = "parameters"
+)
+
+// getDesignMetadata decodes the field of the metadata of the design into v, it returns false if the field is not set.
+func getDesignMetadata(p *pattern.PatternFile, field string, v interface{}) (bool, error) {
+	if p.Preferences == nil || p.Preferences.Layers == nil || p.Preferences.Layers[designMetadataKey] == nil {
+		return false, nil
+	}
+	metadata, ok := p.Preferences.Layers[designMetadataKey].(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("the value of %s in the layers of the design is not an object", designMetadataKey)
+	}
+	if metadata[field] == nil {
+		return false, nil
+	}
+	data, err := json.Marshal(metadata[field])
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// setDesignMetadata sets the field of the metadata of the design to the generic JSON form of v, a nil v removes the field.
+func setDesignMetadata(p *pattern.PatternFile, field string, v interface{}) error {
+	var metadata map[string]interface{}
+	if p.Preferences != nil && p.Preferences.Layers != nil {
+		metadata, _ = p.Preferences.Layers[designMetadataKey].(map[string]interface{})
+	}
+	if v == nil {
+		if metadata != nil {
+			delete(metadata, field)
+			if len(metadata) == 0 {
+				delete(p.Preferences.Layers, designMetadataKey)
+			}
+		}
+		return nil
+	}
+	// the metadata is stored in its generic JSON form, as it is after decoding the design
+	value, err := toJSONValue(v)
+	if err != nil {
+		return err
+	}
+	if p.Preferences == nil {
+		p.Preferences = &struct {
+			Layers map[string]interface{} `json:"layers" yaml:"layers"`
+		}{}
+	}
+	if p.Preferences.Layers == nil {
+		p.Preferences.Layers = map[string]interface{}{}
+	}
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		p.Preferences.Layers[designMetadataKey] = metadata
+	}
+	metadata[field] = value
+	return nil
+}
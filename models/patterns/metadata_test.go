@@ -0,0 +1,62 @@
+package patterns
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+func TestDesignMetadata(t *testing.T) {
+	design := testDesign(t, []string{testComponentJSON(webID, "Deployment", "web", `{"spec": {"replicas": "{{.replicas}}"}}`)})
+	design.Preferences = &struct {
+		Layers map[string]interface{} `json:"layers" yaml:"layers"`
+	}{Layers: map[string]interface{}{"parameters": "a layer of the design"}}
+
+	params := &DesignParameters{Inputs: []Parameter{{Name: "replicas", Type: IntegerParameter, Default: 1}}}
+	if err := SetParameters(design, params); err != nil {
+		t.Fatal(err)
+	}
+	history := VersionHistory{{Version: "0.0.2", PreviousVersion: "0.0.1", Changelog: []ChangelogEntry{}}}
+	if err := SetVersionHistory(design, history); err != nil {
+		t.Fatal(err)
+	}
+	if len(design.Preferences.Layers) != 2 || design.Preferences.Layers["parameters"] != "a layer of the design" {
+		t.Errorf("layers = %v, want the layer of the design and the metadata only", design.Preferences.Layers)
+	}
+
+	// the metadata survives the round trip through the JSON of the design
+	data, err := json.Marshal(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &pattern.PatternFile{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := GetParameters(decoded); err != nil || len(got.Inputs) != 1 || got.Inputs[0].Name != "replicas" {
+		t.Errorf("GetParameters() = %+v, %v", got, err)
+	}
+	if got, err := GetVersionHistory(decoded); err != nil || !reflect.DeepEqual(got, history) {
+		t.Errorf("GetVersionHistory() = %+v, %v, want %+v", got, err, history)
+	}
+
+	if err := SetParameters(decoded, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := GetParameters(decoded); err != nil || got != nil {
+		t.Errorf("GetParameters() after removal = %+v, %v", got, err)
+	}
+	if err := SetVersionHistory(decoded, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"parameters": "a layer of the design"}; !reflect.DeepEqual(decoded.Preferences.Layers, want) {
+		t.Errorf("layers after removing the metadata = %v, want %v", decoded.Preferences.Layers, want)
+	}
+
+	decoded.Preferences.Layers[designMetadataKey] = "not an object"
+	if _, err := GetVersionHistory(decoded); err == nil {
+		t.Error("GetVersionHistory() of invalid metadata succeeded")
+	}
+}
@@ -0,0 +1,435 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/layer5io/meshkit/utils"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+type ParameterType string
+
+const (
+	StringParameter  ParameterType = "string"
+	IntegerParameter ParameterType = "integer"
+	NumberParameter  ParameterType = "number"
+	BooleanParameter ParameterType = "boolean"
+	ObjectParameter  ParameterType = "object"
+	ArrayParameter   ParameterType = "array"
+)
+
+// Parameter is an input of a design, referenced in the configuration of its components as {{.name}}.
+type Parameter struct {
+	Name        string        `json:"name" yaml:"name"`
+	Type        ParameterType `json:"type" yaml:"type"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty" yaml:"default,omitempty"`
+	// Required parameters must be supplied by the values or the profile, their default is not used.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+/*
+DesignParameters are the inputs of a design and the values of the inputs in the environments it is deployed to.
+
+A configuration value which is a reference only, e.g. "{{.replicas}}", is replaced by the value of the parameter, keeping its type.
+Other values holding references, e.g. "nginx:{{.tag}}", are rendered as templates. Optional parameters without a value
+remove the configuration values referencing them only, and render as empty strings, objects or arrays in templates.
+
+Only strings whose references are all declared parameters are rendered, others are kept as they are, e.g. the
+{{ $labels.instance }} of a Prometheus rule or the {{ .Values.tag }} of a Helm chart. To keep a literal "{{" in a string,
+write it as {{"{{"}}, e.g. "{{"{{"}}.tag}}" resolves to "{{.tag}}" even when tag is a parameter.
+*/
+type DesignParameters struct {
+	Inputs []Parameter `json:"inputs" yaml:"inputs"`
+	// Profiles are the values of the inputs by environment, e.g. dev, stage or prod.
+	Profiles map[string]map[string]interface{} `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// GetParameters returns the parameters of the design, nil if it has none.
+func GetParameters(p *pattern.PatternFile) (*DesignParameters, error) {
+	params := &DesignParameters{}
+	found, err := getDesignMetadata(p, parametersField, params)
+	if err != nil {
+		return nil, ErrInvalidParameters([]string{err.Error()})
+	}
+	if !found {
+		return nil, nil
+	}
+	return params, nil
+}
+
+// SetParameters declares the parameters of the design, nil removes them.
+func SetParameters(p *pattern.PatternFile, params *DesignParameters) error {
+	if params == nil {
+		return setDesignMetadata(p, parametersField, nil)
+	}
+	if problems := params.validateDeclarations(); len(problems) > 0 {
+		return ErrInvalidParameters(problems)
+	}
+	if err := setDesignMetadata(p, parametersField, params); err != nil {
+		return ErrInvalidParameters([]string{err.Error()})
+	}
+	return nil
+}
+
+var parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (dp *DesignParameters) validateDeclarations() []string {
+	problems := []string{}
+	declared := map[string]bool{}
+	for _, param := range dp.Inputs {
+		if !parameterName.MatchString(param.Name) {
+			problems = append(problems, fmt.Sprintf("parameter name %q is not an identifier", param.Name))
+		}
+		if declared[param.Name] {
+			problems = append(problems, fmt.Sprintf("parameter %s is declared more than once", param.Name))
+		}
+		declared[param.Name] = true
+		switch param.Type {
+		case StringParameter, IntegerParameter, NumberParameter, BooleanParameter, ObjectParameter, ArrayParameter:
+		default:
+			problems = append(problems, fmt.Sprintf("parameter %s has unknown type %q", param.Name, param.Type))
+			continue
+		}
+		if param.Default != nil {
+			if _, err := coerceParameter(param, param.Default); err != nil {
+				problems = append(problems, fmt.Sprintf("default of parameter %s: %s", param.Name, err))
+			}
+		}
+	}
+	for profile, values := range dp.Profiles {
+		for name := range values {
+			if !declared[name] {
+				problems = append(problems, fmt.Sprintf("profile %s sets undeclared parameter %s", profile, name))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+/*
+values returns the values of the parameters: values take precedence over the profile, the profile over the defaults.
+Parameters without a value are missing from the result.
+*/
+func (dp *DesignParameters) values(profile string, values map[string]interface{}) (map[string]interface{}, []string) {
+	problems := dp.validateDeclarations()
+	profileValues, ok := dp.Profiles[profile]
+	if profile != "" && !ok {
+		problems = append(problems, fmt.Sprintf("profile %s is not defined", profile))
+	}
+
+	resolved := map[string]interface{}{}
+	for _, param := range dp.Inputs {
+		value, supplied := values[param.Name]
+		if !supplied {
+			value, supplied = profileValues[param.Name]
+		}
+		if !supplied && param.Required {
+			problems = append(problems, fmt.Sprintf("required parameter %s is not supplied", param.Name))
+			continue
+		}
+		if !supplied {
+			value = param.Default
+		}
+		if value == nil {
+			continue
+		}
+		coerced, err := coerceParameter(param, value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("parameter %s: %s", param.Name, err))
+			continue
+		}
+		resolved[param.Name] = coerced
+	}
+	return resolved, problems
+}
+
+/*
+coerceParameter converts the value to the type of the parameter. Strings, e.g. from environment variables or the command line,
+are parsed as the type of the parameter, objects and arrays as JSON.
+*/
+func coerceParameter(param Parameter, value interface{}) (interface{}, error) {
+	generic, err := toJSONValue(value)
+	if err != nil {
+		return nil, err
+	}
+	s, isString := generic.(string)
+	mismatch := fmt.Errorf("%v is not of type %s", value, param.Type)
+	switch param.Type {
+	case StringParameter:
+		if isString {
+			return s, nil
+		}
+		return nil, mismatch
+	case IntegerParameter:
+		if isString {
+			i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, mismatch
+			}
+			return i, nil
+		}
+		if f, ok := generic.(float64); ok && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+		return nil, mismatch
+	case NumberParameter:
+		if isString {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, mismatch
+			}
+			return f, nil
+		}
+		if f, ok := generic.(float64); ok {
+			return f, nil
+		}
+		return nil, mismatch
+	case BooleanParameter:
+		if isString {
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, mismatch
+			}
+			return b, nil
+		}
+		if b, ok := generic.(bool); ok {
+			return b, nil
+		}
+		return nil, mismatch
+	case ObjectParameter, ArrayParameter:
+		if isString {
+			if err := json.Unmarshal([]byte(s), &generic); err != nil {
+				return nil, mismatch
+			}
+		}
+		if _, ok := generic.(map[string]interface{}); ok && param.Type == ObjectParameter {
+			return generic, nil
+		}
+		if _, ok := generic.([]interface{}); ok && param.Type == ArrayParameter {
+			return generic, nil
+		}
+		return nil, mismatch
+	}
+	return nil, mismatch
+}
+
+// ValidateParameters checks that the parameters of the design are declared correctly, that the configurations referencing
+// them render and that all required parameters are supplied by the values or the profile.
+func ValidateParameters(p *pattern.PatternFile, profile string, values map[string]interface{}) error {
+	_, err := ResolveParameters(p, profile, values)
+	return err
+}
+
+/*
+ResolveParameters returns a copy of the design with the references to the parameters in the configurations of its components
+replaced by their values, taken from values, the profile, or the defaults of the parameters, in that order.
+The design is never modified, a copy is returned even when it has no parameters.
+*/
+func ResolveParameters(p *pattern.PatternFile, profile string, values map[string]interface{}) (*pattern.PatternFile, error) {
+	params, err := GetParameters(p)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, ErrInvalidParameters([]string{err.Error()})
+	}
+	resolved := &pattern.PatternFile{}
+	if err := json.Unmarshal(data, resolved); err != nil {
+		return nil, ErrInvalidParameters([]string{err.Error()})
+	}
+	if params == nil {
+		if profile != "" {
+			return nil, ErrInvalidParameters([]string{fmt.Sprintf("profile %s is not defined", profile)})
+		}
+		return resolved, nil
+	}
+	resolvedValues, problems := params.values(profile, values)
+
+	declared := map[string]ParameterType{}
+	for _, param := range params.Inputs {
+		declared[param.Name] = param.Type
+	}
+	r := &parameterResolver{declared: declared, values: resolvedValues}
+	for _, comp := range resolved.Components {
+		if comp == nil || comp.Configuration == nil {
+			continue
+		}
+		r.component = comp.DisplayName
+		configuration, _ := r.resolve("", comp.Configuration).(map[string]interface{})
+		comp.Configuration = configuration
+	}
+	problems = append(problems, r.problems...)
+	if len(problems) > 0 {
+		return nil, ErrInvalidParameters(problems)
+	}
+	return resolved, nil
+}
+
+var exactReference = regexp.MustCompile(`^\{\{-?\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*-?\}\}$`)
+
+type parameterResolver struct {
+	declared  map[string]ParameterType
+	values    map[string]interface{}
+	component string
+	problems  []string
+}
+
+// unset marks configuration values referencing optional parameters without a value, they are removed from the configuration.
+type unsetValue struct{}
+
+var unset = unsetValue{}
+
+func (r *parameterResolver) resolve(path string, v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		resolved := map[string]interface{}{}
+		for k, item := range value {
+			if item := r.resolve(pointer(path, k), item); item != unset {
+				resolved[k] = item
+			}
+		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(value))
+		for i, item := range value {
+			resolved[i] = r.resolve(pointer(path, strconv.Itoa(i)), item)
+			if resolved[i] == unset {
+				resolved[i] = nil
+			}
+		}
+		return resolved
+	case string:
+		if !strings.Contains(value, "{{") {
+			return value
+		}
+		return r.resolveString(path, value)
+	}
+	return v
+}
+
+func (r *parameterResolver) resolveString(path, s string) interface{} {
+	problem := func(format string, args ...interface{}) interface{} {
+		r.problems = append(r.problems, fmt.Sprintf("component %s, %s: ", r.component, path)+fmt.Sprintf(format, args...))
+		return s
+	}
+
+	if m := exactReference.FindStringSubmatch(s); m != nil {
+		if _, ok := r.declared[m[1]]; !ok {
+			return s
+		}
+		if value, ok := r.values[m[1]]; ok {
+			return value
+		}
+		return unset
+	}
+
+	// strings which are not templates of the parameters, e.g. alerting rules, are kept as they are
+	t, err := template.New("").Parse(s)
+	if err != nil {
+		return s
+	}
+	names, ok := referencedFields(t.Tree.Root)
+	if !ok {
+		return s
+	}
+	data := map[string]interface{}{}
+	for _, name := range names {
+		paramType, ok := r.declared[name]
+		if !ok {
+			return s
+		}
+		if value, ok := r.values[name]; ok {
+			data[name] = value
+			continue
+		}
+		switch paramType {
+		case ObjectParameter:
+			data[name] = map[string]interface{}{}
+		case ArrayParameter:
+			data[name] = []interface{}{}
+		default:
+			data[name] = ""
+		}
+	}
+	rendered, err := utils.MergeToTemplate([]byte(s), data)
+	if err != nil {
+		return problem("%s", err)
+	}
+	return string(rendered)
+}
+
+/*
+referencedFields returns the names of the fields of the data referenced by the template, e.g. tag for {{.tag}} or {{$.tag}}.
+Fields within range and with blocks refer to the element the block iterates over or selects, they are not parameters.
+It returns false when the template references the data otherwise, e.g. {{.}}, {{$}} or {{template "a" .}},
+as such templates are not written against the parameters.
+*/
+func referencedFields(node parse.Node) ([]string, bool) {
+	fields := []string{}
+	ok := true
+	var walk func(node parse.Node, scoped bool)
+	walk = func(node parse.Node, scoped bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, scoped)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, scoped)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, scoped)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, scoped)
+			}
+		case *parse.FieldNode:
+			if !scoped {
+				fields = append(fields, n.Ident[0])
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" {
+				if len(n.Ident) > 1 {
+					fields = append(fields, n.Ident[1])
+				}
+				ok = ok && len(n.Ident) > 1
+			}
+		case *parse.DotNode:
+			ok = ok && scoped
+		case *parse.ChainNode, *parse.TemplateNode:
+			ok = false
+		case *parse.IfNode:
+			walk(n.Pipe, scoped)
+			walk(n.List, scoped)
+			walk(n.ElseList, scoped)
+		case *parse.RangeNode:
+			walk(n.Pipe, scoped)
+			walk(n.List, true)
+			walk(n.ElseList, scoped)
+		case *parse.WithNode:
+			walk(n.Pipe, scoped)
+			walk(n.List, true)
+			walk(n.ElseList, scoped)
+		}
+	}
+	walk(node, false)
+	return fields, ok
+}
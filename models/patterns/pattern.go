@@ -8,6 +8,7 @@ import (
 	"github.com/meshery/schemas/models/v1beta1/pattern"
 )
 
+// GetNextVersion increments the patch level of the version, VersioningPolicy.BumpVersion bumps the version according to the changes of the design.
 func GetNextVersion(p *pattern.PatternFile) (string, error) {
 	// Existing patterns do not have version hence when trying to assign next version for such patterns, it will fail the validation.
 	// Hence, if version is not present, start versioning for those afresh.
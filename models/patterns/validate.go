@@ -0,0 +1,185 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshkit/errors"
+	"github.com/layer5io/meshkit/models/meshmodel/registry"
+	regv1beta1 "github.com/layer5io/meshkit/models/meshmodel/registry/v1beta1"
+	"github.com/layer5io/meshkit/utils"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+// Violation is a value of the configuration of a component which does not match the schema of the component.
+type Violation struct {
+	// InstancePath is the JSON pointer of the value within the configuration, e.g. /spec/replicas
+	InstancePath string `json:"instancePath"`
+	Message      string `json:"message"`
+}
+
+// ComponentValidationInfo is the AdditionalInfo of the errors returned by DesignValidator.
+type ComponentValidationInfo struct {
+	ComponentID   uuid.UUID   `json:"componentId"`
+	ComponentName string      `json:"componentName"`
+	Kind          string      `json:"kind"`
+	APIVersion    string      `json:"apiVersion"`
+	Model         string      `json:"model"`
+	Violations    []Violation `json:"violations"`
+}
+
+/*
+DesignValidator validates the configuration of the components of designs against the schemas of the registered component definitions.
+Components are resolved by kind, apiVersion, model name and model version, any registered version of the model is used
+if the version of the component's model is not registered. A DesignValidator can be used by several goroutines.
+*/
+type DesignValidator struct {
+	regManager *registry.RegistryManager
+	mx         sync.RWMutex
+	schemas    map[string]*cue.Value
+}
+
+func NewDesignValidator(regManager *registry.RegistryManager) *DesignValidator {
+	return &DesignValidator{regManager: regManager, schemas: map[string]*cue.Value{}}
+}
+
+/*
+Validate returns an error for every component of the design whose configuration does not match its schema,
+or which is not registered. The AdditionalInfo of the errors is a ComponentValidationInfo.
+Components whose definition does not have a schema are not validated, annotation components are skipped.
+*/
+func (dv *DesignValidator) Validate(design *pattern.PatternFile) ([]errors.ErrorV2, error) {
+	result := []errors.ErrorV2{}
+	for _, comp := range design.Components {
+		if comp == nil || comp.Metadata.IsAnnotation {
+			continue
+		}
+		info := ComponentValidationInfo{
+			ComponentID:   comp.Id,
+			ComponentName: comp.DisplayName,
+			Kind:          comp.Component.Kind,
+			APIVersion:    comp.Component.Version,
+			Model:         comp.Model.Name,
+			Violations:    []Violation{},
+		}
+
+		schema, registered, err := dv.schema(comp)
+		if err != nil {
+			return nil, err
+		}
+		if !registered {
+			result = append(result, ErrComponentNotRegistered(comp).ErrorV2(info))
+			continue
+		}
+		if schema == nil {
+			continue
+		}
+
+		violations, err := validateConfiguration(*schema, comp)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			info.Violations = violations
+			result = append(result, ErrInvalidComponentConfiguration(comp, violations).ErrorV2(info))
+		}
+	}
+	return result, nil
+}
+
+// schema returns the schema of the registered definition of the component, nil if the definition does not have a schema.
+func (dv *DesignValidator) schema(comp *component.ComponentDefinition) (*cue.Value, bool, error) {
+	key := fmt.Sprintf("%s@%s@%s@%s", comp.Component.Kind, comp.Component.Version, comp.Model.Name, comp.Model.Model.Version)
+	dv.mx.RLock()
+	schema, ok := dv.schemas[key]
+	dv.mx.RUnlock()
+	if ok {
+		return schema, true, nil
+	}
+
+	def, err := dv.definition(comp)
+	if err != nil || def == nil {
+		return nil, false, err
+	}
+	if def.Component.Schema != "" {
+		value, err := utils.JsonSchemaToCue(def.Component.Schema)
+		if err != nil {
+			return nil, false, err
+		}
+		schema = &value
+	}
+	dv.mx.Lock()
+	dv.schemas[key] = schema
+	dv.mx.Unlock()
+	return schema, true, nil
+}
+
+func (dv *DesignValidator) definition(comp *component.ComponentDefinition) (*component.ComponentDefinition, error) {
+	filter := &regv1beta1.ComponentFilter{
+		Name:       comp.Component.Kind,
+		APIVersion: comp.Component.Version,
+		ModelName:  comp.Model.Name,
+		Version:    comp.Model.Model.Version,
+	}
+	for {
+		entities, _, _, err := dv.regManager.GetEntities(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entities {
+			if def, ok := e.(*component.ComponentDefinition); ok {
+				return def, nil
+			}
+		}
+		if filter.Version == "" {
+			return nil, nil
+		}
+		filter.Version = ""
+	}
+}
+
+// validateConfiguration validates the configuration of the component, as the resource it describes, against the schema.
+func validateConfiguration(schema cue.Value, comp *component.ComponentDefinition) ([]Violation, error) {
+	resource := map[string]interface{}{}
+	for k, v := range comp.Configuration {
+		resource[k] = v
+	}
+	if _, ok := resource["apiVersion"]; !ok && comp.Component.Version != "" {
+		resource["apiVersion"] = comp.Component.Version
+	}
+	if _, ok := resource["kind"]; !ok && comp.Component.Kind != "" {
+		resource["kind"] = comp.Component.Kind
+	}
+
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, utils.ErrMarshal(err)
+	}
+	value, err := utils.JsonToCue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := []Violation{}
+	valid, errs := utils.Validate(schema, value)
+	if valid {
+		return violations, nil
+	}
+	for _, e := range errs {
+		instancePath := ""
+		for _, label := range e.Path() {
+			if unquoted, err := strconv.Unquote(label); err == nil {
+				label = unquoted
+			}
+			instancePath = pointer(instancePath, label)
+		}
+		format, args := e.Msg()
+		violations = append(violations, Violation{InstancePath: instancePath, Message: fmt.Sprintf(format, args...)})
+	}
+	return violations, nil
+}
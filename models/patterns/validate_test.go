@@ -0,0 +1,85 @@
+package patterns
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/registry"
+	"github.com/meshery/schemas/models/v1beta1"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/connection"
+	"github.com/meshery/schemas/models/v1beta1/model"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDesignValidator(t *testing.T) *DesignValidator {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	rm, err := registry.NewRegistryManager(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := model.ModelDefinition{
+		SchemaVersion: v1beta1.ModelSchemaVersion,
+		Name:          "kubernetes",
+		DisplayName:   "Kubernetes",
+		Status:        "enabled",
+		Category:      category.CategoryDefinition{Name: "Orchestration"},
+		Model:         model.Model{Version: "1.29.0"},
+		Registrant:    connection.Connection{Kind: "github"},
+	}
+	if _, _, err := rm.RegisterEntity(m.Registrant, &m); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{"Deployment", "Service"} {
+		c := component.ComponentDefinition{
+			SchemaVersion: v1beta1.ComponentSchemaVersion,
+			DisplayName:   kind,
+			Component:     component.Component{Kind: kind, Version: "v1", Schema: `{"type": "object", "properties": {"spec": {"type": "object", "properties": {"replicas": {"type": "integer"}}}}}`},
+			Model:         m,
+		}
+		if _, _, err := rm.RegisterEntity(m.Registrant, &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewDesignValidator(rm)
+}
+
+func TestDesignValidatorConcurrentUse(t *testing.T) {
+	dv := newTestDesignValidator(t)
+	design := testDesign(t, []string{
+		testComponentJSON(webID, "Deployment", "web", `{"spec": {"replicas": "two"}}`),
+		testComponentJSON(svcID, "Service", "svc", `{"spec": {"replicas": 1}}`),
+		testComponentJSON(cfgID, "ConfigMap", "config", ""),
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := dv.Validate(design)
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			// the replicas of web are not an integer and ConfigMap is not registered
+			if len(result) != 2 {
+				errs <- "unexpected validation errors"
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,292 @@
+package patterns
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/meshery/schemas/models/v1beta1/pattern"
+)
+
+// VersionBump is the part of the semver version of a design which is incremented for a change.
+type VersionBump string
+
+const (
+	NoBump    VersionBump = "none"
+	PatchBump VersionBump = "patch"
+	MinorBump VersionBump = "minor"
+	MajorBump VersionBump = "major"
+)
+
+func (b VersionBump) rank() int {
+	switch b {
+	case PatchBump:
+		return 1
+	case MinorBump:
+		return 2
+	case MajorBump:
+		return 3
+	}
+	return 0
+}
+
+/*
+VersioningPolicy decides how the version of a design is bumped for the changes made to it.
+Organizations store their own policy, e.g. as JSON, decoding it into DefaultVersioningPolicy() keeps the defaults of the fields which are not set.
+*/
+type VersioningPolicy struct {
+	ComponentAdded      VersionBump `json:"componentAdded" yaml:"componentAdded"`
+	ComponentRemoved    VersionBump `json:"componentRemoved" yaml:"componentRemoved"`
+	RelationshipAdded   VersionBump `json:"relationshipAdded" yaml:"relationshipAdded"`
+	RelationshipRemoved VersionBump `json:"relationshipRemoved" yaml:"relationshipRemoved"`
+	// ValueAdded, ValueChanged and ValueRemoved apply to the values of modified components and relationships.
+	ValueAdded   VersionBump `json:"valueAdded" yaml:"valueAdded"`
+	ValueChanged VersionBump `json:"valueChanged" yaml:"valueChanged"`
+	ValueRemoved VersionBump `json:"valueRemoved" yaml:"valueRemoved"`
+	// BreakingPaths are the JSON pointers, within components, of the values whose change is breaking, e.g. /component/kind.
+	// A pointer covers the values below it.
+	BreakingPaths []string `json:"breakingPaths" yaml:"breakingPaths"`
+	// BreakingChange applies to the changes of values at BreakingPaths.
+	BreakingChange VersionBump `json:"breakingChange" yaml:"breakingChange"`
+	// MaxHistory is the number of versions kept in the version history of the design, all versions are kept if it is 0.
+	MaxHistory int `json:"maxHistory" yaml:"maxHistory"`
+}
+
+/*
+DefaultVersioningPolicy bumps the major version for removed components and relationships and for changes of the identity of components,
+i.e. their kind, apiVersion, model, name, namespace or selector, the minor version for added components and relationships
+and the patch version for any other change.
+*/
+func DefaultVersioningPolicy() VersioningPolicy {
+	return VersioningPolicy{
+		ComponentAdded:      MinorBump,
+		ComponentRemoved:    MajorBump,
+		RelationshipAdded:   MinorBump,
+		RelationshipRemoved: MajorBump,
+		ValueAdded:          PatchBump,
+		ValueChanged:        PatchBump,
+		ValueRemoved:        PatchBump,
+		BreakingPaths: []string{
+			"/component/kind",
+			"/component/version",
+			"/model/name",
+			"/configuration/metadata/name",
+			"/configuration/metadata/namespace",
+			"/configuration/spec/selector",
+		},
+		BreakingChange: MajorBump,
+		MaxHistory:     50,
+	}
+}
+
+// ChangelogEntry describes a change of a design and the version bump it required.
+type ChangelogEntry struct {
+	Bump VersionBump `json:"bump" yaml:"bump"`
+	// Entity is "component" or "relationship".
+	Entity string     `json:"entity" yaml:"entity"`
+	ID     string     `json:"id" yaml:"id"`
+	Type   ChangeType `json:"type" yaml:"type"`
+	// Path is the JSON pointer of the changed value within the entity, empty if the entity has been added or removed.
+	Path        string `json:"path,omitempty" yaml:"path,omitempty"`
+	Description string `json:"description" yaml:"description"`
+}
+
+type VersionRecord struct {
+	Version         string           `json:"version" yaml:"version"`
+	PreviousVersion string           `json:"previousVersion,omitempty" yaml:"previousVersion,omitempty"`
+	CreatedAt       time.Time        `json:"createdAt" yaml:"createdAt"`
+	Changelog       []ChangelogEntry `json:"changelog" yaml:"changelog"`
+}
+
+// VersionHistory holds the versions of a design, oldest first.
+type VersionHistory []VersionRecord
+
+func (b VersionBump) max(other VersionBump) VersionBump {
+	if other.rank() > b.rank() {
+		return other
+	}
+	return b
+}
+
+// Classify returns the version bump required by the changes of the diff, and a changelog entry for every change.
+func (p VersioningPolicy) Classify(diff DesignDiff) (VersionBump, []ChangelogEntry) {
+	bump := NoBump
+	entries := []ChangelogEntry{}
+	add := func(entry ChangelogEntry) {
+		if entry.Bump == "" {
+			entry.Bump = NoBump
+		}
+		bump = bump.max(entry.Bump)
+		entries = append(entries, entry)
+	}
+
+	for _, c := range diff.Components {
+		name := fmt.Sprintf("component %s (%s)", c.Name, c.Kind)
+		switch c.Type {
+		case Added:
+			add(ChangelogEntry{Bump: p.ComponentAdded, Entity: "component", ID: c.ID.String(), Type: Added, Description: name + " added"})
+		case Removed:
+			add(ChangelogEntry{Bump: p.ComponentRemoved, Entity: "component", ID: c.ID.String(), Type: Removed, Description: name + " removed"})
+		case Modified:
+			for _, change := range c.Changes {
+				add(ChangelogEntry{Bump: p.valueBump(change, true), Entity: "component", ID: c.ID.String(), Type: change.Type, Path: change.Path, Description: fmt.Sprintf("%s: %s %s", name, change.Path, change.Type)})
+			}
+		}
+	}
+	for _, r := range diff.Relationships {
+		name := fmt.Sprintf("relationship %s/%s/%s", r.Kind, r.RelationshipType, r.SubType)
+		switch r.Type {
+		case Added:
+			add(ChangelogEntry{Bump: p.RelationshipAdded, Entity: "relationship", ID: r.ID.String(), Type: Added, Description: name + " added"})
+		case Removed:
+			add(ChangelogEntry{Bump: p.RelationshipRemoved, Entity: "relationship", ID: r.ID.String(), Type: Removed, Description: name + " removed"})
+		case Modified:
+			for _, change := range r.Changes {
+				add(ChangelogEntry{Bump: p.valueBump(change, false), Entity: "relationship", ID: r.ID.String(), Type: change.Type, Path: change.Path, Description: fmt.Sprintf("%s: %s %s", name, change.Path, change.Type)})
+			}
+		}
+	}
+	return bump, entries
+}
+
+func (p VersioningPolicy) valueBump(change Change, component bool) VersionBump {
+	if component {
+		for _, breaking := range p.BreakingPaths {
+			if change.Path == breaking || strings.HasPrefix(change.Path, breaking+"/") {
+				return p.BreakingChange
+			}
+			// the change of an object holding the value, e.g. of /configuration/metadata for /configuration/metadata/name
+			if strings.HasPrefix(breaking, change.Path+"/") {
+				rest := strings.TrimPrefix(breaking, change.Path)
+				old, inOld := lookupPointer(change.Old, rest)
+				new, inNew := lookupPointer(change.New, rest)
+				if inOld != inNew || !reflect.DeepEqual(old, new) {
+					return p.BreakingChange
+				}
+			}
+		}
+	}
+	switch change.Type {
+	case Added:
+		return p.ValueAdded
+	case Removed:
+		return p.ValueRemoved
+	}
+	return p.ValueChanged
+}
+
+// lookupPointer returns the value at the JSON pointer within v.
+func lookupPointer(v interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return v, true
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch value := v.(type) {
+		case map[string]interface{}:
+			next, ok := value[token]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(value) {
+				return nil, false
+			}
+			v = value[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// IncrementVersion bumps the semver version, an empty version is assigned the initial version of designs.
+func IncrementVersion(version string, bump VersionBump) (string, error) {
+	if version == "" {
+		p := &pattern.PatternFile{}
+		AssignVersion(p)
+		return p.Version, nil
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", ErrInvalidVersion(err)
+	}
+	var next semver.Version
+	switch bump {
+	case MajorBump:
+		next = v.IncMajor()
+	case MinorBump:
+		next = v.IncMinor()
+	case PatchBump:
+		next = v.IncPatch()
+	default:
+		return version, nil
+	}
+	return next.String(), nil
+}
+
+/*
+BumpVersion compares the design with its previous version, sets its version as required by the policy and records the changes in its version history.
+The version history is carried over from the previous version. A version set by the user is kept if it is higher than the computed one.
+It returns nil if the design has not changed, the version is then kept as it is in the previous version.
+*/
+func (p VersioningPolicy) BumpVersion(previous, current *pattern.PatternFile) (*VersionRecord, error) {
+	history, err := GetVersionHistory(previous)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := Diff(previous, current)
+	if err != nil {
+		return nil, err
+	}
+	bump, changelog := p.Classify(diff)
+	if bump == NoBump {
+		current.Version = previous.Version
+		return nil, SetVersionHistory(current, history)
+	}
+
+	version, err := IncrementVersion(previous.Version, bump)
+	if err != nil {
+		return nil, err
+	}
+	if current.Version != previous.Version {
+		requested, errRequested := semver.NewVersion(current.Version)
+		computed, errComputed := semver.NewVersion(version)
+		if errRequested == nil && errComputed == nil && requested.GreaterThan(computed) {
+			version = current.Version
+		}
+	}
+	current.Version = version
+
+	record := VersionRecord{Version: version, PreviousVersion: previous.Version, CreatedAt: time.Now().UTC(), Changelog: changelog}
+	history = append(history, record)
+	if p.MaxHistory > 0 && len(history) > p.MaxHistory {
+		history = history[len(history)-p.MaxHistory:]
+	}
+	return &record, SetVersionHistory(current, history)
+}
+
+// GetVersionHistory returns the version history of the design, empty if it has none.
+func GetVersionHistory(p *pattern.PatternFile) (VersionHistory, error) {
+	history := VersionHistory{}
+	if _, err := getDesignMetadata(p, versionHistoryField, &history); err != nil {
+		return nil, ErrVersionHistory(err)
+	}
+	return history, nil
+}
+
+// SetVersionHistory stores the version history in the metadata of the design, an empty history removes it.
+func SetVersionHistory(p *pattern.PatternFile, history VersionHistory) error {
+	if len(history) == 0 {
+		return setDesignMetadata(p, versionHistoryField, nil)
+	}
+	if err := setDesignMetadata(p, versionHistoryField, history); err != nil {
+		return ErrVersionHistory(err)
+	}
+	return nil
+}
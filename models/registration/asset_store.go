@@ -0,0 +1,192 @@
+package registration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/utils"
+)
+
+// Icons are normalized to the size used by the UI before they are stored.
+const (
+	DefaultSVGWidth  = 20
+	DefaultSVGHeight = 20
+)
+
+/*
+AssetStore stores the SVG icons of registered models and components.
+Icons are normalized and addressed by the SHA-256 of their normalized content, so an icon shared by many entities is stored once.
+The registered definitions hold the reference returned by Put instead of the SVG.
+*/
+type AssetStore interface {
+	// Put stores the SVG and returns its reference.
+	Put(svg string) (string, error)
+	// Get returns the SVG stored under the reference.
+	Get(ref string) (string, error)
+}
+
+// svgReference matches the references returned by the asset stores, <hash>.svg optionally preceded by the path of the store.
+var svgReference = regexp.MustCompile(`^/?([A-Za-z0-9._-]+/)*[0-9a-f]{64}\.svg$`)
+
+func isSVGReference(value string) bool {
+	return svgReference.MatchString(value) && !strings.Contains(value, "..")
+}
+
+// normalizeSVG validates the SVG and sets its size, it returns the normalized SVG and the hex encoded SHA-256 of it.
+func normalizeSVG(svg string, width, height int) (string, string, error) {
+	// UpdateSVGString adds the XML header, remove it from SVGs which have already been normalized, e.g. exported ones
+	svg = strings.TrimPrefix(strings.TrimSpace(svg), utils.XMLTAG)
+	normalized, err := utils.UpdateSVGString(svg, width, height, false)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return normalized, hex.EncodeToString(sum[:]), nil
+}
+
+/*
+FilesystemAssetStore writes icons to <baseDir>/<first two characters of the hash>/<hash>.svg.
+References are the paths of the icons as served by the UI, i.e. relative to the UI directory, see getRelativePathForAPI.
+*/
+type FilesystemAssetStore struct {
+	baseDir string
+	Width   int
+	Height  int
+}
+
+func NewFilesystemAssetStore(baseDir string) *FilesystemAssetStore {
+	return &FilesystemAssetStore{baseDir: baseDir, Width: DefaultSVGWidth, Height: DefaultSVGHeight}
+}
+
+func (fs *FilesystemAssetStore) Put(svg string) (string, error) {
+	normalized, hash, err := normalizeSVG(svg, fs.Width, fs.Height)
+	if err != nil {
+		return "", ErrStoreSVG(err)
+	}
+	rel := filepath.Join(hash[:2], hash+".svg")
+	path := filepath.Join(fs.baseDir, rel)
+	ref := getRelativePathForAPI(fs.baseDir, rel)
+
+	// the content is addressed by its hash, an existing file holds the same icon
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", ErrStoreSVG(utils.ErrCreateDir(err, filepath.Dir(path)))
+	}
+	// write to a temporary file first, so that concurrent registrations never see a partially written icon
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return "", ErrStoreSVG(err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(normalized)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return "", ErrStoreSVG(err)
+	}
+	return ref, nil
+}
+
+func (fs *FilesystemAssetStore) Get(ref string) (string, error) {
+	// the icon is located by its hash, the directory of the reference depends on the UI
+	hash, ok := strings.CutSuffix(filepath.Base(ref), ".svg")
+	if !ok || len(hash) != sha256.Size*2 {
+		return "", ErrSVGNotFound(ref)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", ErrSVGNotFound(ref)
+	}
+	svg, err := os.ReadFile(filepath.Join(fs.baseDir, hash[:2], hash+".svg"))
+	if err != nil {
+		return "", ErrSVGNotFound(ref)
+	}
+	return string(svg), nil
+}
+
+// MemoryAssetStore keeps icons in memory, references are of the form <hash>.svg
+type MemoryAssetStore struct {
+	mx     sync.RWMutex
+	svgs   map[string]string
+	Width  int
+	Height int
+}
+
+func NewMemoryAssetStore() *MemoryAssetStore {
+	return &MemoryAssetStore{svgs: make(map[string]string), Width: DefaultSVGWidth, Height: DefaultSVGHeight}
+}
+
+func (ms *MemoryAssetStore) Put(svg string) (string, error) {
+	normalized, hash, err := normalizeSVG(svg, ms.Width, ms.Height)
+	if err != nil {
+		return "", ErrStoreSVG(err)
+	}
+	ref := hash + ".svg"
+	ms.mx.Lock()
+	ms.svgs[ref] = normalized
+	ms.mx.Unlock()
+	return ref, nil
+}
+
+func (ms *MemoryAssetStore) Get(ref string) (string, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	svg, ok := ms.svgs[ref]
+	if !ok {
+		return "", ErrSVGNotFound(ref)
+	}
+	return svg, nil
+}
+
+// Len returns the number of stored icons.
+func (ms *MemoryAssetStore) Len() int {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	return len(ms.svgs)
+}
+
+var _ AssetStore = (*FilesystemAssetStore)(nil)
+var _ AssetStore = (*MemoryAssetStore)(nil)
+
+/*
+storeSVG sanitizes the SVG and replaces it with its reference in the asset store.
+Content removed by the sanitizer and SVGs which cannot be stored are reported to the registration error store,
+SVGs which cannot be sanitized, including values which are not SVGs, e.g. HTML or data URIs, are rejected
+and replaced by an empty value. Only references to already stored icons are returned unchanged.
+*/
+func (rh *RegistrationHelper) storeSVG(svg string, en entity.EntityType, hostname, modelName, entityName string) string {
+	if svg == "" || isSVGReference(svg) {
+		return svg
+	}
+	sanitized, removed, err := utils.SanitizeSVG(svg, utils.DefaultMaxSVGImageSize)
+	if err != nil {
+		rh.regErrStore.InsertEntityRegError(hostname, modelName, en, entityName, ErrStoreSVG(err))
+		return ""
+	}
+	if len(removed) > 0 {
+		rh.regErrStore.InsertEntityRegError(hostname, modelName, en, entityName, ErrUnsafeSVG(entityName, removed))
+	}
+	if rh.AssetStore == nil {
+		return sanitized
+	}
+	ref, err := rh.AssetStore.Put(sanitized)
+	if err != nil {
+		rh.regErrStore.InsertEntityRegError(hostname, modelName, en, entityName, err)
+		return ""
+	}
+	return ref
+}
@@ -0,0 +1,77 @@
+package registration
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+)
+
+func TestStoreSVG(t *testing.T) {
+	const icon = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><path d="M0 0"/></svg>`
+	stores := map[string]AssetStore{
+		"memory":     NewMemoryAssetStore(),
+		"filesystem": NewFilesystemAssetStore(filepath.Join(t.TempDir(), "ui", "public", "static", "img", "meshmodels")),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			errStore := NewMemoryRegistrationErrorStore()
+			rh := RegistrationHelper{AssetStore: store, regErrStore: errStore}
+			store := func(svg string) string {
+				return rh.storeSVG(svg, entity.ComponentDefinition, "github", "Kubernetes", "Pod")
+			}
+
+			ref := store(icon)
+			if !isSVGReference(ref) {
+				t.Fatalf("storeSVG() = %q, want a reference", ref)
+			}
+			if got := store(ref); got != ref {
+				t.Errorf("storeSVG() of a reference = %q, want it unchanged", got)
+			}
+			if got := store(""); got != "" {
+				t.Errorf("storeSVG() of an empty value = %q", got)
+			}
+
+			unsafe := store(`<svg onload="alert(1)"><path d="M0 0"/></svg>`)
+			if stored, err := rh.AssetStore.Get(unsafe); err != nil || strings.Contains(stored, "onload") {
+				t.Errorf("stored unsafe SVG = %q, %v", stored, err)
+			}
+			if _, n, _ := errStore.Errors(RegistrationErrorFilter{}); n != 1 {
+				t.Errorf("%d errors recorded for the removed handler, want 1", n)
+			}
+
+			for _, value := range []string{
+				`<SVG onload="alert(1)"></SVG>`,
+				`data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9ImFsZXJ0KDEpIi8+`,
+				`<img src="x" onerror="alert(1)">`,
+				`<div><svg></svg></div>`,
+				`../../../etc/passwd.svg`,
+				`javascript:alert(1)//` + strings.Repeat("a", 64) + `.svg`,
+			} {
+				if got := store(value); got != "" {
+					t.Errorf("storeSVG(%q) = %q, want it rejected", value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteAndReplaceSVGWithFileSystemPath(t *testing.T) {
+	const icon = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><path d="M0 0"/></svg>`
+	baseDir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		if color, _, _ := WriteAndReplaceSVGWithFileSystemPath(icon, icon, "", baseDir, "kubernetes", "pod"); color == "" {
+			t.Fatal("WriteAndReplaceSVGWithFileSystemPath() returned no path")
+		}
+	}
+	n := 0
+	for _, path := range UISVGPaths {
+		if path == baseDir {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%s appears %d times in UISVGPaths, want once", baseDir, n)
+	}
+}
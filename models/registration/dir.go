@@ -16,6 +16,9 @@ import (
 	"github.com/meshery/schemas/models/v1beta1/model"
 )
 
+// errMissingModel is returned when an imported package does not contain a model definition.
+var errMissingModel = fmt.Errorf("model definition not found in imported package. Model definitions often use the filename `model.json`, but are not required to have this filename. One and exactly one entity containing schema: model.core must be present, otherwise the model package is considered malformed")
+
 type Dir struct {
 	dirpath string
 }
@@ -45,8 +48,8 @@ func (d Dir) PkgUnit(regErrStore RegistrationErrorStore) (_ PackagingUnit, err e
 		return pkg, ErrDirPkgUnitParseFail(d.dirpath, fmt.Errorf("could not access the path: %w", err))
 	}
 
-	// Process the path (file or directory)
-	err = processDir(d.dirpath, &pkg, regErrStore)
+	// Process the path (file or directory), archives inside it are extracted with the default limits
+	err = processDir(d.dirpath, &pkg, regErrStore, Tar{}.limits())
 	if err != nil {
 		modelName := ""
 		if !reflect.ValueOf(pkg.Model).IsZero() {
@@ -57,15 +60,19 @@ func (d Dir) PkgUnit(regErrStore RegistrationErrorStore) (_ PackagingUnit, err e
 	}
 
 	if reflect.ValueOf(pkg.Model).IsZero() {
-		errMsg := fmt.Errorf("model definition not found in imported package. Model definitions often use the filename `model.json`, but are not required to have this filename. One and exactly one entity containing schema: model.core must be present, otherwise the model package is considered malformed")
-		regErrStore.InsertEntityRegError("", "", entity.Model, filename, errMsg)
-		return pkg, errMsg
+		regErrStore.InsertEntityRegError("", "", entity.Model, filename, errMissingModel)
+		return pkg, errMissingModel
 	}
 
 	return pkg, nil
 }
 
-func processDir(dirPath string, pkg *PackagingUnit, regErrStore RegistrationErrorStore) error {
+/*
+processDir adds the definitions of the files inside dirPath to the packaging unit, extracting the archives it contains.
+Nested .zip and .tar(.gz) archives are extracted the same way as a Tar and nested OCI artifacts are read the same way
+as an OCIImage, within the limits shared by all of them.
+*/
+func processDir(dirPath string, pkg *PackagingUnit, regErrStore RegistrationErrorStore, limits *archiveLimits) error {
 	var tempDirs []string
 	defer func() {
 		for _, tempDir := range tempDirs {
@@ -93,25 +100,15 @@ func processDir(dirPath string, pkg *PackagingUnit, regErrStore RegistrationErro
 			return nil
 		}
 
-		// Check if the file is an OCI artifact
+		// OCI artifacts are read straight from their layers, the same way as an OCIImage, within the shared limits
 		if oci.IsOCIArtifact(data) {
-			// Extract the OCI artifact
-			tempDir, err := oci.CreateTempOCIContentDir()
-			if err != nil {
-				regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
-				regErrStore.AddInvalidDefinition(path, err)
-				return nil
+			img, err := NewOCIImageFromTarball(path)
+			if err == nil {
+				err = img.readLayers(pkg, regErrStore, limits)
 			}
-			tempDirs = append(tempDirs, tempDir)
-			err = oci.UnCompressOCIArtifact(path, tempDir)
 			if err != nil {
 				regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
 				regErrStore.AddInvalidDefinition(path, err)
-				return nil
-			}
-			// Recursively process the extracted directory
-			if err := processDir(tempDir, pkg, regErrStore); err != nil {
-				return err
 			}
 			return nil
 		}
@@ -126,88 +123,96 @@ func processDir(dirPath string, pkg *PackagingUnit, regErrStore RegistrationErro
 				return nil
 			}
 			tempDirs = append(tempDirs, tempDir)
-			if err := utils.ExtractFile(path, tempDir); err != nil {
+			if err := NewTar(path).extract(tempDir, limits); err != nil {
+				err = ErrTarPkgUnitParseFail(filepath.Base(path), err)
 				regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
 				regErrStore.AddInvalidDefinition(path, err)
 				return nil
 			}
 			// Recursively process the extracted directory
-			if err := processDir(tempDir, pkg, regErrStore); err != nil {
+			if err := processDir(tempDir, pkg, regErrStore, limits); err != nil {
 				return err
 			}
 			return nil
 		}
 
-		content := data
-		content, err = utils.YAMLToJSON(content)
-		if err != nil {
-			regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
-			return nil
-		}
-		// Determine the entity type
-		entityType, err := utils.FindEntityType(content)
-		if err != nil {
-			regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
-			regErrStore.AddInvalidDefinition(path, err)
-			return nil
-		}
+		addEntity(path, data, pkg, regErrStore)
+		return nil
+	})
+}
 
-		if entityType == "" {
-			// Not an entity we care about
-			return nil
-		}
+/*
+addEntity parses the definition read from path and adds it to the packaging unit.
+Invalid definitions are stored in the regErrStore, files which are not meshery definitions are ignored.
+*/
+func addEntity(path string, data []byte, pkg *PackagingUnit, regErrStore RegistrationErrorStore) {
+	content, err := utils.YAMLToJSON(data)
+	if err != nil {
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
+		return
+	}
+	// Determine the entity type
+	entityType, err := utils.FindEntityType(content)
+	if err != nil {
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), filepath.Base(path), err)
+		regErrStore.AddInvalidDefinition(path, err)
+		return
+	}
 
-		// Get the entity
-		var e entity.Entity
-		e, err = getEntity(content)
-		if err != nil {
-			regErrStore.InsertEntityRegError("", "", entityType, filepath.Base(path), fmt.Errorf("could not get entity: %w", err))
-			regErrStore.AddInvalidDefinition(path, fmt.Errorf("could not get entity: %w", err))
-			return nil
-		}
+	if entityType == "" {
+		// Not an entity we care about
+		return
+	}
 
-		// Add the entity to the packaging unit
-		switch e.Type() {
-		case entity.Model:
-			model, err := utils.Cast[*model.ModelDefinition](e)
-			if err != nil {
-				modelName := ""
-				if model != nil {
-					modelName = model.Name
-				}
-				regErrStore.InsertEntityRegError("", modelName, entityType, modelName, ErrGetEntity(err))
-				regErrStore.AddInvalidDefinition(path, ErrGetEntity(err))
-				return nil
+	// Get the entity
+	var e entity.Entity
+	e, err = getEntity(content)
+	if err != nil {
+		regErrStore.InsertEntityRegError("", "", entityType, filepath.Base(path), fmt.Errorf("could not get entity: %w", err))
+		regErrStore.AddInvalidDefinition(path, fmt.Errorf("could not get entity: %w", err))
+		return
+	}
+
+	// Add the entity to the packaging unit
+	switch e.Type() {
+	case entity.Model:
+		model, err := utils.Cast[*model.ModelDefinition](e)
+		if err != nil {
+			modelName := ""
+			if model != nil {
+				modelName = model.Name
 			}
-			pkg.Model = *model
-		case entity.ComponentDefinition:
-			comp, err := utils.Cast[*component.ComponentDefinition](e)
-			if err != nil {
-				componentName := ""
-				if comp != nil {
-					componentName = comp.Component.Kind
-				}
-				regErrStore.InsertEntityRegError("", "", entityType, componentName, ErrGetEntity(err))
-				regErrStore.AddInvalidDefinition(path, ErrGetEntity(err))
-				return nil
+			regErrStore.InsertEntityRegError("", modelName, entityType, modelName, ErrGetEntity(err))
+			regErrStore.AddInvalidDefinition(path, ErrGetEntity(err))
+			return
+		}
+		pkg.Model = *model
+	case entity.ComponentDefinition:
+		comp, err := utils.Cast[*component.ComponentDefinition](e)
+		if err != nil {
+			componentName := ""
+			if comp != nil {
+				componentName = comp.Component.Kind
 			}
-			pkg.Components = append(pkg.Components, *comp)
-		case entity.RelationshipDefinition:
-			rel, err := utils.Cast[*relationship.RelationshipDefinition](e)
-			if err != nil {
-				relationshipName := ""
-				if rel != nil {
-					relationshipName = rel.Model.Name
-				}
-				regErrStore.InsertEntityRegError("", "", entityType, relationshipName, ErrGetEntity(err))
-				regErrStore.AddInvalidDefinition(path, ErrGetEntity(err))
-				return nil
+			regErrStore.InsertEntityRegError("", "", entityType, componentName, ErrGetEntity(err))
+			regErrStore.AddInvalidDefinition(path, ErrGetEntity(err))
+			return
+		}
+		pkg.Components = append(pkg.Components, *comp)
+	case entity.RelationshipDefinition:
+		rel, err := utils.Cast[*relationship.RelationshipDefinition](e)
+		if err != nil {
+			relationshipName := ""
+			if rel != nil {
+				relationshipName = rel.Model.Name
 			}
-			pkg.Relationships = append(pkg.Relationships, *rel)
-		default:
-			// Unhandled entity type
-			return nil
+			regErrStore.InsertEntityRegError("", "", entityType, relationshipName, ErrGetEntity(err))
+			regErrStore.AddInvalidDefinition(path, ErrGetEntity(err))
+			return
 		}
-		return nil
-	})
+		pkg.Relationships = append(pkg.Relationships, *rel)
+	default:
+		// Unhandled entity type
+		return
+	}
 }
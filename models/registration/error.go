@@ -2,17 +2,25 @@ package registration
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/layer5io/meshkit/errors"
 )
 
 const (
-	ErrDirPkgUnitParseFailCode = "replace_me"
-	ErrGetEntityCode           = "replace_me"
-	ErrRegisterEntityCode      = "replace_me"
-	ErrImportFailureCode       = "replace_me"
-	ErrMissingRegistrantCode   = "replace_me"
-	ErrSeedingComponentsCode   = "replace-me"
+	ErrDirPkgUnitParseFailCode        = "replace_me"
+	ErrGetEntityCode                  = "replace_me"
+	ErrRegisterEntityCode             = "replace_me"
+	ErrImportFailureCode              = "replace_me"
+	ErrMissingRegistrantCode          = "replace_me"
+	ErrSeedingComponentsCode          = "replace-me"
+	ErrBreakingSchemaChangeCode       = "replace_me"
+	ErrTarPkgUnitParseFailCode        = "replace_me"
+	ErrStoreSVGCode                   = "replace_me"
+	ErrSVGNotFoundCode                = "replace_me"
+	ErrUnsafeSVGCode                  = "replace_me"
+	ErrRegistrationErrorStoreCode     = "replace_me"
+	ErrDanglingSelectorReferencesCode = "replace_me"
 )
 
 func ErrSeedingComponents(err error) error {
@@ -71,6 +79,17 @@ func ErrDirPkgUnitParseFail(dirpath string, err error) error {
 	)
 }
 
+func ErrTarPkgUnitParseFail(name string, err error) error {
+	return errors.New(
+		ErrTarPkgUnitParseFailCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Archive %s cannot be registered into Meshery", name)},
+		[]string{err.Error()},
+		[]string{"The archive is not a valid .tar, .tar.gz or .zip file", "The archive contains paths outside of the extraction directory", "The archive exceeds the size or file count limits", "The archive might not have a valid model definition"},
+		[]string{"Make sure that the archive is not corrupt and contains a valid model definition", "Make sure that all paths in the archive are relative and do not contain '..'", "Increase the limits of the Tar if the model package is expected to be this large"},
+	)
+}
+
 func ErrImportFailure(hostname string, failedMsg string) error {
 	return errors.New(
 		ErrImportFailureCode,
@@ -81,3 +100,73 @@ func ErrImportFailure(hostname string, failedMsg string) error {
 		[]string{"See the registration logs (found at $HOME/.meshery/logs/registry/registry-logs.log) to find out which Entity failed to be imported with more specific error information."},
 	)
 }
+
+func ErrBreakingSchemaChange(modelName, version, previousVersion string, components []string) error {
+	return errors.New(
+		ErrBreakingSchemaChangeCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Version %s of model %s has breaking schema changes compared to version %s", version, modelName, previousVersion)},
+		[]string{fmt.Sprintf("Components with breaking changes: %s", strings.Join(components, ", "))},
+		[]string{"Properties have been removed, types or allowed values have been narrowed, or new properties are required."},
+		[]string{"Register the model with a new major version, or use the annotate compatibility policy to register it anyway."},
+	)
+}
+
+func ErrStoreSVG(err error) error {
+	return errors.New(
+		ErrStoreSVGCode,
+		errors.Alert,
+		[]string{"Failed to store the SVG icon"},
+		[]string{err.Error()},
+		[]string{"The SVG is not well-formed XML", "The directory the icons are written to is not writable"},
+		[]string{"Make sure the SVGs in the definitions are valid", "Check the permissions of the directory the icons are written to"},
+	)
+}
+
+func ErrSVGNotFound(ref string) error {
+	return errors.New(
+		ErrSVGNotFoundCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("SVG icon %s not found", ref)},
+		[]string{"No icon is stored under the reference"},
+		[]string{"The icon has been registered using a different asset store", "The icon has been deleted"},
+		[]string{"Register the model again to store its icons"},
+	)
+}
+
+func ErrUnsafeSVG(entityName string, removed []string) error {
+	return errors.New(
+		ErrUnsafeSVGCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("The SVG icon of %s contains unsafe content", entityName)},
+		[]string{fmt.Sprintf("Removed: %s", strings.Join(removed, ", "))},
+		[]string{"The SVG contains scripts, event handlers or references to external resources", "The SVG embeds images larger than the size limit"},
+		[]string{"Remove scripts, event handlers and external references from the SVG", "Reduce the size of the embedded images, or reference them from the model package instead"},
+	)
+}
+
+func ErrRegistrationErrorStore(err error) error {
+	return errors.New(
+		ErrRegistrationErrorStoreCode,
+		errors.Alert,
+		[]string{"Failed to access the registration errors"},
+		[]string{err.Error()},
+		[]string{"The database is not reachable", "The registration_errors table could not be migrated"},
+		[]string{"Make sure the database is reachable and the user has permissions to create tables"},
+	)
+}
+
+func ErrDanglingSelectorReferences(relationshipName string, refs []string, rejected bool) error {
+	outcome := "The relationship has been registered"
+	if rejected {
+		outcome = "The relationship has not been registered"
+	}
+	return errors.New(
+		ErrDanglingSelectorReferencesCode,
+		errors.Alert,
+		[]string{fmt.Sprintf("Selectors of relationship %s reference components which are not registered. %s.", relationshipName, outcome)},
+		[]string{fmt.Sprintf("Unresolved selectors: %s", strings.Join(refs, ", "))},
+		[]string{"The referenced components, or their models, have not been registered", "The component kind, model name or model version in the selector is misspelled"},
+		[]string{"Register the models the relationship refers to before the relationship", "Correct the kind, model name and version in the selectors, use * to match any value"},
+	)
+}
@@ -0,0 +1,300 @@
+package registration
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshkit/database"
+	meshkiterrors "github.com/layer5io/meshkit/errors"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"gorm.io/gorm"
+)
+
+// RegistrationError is an error recorded by a registration error store.
+type RegistrationError struct {
+	ID uuid.UUID `json:"id" gorm:"primaryKey"`
+	// Hostname is the kind of the registrant, e.g. github or artifacthub.
+	Hostname   string            `json:"hostname" gorm:"index"`
+	ModelName  string            `json:"modelName" gorm:"index"`
+	EntityType entity.EntityType `json:"entityType"`
+	EntityName string            `json:"entityName"`
+	// Path is the path of the definition, it is only set for invalid definitions.
+	Path string `json:"path,omitempty"`
+	// Code is the meshkit error code, empty if the error is not a meshkit error.
+	Code        string    `json:"code" gorm:"index"`
+	Description string    `json:"description"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"index"`
+}
+
+func (RegistrationError) TableName() string {
+	return "registration_errors"
+}
+
+func newRegistrationError(hostname, modelName string, entityType entity.EntityType, entityName, path string, err error) RegistrationError {
+	id, _ := uuid.NewV4()
+	re := RegistrationError{
+		ID:         id,
+		Hostname:   hostname,
+		ModelName:  modelName,
+		EntityType: entityType,
+		EntityName: entityName,
+		Path:       path,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err == nil {
+		return re
+	}
+	re.Message = err.Error()
+	var merr *meshkiterrors.Error
+	if errors.As(err, &merr) {
+		re.Code = merr.Code
+		re.Description = meshkiterrors.GetSDescription(merr)
+	}
+	return re
+}
+
+// RegistrationErrorFilter selects recorded errors, empty fields match all errors.
+type RegistrationErrorFilter struct {
+	Hostname   string
+	ModelName  string
+	EntityType entity.EntityType
+	Code       string
+	// Since selects the errors recorded at or after the time.
+	Since  time.Time
+	Limit  int
+	Offset int
+}
+
+func (f RegistrationErrorFilter) matches(re RegistrationError) bool {
+	return (f.Hostname == "" || re.Hostname == f.Hostname) &&
+		(f.ModelName == "" || re.ModelName == f.ModelName) &&
+		(f.EntityType == "" || re.EntityType == f.EntityType) &&
+		(f.Code == "" || re.Code == f.Code) &&
+		(f.Since.IsZero() || !re.CreatedAt.Before(f.Since))
+}
+
+// RegistrationErrorSummary counts the errors of a model of a registrant, invalid definitions are counted with empty hostname and model.
+type RegistrationErrorSummary struct {
+	Hostname  string `json:"hostname"`
+	ModelName string `json:"modelName"`
+	// Errors counts the errors by entity type.
+	Errors map[entity.EntityType]int64 `json:"errors"`
+	Total  int64                       `json:"total"`
+}
+
+// summarize groups the counts by registrant and model, sorted by hostname and model name.
+func summarize(counts []registrationErrorCount) []RegistrationErrorSummary {
+	type key struct{ hostname, modelName string }
+	index := map[key]int{}
+	summaries := []RegistrationErrorSummary{}
+	for _, c := range counts {
+		k := key{c.Hostname, c.ModelName}
+		i, ok := index[k]
+		if !ok {
+			i = len(summaries)
+			index[k] = i
+			summaries = append(summaries, RegistrationErrorSummary{Hostname: c.Hostname, ModelName: c.ModelName, Errors: map[entity.EntityType]int64{}})
+		}
+		summaries[i].Errors[c.EntityType] += c.Count
+		summaries[i].Total += c.Count
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Hostname != summaries[j].Hostname {
+			return summaries[i].Hostname < summaries[j].Hostname
+		}
+		return summaries[i].ModelName < summaries[j].ModelName
+	})
+	return summaries
+}
+
+type registrationErrorCount struct {
+	Hostname   string
+	ModelName  string
+	EntityType entity.EntityType
+	Count      int64
+}
+
+/*
+DBRegistrationErrorStore persists registration errors in the registration_errors table, so that they are kept across restarts.
+Errors which occur while recording are kept and returned by Err, as the RegistrationErrorStore interface cannot return them.
+*/
+type DBRegistrationErrorStore struct {
+	db  *database.Handler
+	mx  sync.Mutex
+	err error
+}
+
+func NewDBRegistrationErrorStore(db *database.Handler) (*DBRegistrationErrorStore, error) {
+	if db == nil {
+		return nil, ErrRegistrationErrorStore(errors.New("nil database handler"))
+	}
+	if err := db.AutoMigrate(&RegistrationError{}); err != nil {
+		return nil, ErrRegistrationErrorStore(err)
+	}
+	return &DBRegistrationErrorStore{db: db}, nil
+}
+
+func (s *DBRegistrationErrorStore) AddInvalidDefinition(path string, err error) {
+	s.insert(newRegistrationError("", "", "", "", path, err))
+}
+
+func (s *DBRegistrationErrorStore) InsertEntityRegError(hostname string, modelName string, entityType entity.EntityType, entityName string, err error) {
+	s.insert(newRegistrationError(hostname, modelName, entityType, entityName, "", err))
+}
+
+func (s *DBRegistrationErrorStore) insert(re RegistrationError) {
+	if err := s.db.Create(&re).Error; err != nil {
+		s.mx.Lock()
+		if s.err == nil {
+			s.err = ErrRegistrationErrorStore(err)
+		}
+		s.mx.Unlock()
+	}
+}
+
+// Err returns the first error that occurred while recording registration errors.
+func (s *DBRegistrationErrorStore) Err() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.err
+}
+
+func (s *DBRegistrationErrorStore) query(f RegistrationErrorFilter) *gorm.DB {
+	q := s.db.Model(&RegistrationError{})
+	if f.Hostname != "" {
+		q = q.Where("hostname = ?", f.Hostname)
+	}
+	if f.ModelName != "" {
+		q = q.Where("model_name = ?", f.ModelName)
+	}
+	if f.EntityType != "" {
+		q = q.Where("entity_type = ?", f.EntityType)
+	}
+	if f.Code != "" {
+		q = q.Where("code = ?", f.Code)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("created_at >= ?", f.Since)
+	}
+	return q
+}
+
+// Errors returns the errors selected by the filter, the most recent first, and the number of selected errors before pagination.
+func (s *DBRegistrationErrorStore) Errors(f RegistrationErrorFilter) ([]RegistrationError, int64, error) {
+	var count int64
+	if err := s.query(f).Count(&count).Error; err != nil {
+		return nil, 0, ErrRegistrationErrorStore(err)
+	}
+	// errors recorded at the same time are ordered by id, so that pages do not overlap
+	q := s.query(f).Order("created_at desc").Order("id desc").Offset(f.Offset)
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	errs := []RegistrationError{}
+	if err := q.Find(&errs).Error; err != nil {
+		return nil, 0, ErrRegistrationErrorStore(err)
+	}
+	return errs, count, nil
+}
+
+// Summary counts the errors selected by the filter, grouped by registrant and model. Pagination is ignored.
+func (s *DBRegistrationErrorStore) Summary(f RegistrationErrorFilter) ([]RegistrationErrorSummary, error) {
+	counts := []registrationErrorCount{}
+	err := s.query(f).
+		Select("hostname, model_name, entity_type, count(*) as count").
+		Group("hostname, model_name, entity_type").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, ErrRegistrationErrorStore(err)
+	}
+	return summarize(counts), nil
+}
+
+// Delete deletes the errors selected by the filter, e.g. the errors of a model before it is registered again. Pagination is ignored.
+func (s *DBRegistrationErrorStore) Delete(f RegistrationErrorFilter) (int64, error) {
+	result := s.query(f).Where("1 = 1").Delete(&RegistrationError{})
+	if result.Error != nil {
+		return 0, ErrRegistrationErrorStore(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// MemoryRegistrationErrorStore keeps registration errors in memory, it offers the same queries as DBRegistrationErrorStore.
+type MemoryRegistrationErrorStore struct {
+	mx   sync.RWMutex
+	errs []RegistrationError
+}
+
+func NewMemoryRegistrationErrorStore() *MemoryRegistrationErrorStore {
+	return &MemoryRegistrationErrorStore{}
+}
+
+func (s *MemoryRegistrationErrorStore) AddInvalidDefinition(path string, err error) {
+	s.insert(newRegistrationError("", "", "", "", path, err))
+}
+
+func (s *MemoryRegistrationErrorStore) InsertEntityRegError(hostname string, modelName string, entityType entity.EntityType, entityName string, err error) {
+	s.insert(newRegistrationError(hostname, modelName, entityType, entityName, "", err))
+}
+
+func (s *MemoryRegistrationErrorStore) insert(re RegistrationError) {
+	s.mx.Lock()
+	s.errs = append(s.errs, re)
+	s.mx.Unlock()
+}
+
+// Errors returns the errors selected by the filter, the most recent first, and the number of selected errors before pagination.
+func (s *MemoryRegistrationErrorStore) Errors(f RegistrationErrorFilter) ([]RegistrationError, int64, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	selected := []RegistrationError{}
+	for i := len(s.errs) - 1; i >= 0; i-- {
+		if f.matches(s.errs[i]) {
+			selected = append(selected, s.errs[i])
+		}
+	}
+	count := int64(len(selected))
+	if f.Offset >= len(selected) {
+		return []RegistrationError{}, count, nil
+	}
+	selected = selected[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(selected) {
+		selected = selected[:f.Limit]
+	}
+	return selected, count, nil
+}
+
+// Summary counts the errors selected by the filter, grouped by registrant and model. Pagination is ignored.
+func (s *MemoryRegistrationErrorStore) Summary(f RegistrationErrorFilter) ([]RegistrationErrorSummary, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	counts := []registrationErrorCount{}
+	for _, re := range s.errs {
+		if f.matches(re) {
+			counts = append(counts, registrationErrorCount{Hostname: re.Hostname, ModelName: re.ModelName, EntityType: re.EntityType, Count: 1})
+		}
+	}
+	return summarize(counts), nil
+}
+
+// Delete deletes the errors selected by the filter. Pagination is ignored.
+func (s *MemoryRegistrationErrorStore) Delete(f RegistrationErrorFilter) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	kept := s.errs[:0]
+	for _, re := range s.errs {
+		if !f.matches(re) {
+			kept = append(kept, re)
+		}
+	}
+	deleted := int64(len(s.errs) - len(kept))
+	s.errs = kept
+	return deleted, nil
+}
+
+var _ RegistrationErrorStore = (*DBRegistrationErrorStore)(nil)
+var _ RegistrationErrorStore = (*MemoryRegistrationErrorStore)(nil)
@@ -0,0 +1,138 @@
+package registration
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"gorm.io/gorm/logger"
+)
+
+// registrationErrorStore is implemented by the stores which can be queried.
+type registrationErrorStore interface {
+	RegistrationErrorStore
+	Errors(RegistrationErrorFilter) ([]RegistrationError, int64, error)
+	Summary(RegistrationErrorFilter) ([]RegistrationErrorSummary, error)
+	Delete(RegistrationErrorFilter) (int64, error)
+}
+
+func newTestDBRegistrationErrorStore(t *testing.T) *DBRegistrationErrorStore {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	store, err := NewDBRegistrationErrorStore(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestRegistrationErrorStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) registrationErrorStore{
+		"memory": func(t *testing.T) registrationErrorStore { return NewMemoryRegistrationErrorStore() },
+		"db":     func(t *testing.T) registrationErrorStore { return newTestDBRegistrationErrorStore(t) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			start := time.Now().UTC().Add(-time.Second)
+			store.AddInvalidDefinition("/models/broken.json", errors.New("invalid json"))
+			store.InsertEntityRegError("github", "kubernetes", entity.Model, "kubernetes", ErrMissingRegistrant("kubernetes"))
+			for _, kind := range []string{"Pod", "Service", "Deployment"} {
+				store.InsertEntityRegError("github", "kubernetes", entity.ComponentDefinition, kind, errors.New("invalid schema"))
+			}
+			store.InsertEntityRegError("github", "istio", entity.ComponentDefinition, "VirtualService", errors.New("invalid schema"))
+			store.InsertEntityRegError("artifacthub", "kubernetes", entity.RelationshipDefinition, "edge", errors.New("dangling"))
+
+			filters := []struct {
+				name   string
+				filter RegistrationErrorFilter
+				want   int64
+			}{
+				{"all", RegistrationErrorFilter{}, 7},
+				{"hostname", RegistrationErrorFilter{Hostname: "github"}, 5},
+				{"model", RegistrationErrorFilter{ModelName: "kubernetes"}, 5},
+				{"entity type", RegistrationErrorFilter{EntityType: entity.ComponentDefinition}, 4},
+				{"code", RegistrationErrorFilter{Code: ErrMissingRegistrantCode}, 1},
+				{"combined", RegistrationErrorFilter{Hostname: "github", ModelName: "kubernetes", EntityType: entity.ComponentDefinition}, 3},
+				{"since", RegistrationErrorFilter{Since: start}, 7},
+				{"since later", RegistrationErrorFilter{Since: time.Now().Add(time.Hour)}, 0},
+			}
+			for _, f := range filters {
+				errs, count, err := store.Errors(f.filter)
+				if err != nil {
+					t.Fatalf("Errors(%s) error = %v", f.name, err)
+				}
+				if count != f.want || int64(len(errs)) != f.want {
+					t.Errorf("Errors(%s) = %d errors and count %d, want %d", f.name, len(errs), count, f.want)
+				}
+			}
+
+			errs, _, _ := store.Errors(RegistrationErrorFilter{})
+			if invalid := errs[len(errs)-1]; invalid.Path != "/models/broken.json" || invalid.Message != "invalid json" || invalid.Hostname != "" {
+				t.Errorf("oldest error = %+v, want the invalid definition", invalid)
+			}
+			if registrant := errs[len(errs)-2]; registrant.Code != ErrMissingRegistrantCode || registrant.Description == "" {
+				t.Errorf("meshkit error recorded as %+v", registrant)
+			}
+
+			// pages cover every error once
+			seen := map[string]bool{}
+			for offset := 0; offset < 7; offset += 3 {
+				page, count, err := store.Errors(RegistrationErrorFilter{Limit: 3, Offset: offset})
+				if err != nil || count != 7 {
+					t.Fatalf("Errors() page at %d = count %d, %v", offset, count, err)
+				}
+				for _, re := range page {
+					if seen[re.ID.String()] {
+						t.Errorf("error %s returned by several pages", re.ID)
+					}
+					seen[re.ID.String()] = true
+				}
+			}
+			if len(seen) != 7 {
+				t.Errorf("pages returned %d errors, want 7", len(seen))
+			}
+
+			summary, err := store.Summary(RegistrationErrorFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []RegistrationErrorSummary{
+				{Hostname: "", ModelName: "", Errors: map[entity.EntityType]int64{"": 1}, Total: 1},
+				{Hostname: "artifacthub", ModelName: "kubernetes", Errors: map[entity.EntityType]int64{entity.RelationshipDefinition: 1}, Total: 1},
+				{Hostname: "github", ModelName: "istio", Errors: map[entity.EntityType]int64{entity.ComponentDefinition: 1}, Total: 1},
+				{Hostname: "github", ModelName: "kubernetes", Errors: map[entity.EntityType]int64{entity.Model: 1, entity.ComponentDefinition: 3}, Total: 4},
+			}
+			if !reflect.DeepEqual(summary, want) {
+				t.Errorf("Summary() = %+v, want %+v", summary, want)
+			}
+
+			deleted, err := store.Delete(RegistrationErrorFilter{Hostname: "github", ModelName: "kubernetes"})
+			if err != nil || deleted != 4 {
+				t.Errorf("Delete() = %d, %v, want 4", deleted, err)
+			}
+			if _, count, _ := store.Errors(RegistrationErrorFilter{}); count != 3 {
+				t.Errorf("%d errors left after Delete(), want 3", count)
+			}
+			if _, count, _ := store.Errors(RegistrationErrorFilter{ModelName: "kubernetes"}); count != 1 {
+				t.Errorf("%d errors of kubernetes left after Delete(), want the error of artifacthub", count)
+			}
+		})
+	}
+
+	store := newTestDBRegistrationErrorStore(t)
+	if err := store.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+	if _, err := NewDBRegistrationErrorStore(nil); err == nil {
+		t.Error("NewDBRegistrationErrorStore() accepted a nil handler")
+	}
+}
@@ -1,14 +1,235 @@
 package registration
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+
+	"github.com/google/go-containerregistry/pkg/crane"
 	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/models/oci"
 )
 
+// tarMagic is the magic of POSIX tar headers, at offset 257 of the first block
+var tarMagic = []byte("ustar")
+
+/*
+OCIImage is a model package published as an OCI artifact.
+The layers of the image either are tarballs containing the definitions, as built by oci.BuildImage, or a single definition.
+*/
 type OCIImage struct {
-	_ gcrv1.Image
+	img  gcrv1.Image
+	name string
+	// MaxSize limits the total size of the definitions read from the layers, DefaultMaxArchiveSize is used when zero.
+	MaxSize int64
+	// MaxEntries limits the number of files in the layers, DefaultMaxArchiveEntries is used when zero.
+	MaxEntries int
+}
+
+func NewOCIImage(img gcrv1.Image, name string) OCIImage {
+	return OCIImage{img: img, name: name}
+}
+
+/*
+NewOCIImageFromTarball loads the image from a tarball, as written by oci.SaveOCIArtifact.
+*/
+func NewOCIImageFromTarball(tarballPath string) (OCIImage, error) {
+	if err := oci.ValidateOCIArtifact(tarballPath); err != nil {
+		return OCIImage{}, oci.ErrValidatingImage(err)
+	}
+	img, err := tarball.ImageFromPath(tarballPath, nil)
+	if err != nil {
+		return OCIImage{}, oci.ErrGettingImage(err)
+	}
+	return NewOCIImage(img, path.Base(tarballPath)), nil
+}
+
+/*
+NewOCIImageFromLayout loads the image with the given digest from an OCI image layout directory.
+If digest is empty, the layout must contain exactly one image.
+*/
+func NewOCIImageFromLayout(layoutPath, digest string) (OCIImage, error) {
+	idx, err := layout.ImageIndexFromPath(layoutPath)
+	if err != nil {
+		return OCIImage{}, oci.ErrGettingImage(err)
+	}
+	if digest == "" {
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return OCIImage{}, oci.ErrGettingImage(err)
+		}
+		if len(manifest.Manifests) != 1 {
+			return OCIImage{}, oci.ErrGettingImage(fmt.Errorf("layout at %s contains %d images, specify the digest of the image to register", layoutPath, len(manifest.Manifests)))
+		}
+		digest = manifest.Manifests[0].Digest.String()
+	}
+	hash, err := gcrv1.NewHash(digest)
+	if err != nil {
+		return OCIImage{}, oci.ErrGettingImage(err)
+	}
+	img, err := idx.Image(hash)
+	if err != nil {
+		return OCIImage{}, oci.ErrGettingImage(err)
+	}
+	return NewOCIImage(img, fmt.Sprintf("%s@%s", path.Base(layoutPath), digest)), nil
+}
+
+/*
+NewOCIImageFromStore loads the image tagged with reference from the local store, without contacting the registry.
+*/
+func NewOCIImageFromStore(store *oci.Store, reference string) (OCIImage, error) {
+	desc, err := store.Resolve(reference)
+	if err != nil {
+		return OCIImage{}, err
+	}
+	img, err := NewOCIImageFromLayout(store.Root(), desc.Digest.String())
+	if err != nil {
+		return OCIImage{}, err
+	}
+	img.name = reference
+	return img, nil
+}
+
+/*
+NewOCIImageFromRegistry pulls the image from a registry, e.g. ghcr.io/meshery/models/kubernetes:v1.31.0
+*/
+func NewOCIImageFromRegistry(ref string, opts ...crane.Option) (OCIImage, error) {
+	img, err := crane.Pull(ref, opts...)
+	if err != nil {
+		return OCIImage{}, oci.ErrGettingImage(err)
+	}
+	return NewOCIImage(img, ref), nil
+}
+
+/*
+NewVerifiedOCIImage verifies the signatures of the artifact against the trust policy and pulls the verified image by digest.
+*/
+func NewVerifiedOCIImage(opts oci.RegistryOptions, policy oci.TrustPolicy) (OCIImage, error) {
+	desc, err := oci.VerifyArtifact(opts, policy)
+	if err != nil {
+		return OCIImage{}, err
+	}
+	img, err := oci.PullImage(opts, desc.Digest.String())
+	if err != nil {
+		return OCIImage{}, err
+	}
+	return NewOCIImage(img, oci.Reference(opts)), nil
 }
 
-func (o OCIImage) PkgUnit(regErrStore RegistrationErrorStore) (PackagingUnit, error) {
+/*
+PkgUnit validates the image and reads the definitions straight from its layers, nothing is written to disk.
+Invalid definitions are stored in the regErrStore with error data.
+*/
+func (o OCIImage) PkgUnit(regErrStore RegistrationErrorStore) (_ PackagingUnit, err error) {
 	pkg := PackagingUnit{}
+
+	if o.img == nil {
+		err = oci.ErrGettingImage(fmt.Errorf("no image to register"))
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), o.name, err)
+		return pkg, err
+	}
+
+	err = oci.ValidateOCIImage(o.img)
+	if err != nil {
+		err = oci.ErrValidatingImage(err)
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), o.name, err)
+		return pkg, err
+	}
+
+	limits := Tar{MaxSize: o.MaxSize, MaxEntries: o.MaxEntries}.limits()
+	err = o.readLayers(&pkg, regErrStore, limits)
+	if err != nil {
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), o.name, err)
+		return pkg, err
+	}
+
+	if reflect.ValueOf(pkg.Model).IsZero() {
+		regErrStore.InsertEntityRegError("", "", entity.Model, o.name, errMissingModel)
+		return pkg, errMissingModel
+	}
+
 	return pkg, nil
 }
+
+// readLayers adds the definitions contained in the layers of the image to the packaging unit.
+func (o OCIImage) readLayers(pkg *PackagingUnit, regErrStore RegistrationErrorStore, limits *archiveLimits) error {
+	layers, err := o.img.Layers()
+	if err != nil {
+		return oci.ErrGettingLayer(err)
+	}
+	for _, layer := range layers {
+		if err := readLayer(layer, pkg, regErrStore, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLayer adds the definitions contained in the layer to the packaging unit.
+func readLayer(layer gcrv1.Layer, pkg *PackagingUnit, regErrStore RegistrationErrorStore, limits *archiveLimits) error {
+	digest, err := layer.Digest()
+	if err != nil {
+		return oci.ErrGettingLayer(err)
+	}
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return oci.ErrGettingLayer(err)
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	header, _ := br.Peek(257 + len(tarMagic))
+	if len(header) < 257+len(tarMagic) || !bytes.Equal(header[257:], tarMagic) {
+		// static layers hold a single definition
+		if err := limits.entry(); err != nil {
+			return err
+		}
+		data, err := readLimited(br, limits)
+		if err != nil {
+			return err
+		}
+		addEntity(digest.String(), data, pkg, regErrStore)
+		return nil
+	}
+
+	tr := tar.NewReader(br)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return oci.ErrUnTaringLayer(err)
+		}
+		if err := limits.entry(); err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := readLimited(tr, limits)
+		if err != nil {
+			return err
+		}
+		addEntity(header.Name, data, pkg, regErrStore)
+	}
+}
+
+func readLimited(r io.Reader, limits *archiveLimits) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.size+1))
+	if err != nil {
+		return nil, oci.ErrReadingFile(err)
+	}
+	limits.size -= int64(len(data))
+	if limits.size < 0 {
+		return nil, fmt.Errorf("image exceeds the size limit")
+	}
+	return data, nil
+}
@@ -1,6 +1,8 @@
 package registration
 
 import (
+	"fmt"
+
 	"github.com/layer5io/meshkit/models/meshmodel/core/v1beta1"
 	"github.com/layer5io/meshkit/models/meshmodel/entity"
 	meshmodel "github.com/layer5io/meshkit/models/meshmodel/registry"
@@ -21,35 +23,139 @@ type PackagingUnit struct {
 type RegistrationHelper struct {
 	regManager  *meshmodel.RegistryManager
 	regErrStore RegistrationErrorStore
-	svgBaseDir  string
 	PkgUnits    []PackagingUnit // Store successfully registered packagingUnits
+	// Fingerprints of the entities registered by this helper, used to detect changes between imports.
+	Fingerprints []meshmodel.EntityFingerprint
+	// CompatibilityPolicy decides whether component schemas are checked against the previous version of the model.
+	CompatibilityPolicy meshmodel.CompatibilityPolicy
+	// RelationshipValidation decides whether relationships whose selectors reference unregistered components are registered.
+	RelationshipValidation meshmodel.RelationshipValidationPolicy
+	// AssetStore stores the SVG icons of the registered entities, the SVGs are kept in the definitions if it is nil.
+	AssetStore AssetStore
 }
 
+/*
+NewRegistrationHelper returns a helper which writes the SVG icons of the registered entities to svgBaseDir.
+Set the AssetStore of the helper to store them elsewhere.
+*/
 func NewRegistrationHelper(svgBaseDir string, regm *meshmodel.RegistryManager, regErrStore RegistrationErrorStore) RegistrationHelper {
-	return RegistrationHelper{svgBaseDir: svgBaseDir, regManager: regm, regErrStore: regErrStore, PkgUnits: []PackagingUnit{}}
+	return RegistrationHelper{AssetStore: NewFilesystemAssetStore(svgBaseDir), regManager: regm, regErrStore: regErrStore, PkgUnits: []PackagingUnit{}, Fingerprints: []meshmodel.EntityFingerprint{}}
 }
 
 /*
-Register will accept a RegisterableEntity (dir, tar or oci for now).
+Changes returns the entities that were added, modified or removed compared to the fingerprints of a previous import.
 */
-func (rh *RegistrationHelper) Register(entity RegisterableEntity) {
-	// get the packaging units
-	pu, err := entity.PkgUnit(rh.regErrStore)
+func (rh *RegistrationHelper) Changes(previous []meshmodel.EntityFingerprint) []meshmodel.EntityChange {
+	return meshmodel.DiffImports(previous, rh.Fingerprints)
+}
+
+// recordFingerprint records the fingerprint of the registered entity, entities which cannot be hashed are reported to the regErrStore.
+func (rh *RegistrationHelper) recordFingerprint(en entity.Entity, hostname, modelName string) {
+	fp, err := meshmodel.NewEntityFingerprint(en)
 	if err != nil {
-		// given input is not a valid model, or could not walk the directory
+		rh.regErrStore.InsertEntityRegError(hostname, modelName, en.Type(), meshmodel.EntityKey(en), err)
 		return
 	}
-	rh.register(pu)
+	rh.Fingerprints = append(rh.Fingerprints, fp)
+}
+
+/*
+validateRelationship resolves the selectors of the relationship against the registry and reports the references to unregistered components.
+It returns false if the relationship must not be registered.
+*/
+func (rh *RegistrationHelper) validateRelationship(rel relationship.RelationshipDefinition, hostname, modelName string) bool {
+	if rh.RelationshipValidation == meshmodel.RelationshipValidationSkip {
+		return true
+	}
+	dangling, err := rh.regManager.DanglingSelectorReferences(rel)
+	if err != nil {
+		rh.regErrStore.InsertEntityRegError(hostname, modelName, entity.RelationshipDefinition, rel.Id.String(), err)
+		return rh.RelationshipValidation != meshmodel.RelationshipValidationReject
+	}
+	if len(dangling) == 0 {
+		return true
+	}
+	refs := make([]string, 0, len(dangling))
+	for _, ref := range dangling {
+		refs = append(refs, ref.String())
+	}
+	rejected := rh.RelationshipValidation == meshmodel.RelationshipValidationReject
+	err = ErrDanglingSelectorReferences(fmt.Sprintf("%s-%s-%s", rel.Kind, rel.RelationshipType, rel.SubType), refs, rejected)
+	rh.regErrStore.InsertEntityRegError(hostname, modelName, entity.RelationshipDefinition, rel.Id.String(), err)
+	return !rejected
 }
 
 /*
-register will return an error if it is not able to register the `model`.
-If there are errors when registering other entities, they are handled properly but does not stop the registration process.
+checkCompatibility compares the component schemas of the model with the previously registered version of the model
+and records the result in the model metadata. It returns false if the model must not be registered.
 */
-func (rh *RegistrationHelper) register(pkg PackagingUnit) {
+func (rh *RegistrationHelper) checkCompatibility(m *model.ModelDefinition, components []component.ComponentDefinition) bool {
+	if rh.CompatibilityPolicy == meshmodel.CompatibilityIgnore {
+		return true
+	}
+	result, err := rh.regManager.CheckModelCompatibility(*m, components)
+	if err != nil {
+		// the check is advisory unless it reports breaking changes
+		rh.regErrStore.InsertEntityRegError(m.Registrant.Kind, "", entity.Model, m.Name, err)
+		return true
+	}
+	if result == nil {
+		return true
+	}
+	if result.Breaking && rh.CompatibilityPolicy == meshmodel.CompatibilityBlock {
+		err := ErrBreakingSchemaChange(m.Name, m.Model.Version, result.PreviousVersion, result.BreakingComponents())
+		rh.regErrStore.InsertEntityRegError(m.Registrant.Kind, "", entity.Model, m.Name, err)
+		return false
+	}
+	if m.Metadata == nil {
+		m.Metadata = &model.ModelDefinition_Metadata{}
+	}
+	m.Metadata.Set("schemaCompatibility", result)
+	return true
+}
+
+/*
+Register will accept a RegisterableEntity (dir, tar or oci for now).
+Relationships can only reference the components of the entity and of the models already registered,
+use RegisterAll to register entities whose relationships reference each other.
+*/
+func (rh *RegistrationHelper) Register(entity RegisterableEntity) {
+	rh.RegisterAll(entity)
+}
+
+/*
+RegisterAll registers the models and components of every entity before their relationships,
+so that the selectors of the relationships are resolved against all the components of the import.
+*/
+func (rh *RegistrationHelper) RegisterAll(entities ...RegisterableEntity) {
+	pending := make([]PackagingUnit, 0, len(entities))
+	for _, entity := range entities {
+		// get the packaging units
+		pu, err := entity.PkgUnit(rh.regErrStore)
+		if err != nil {
+			// given input is not a valid model, or could not walk the directory
+			continue
+		}
+		if rh.registerModel(&pu) {
+			pending = append(pending, pu)
+		}
+	}
+	for _, pu := range pending {
+		rh.registerRelationships(&pu)
+		// Store the successfully registered PackagingUnit
+		rh.PkgUnits = append(rh.PkgUnits, pu)
+	}
+}
+
+/*
+registerModel registers the model and the components of the packaging unit, and keeps only the registered components in pkg.
+It returns false if the model is not registered, in which case nothing else of the packaging unit is registered.
+If there are errors when registering components, they are handled properly but does not stop the registration process.
+*/
+func (rh *RegistrationHelper) registerModel(pkg *PackagingUnit) bool {
 	if len(pkg.Components) == 0 && len(pkg.Relationships) == 0 {
 		//silently exit if the model does not conatin any components or relationships
-		return
+		return false
 	}
 	// 1. Register the model
 	model := pkg.Model
@@ -58,28 +164,20 @@ func (rh *RegistrationHelper) register(pkg PackagingUnit) {
 	if model.Registrant.Kind == "" {
 		err := ErrMissingRegistrant(model.Name)
 		rh.regErrStore.InsertEntityRegError(model.Registrant.Kind, "", entity.Model, model.Name, err)
-		return
+		return false
+	}
+
+	if !rh.checkCompatibility(&model, pkg.Components) {
+		return false
 	}
 
 	if model.Metadata != nil {
-		svgComplete := ""
+		// Store SVG for models
+		model.Metadata.SvgColor = rh.storeSVG(model.Metadata.SvgColor, entity.Model, model.Registrant.Kind, "", model.Name)
+		model.Metadata.SvgWhite = rh.storeSVG(model.Metadata.SvgWhite, entity.Model, model.Registrant.Kind, "", model.Name)
 		if model.Metadata.SvgComplete != nil {
-			svgComplete = *model.Metadata.SvgComplete
-		}
-
-		var svgCompletePath string
-
-		// Write SVG for models
-		model.Metadata.SvgColor, model.Metadata.SvgWhite, svgCompletePath = WriteAndReplaceSVGWithFileSystemPath(
-			model.Metadata.SvgColor,
-			model.Metadata.SvgWhite,
-			svgComplete,
-			rh.svgBaseDir,
-			model.Name,
-			model.Name,
-		)
-		if svgCompletePath != "" {
-			model.Metadata.SvgComplete = &svgCompletePath
+			svgComplete := rh.storeSVG(*model.Metadata.SvgComplete, entity.Model, model.Registrant.Kind, "", model.Name)
+			model.Metadata.SvgComplete = &svgComplete
 		}
 	}
 
@@ -90,28 +188,23 @@ func (rh *RegistrationHelper) register(pkg PackagingUnit) {
 	if err != nil {
 		err = ErrRegisterEntity(err, string(model.Type()), model.DisplayName)
 		rh.regErrStore.InsertEntityRegError(model.Registrant.Kind, "", entity.Model, model.Name, err)
-		return
+		return false
 	}
+	rh.recordFingerprint(&model, model.Registrant.Kind, "")
 
 	hostname := model.Registrant.Kind
 
-	// Prepare slices to hold successfully registered components and relationships
+	// Prepare a slice to hold successfully registered components
 	var registeredComponents []component.ComponentDefinition
-	var registeredRelationships []relationship.RelationshipDefinition
 	// 2. Register components
 	for _, comp := range pkg.Components {
 		comp.Model = model
 
 		if comp.Styles != nil {
-			// Write SVG for components
-			comp.Styles.SvgColor, comp.Styles.SvgWhite, comp.Styles.SvgComplete = WriteAndReplaceSVGWithFileSystemPath(
-				comp.Styles.SvgColor,
-				comp.Styles.SvgWhite,
-				comp.Styles.SvgComplete,
-				rh.svgBaseDir,
-				comp.Model.Name,
-				comp.Component.Kind,
-			)
+			// Store SVG for components
+			comp.Styles.SvgColor = rh.storeSVG(comp.Styles.SvgColor, entity.ComponentDefinition, hostname, model.DisplayName, comp.DisplayName)
+			comp.Styles.SvgWhite = rh.storeSVG(comp.Styles.SvgWhite, entity.ComponentDefinition, hostname, model.DisplayName, comp.DisplayName)
+			comp.Styles.SvgComplete = rh.storeSVG(comp.Styles.SvgComplete, entity.ComponentDefinition, hostname, model.DisplayName, comp.DisplayName)
 		}
 
 		_, _, err := rh.regManager.RegisterEntity(model.Registrant, &comp)
@@ -121,12 +214,30 @@ func (rh *RegistrationHelper) register(pkg PackagingUnit) {
 		} else {
 			// Successful registration, add to successfulComponents
 			registeredComponents = append(registeredComponents, comp)
+			rh.recordFingerprint(&comp, hostname, model.DisplayName)
 		}
 	}
 
+	// Update pkg with only successfully registered components
+	pkg.Components = registeredComponents
+	pkg.Model = model
+	return true
+}
+
+/*
+registerRelationships registers the relationships of a packaging unit whose model is registered,
+and keeps only the registered relationships in pkg.
+*/
+func (rh *RegistrationHelper) registerRelationships(pkg *PackagingUnit) {
+	model := pkg.Model
+	hostname := model.Registrant.Kind
+	var registeredRelationships []relationship.RelationshipDefinition
 	// 3. Register relationships
 	for _, rel := range pkg.Relationships {
 		rel.Model = model
+		if !rh.validateRelationship(rel, hostname, model.DisplayName) {
+			continue
+		}
 		_, _, err := rh.regManager.RegisterEntity(model.Registrant, &rel)
 		if err != nil {
 			err = ErrRegisterEntity(err, string(rel.Type()), string(rel.Kind))
@@ -134,13 +245,10 @@ func (rh *RegistrationHelper) register(pkg PackagingUnit) {
 		} else {
 			// Successful registration, add to successfulRelationships
 			registeredRelationships = append(registeredRelationships, rel)
+			rh.recordFingerprint(&rel, hostname, model.DisplayName)
 		}
 	}
 
-	// Update pkg with only successfully registered components and relationships
-	pkg.Components = registeredComponents
+	// Update pkg with only successfully registered relationships
 	pkg.Relationships = registeredRelationships
-	pkg.Model = model
-	// Store the successfully registered PackagingUnit
-	rh.PkgUnits = append(rh.PkgUnits, pkg)
 }
@@ -0,0 +1,145 @@
+package registration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/layer5io/meshkit/database"
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	meshmodel "github.com/layer5io/meshkit/models/meshmodel/registry"
+	"github.com/meshery/schemas/models/v1alpha3"
+	"github.com/meshery/schemas/models/v1alpha3/relationship"
+	"github.com/meshery/schemas/models/v1beta1"
+	"github.com/meshery/schemas/models/v1beta1/category"
+	"github.com/meshery/schemas/models/v1beta1/component"
+	"github.com/meshery/schemas/models/v1beta1/connection"
+	"github.com/meshery/schemas/models/v1beta1/model"
+	"gorm.io/gorm/logger"
+)
+
+// packagingUnit is a RegisterableEntity which is already parsed.
+type packagingUnit PackagingUnit
+
+func (p packagingUnit) PkgUnit(RegistrationErrorStore) (PackagingUnit, error) {
+	return PackagingUnit(p), nil
+}
+
+func newTestRegistrationHelper(t *testing.T, policy meshmodel.RelationshipValidationPolicy) (*RegistrationHelper, *MemoryRegistrationErrorStore) {
+	t.Helper()
+	h, err := database.New(database.Options{Engine: database.SQLITE, Filename: filepath.Join(t.TempDir(), "registry.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Logger = logger.Discard
+	rm, err := meshmodel.NewRegistryManager(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryRegistrationErrorStore()
+	rh := NewRegistrationHelper(filepath.Join(t.TempDir(), "svg"), rm, store)
+	rh.RelationshipValidation = policy
+	return &rh, store
+}
+
+// testPackagingUnit returns a model with a component of the given kind,
+// and a relationship from the component to the components of the given kind and model.
+func testPackagingUnit(name, kind, toModel, toKind string) packagingUnit {
+	m := model.ModelDefinition{
+		SchemaVersion: v1beta1.ModelSchemaVersion,
+		Name:          name,
+		DisplayName:   name,
+		Status:        "enabled",
+		Category:      category.CategoryDefinition{Name: "Orchestration"},
+		Model:         model.Model{Version: "1.0.0"},
+		Registrant:    connection.Connection{Kind: "github"},
+	}
+	pu := packagingUnit{
+		Model: m,
+		Components: []component.ComponentDefinition{{
+			SchemaVersion: v1beta1.ComponentSchemaVersion,
+			DisplayName:   kind,
+			Component:     component.Component{Kind: kind, Version: "v1", Schema: `{"type": "object"}`},
+		}},
+	}
+	if toKind != "" {
+		from, to := kind, toKind
+		pu.Relationships = []relationship.RelationshipDefinition{{
+			SchemaVersion:    v1alpha3.RelationshipSchemaVersion,
+			Kind:             "edge",
+			RelationshipType: "non-binding",
+			SubType:          "network",
+			Selectors: &relationship.SelectorSet{{Allow: relationship.Selector{
+				From: []relationship.SelectorItem{{Kind: &from, Model: &model.ModelDefinition{Name: name}}},
+				To:   []relationship.SelectorItem{{Kind: &to, Model: &model.ModelDefinition{Name: toModel}}},
+			}}},
+		}}
+	}
+	return pu
+}
+
+func TestRegisterRelationshipValidation(t *testing.T) {
+	// the relationship of kubernetes references a component of istio, which is registered after kubernetes
+	kubernetes := testPackagingUnit("kubernetes", "Service", "istio", "VirtualService")
+	istio := testPackagingUnit("istio", "VirtualService", "", "")
+	dangling := testPackagingUnit("kubernetes", "Service", "istio", "Gateway")
+
+	tests := []struct {
+		name              string
+		policy            meshmodel.RelationshipValidationPolicy
+		register          func(rh *RegistrationHelper)
+		wantRelationships int
+		wantErrors        int64
+	}{
+		{"report", meshmodel.RelationshipValidationReport, func(rh *RegistrationHelper) { rh.Register(kubernetes); rh.Register(istio) }, 1, 1},
+		{"reject", meshmodel.RelationshipValidationReject, func(rh *RegistrationHelper) { rh.Register(kubernetes); rh.Register(istio) }, 0, 1},
+		{"skip", meshmodel.RelationshipValidationSkip, func(rh *RegistrationHelper) { rh.Register(kubernetes); rh.Register(istio) }, 1, 0},
+		{"reject referenced model registered before", meshmodel.RelationshipValidationReject, func(rh *RegistrationHelper) { rh.Register(istio); rh.Register(kubernetes) }, 1, 0},
+		{"report in the same import", meshmodel.RelationshipValidationReport, func(rh *RegistrationHelper) { rh.RegisterAll(kubernetes, istio) }, 1, 0},
+		{"reject in the same import", meshmodel.RelationshipValidationReject, func(rh *RegistrationHelper) { rh.RegisterAll(kubernetes, istio) }, 1, 0},
+		{"report dangling in the same import", meshmodel.RelationshipValidationReport, func(rh *RegistrationHelper) { rh.RegisterAll(dangling, istio) }, 1, 1},
+		{"reject dangling in the same import", meshmodel.RelationshipValidationReject, func(rh *RegistrationHelper) { rh.RegisterAll(dangling, istio) }, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rh, store := newTestRegistrationHelper(t, tt.policy)
+			tt.register(rh)
+			if len(rh.PkgUnits) != 2 {
+				t.Fatalf("%d packaging units registered, want 2", len(rh.PkgUnits))
+			}
+			relationships := 0
+			for _, pu := range rh.PkgUnits {
+				if len(pu.Components) != 1 {
+					t.Errorf("%s registered %d components, want 1", pu.Model.Name, len(pu.Components))
+				}
+				relationships += len(pu.Relationships)
+			}
+			if relationships != tt.wantRelationships {
+				t.Errorf("%d relationships registered, want %d", relationships, tt.wantRelationships)
+			}
+			if _, n, _ := store.Errors(RegistrationErrorFilter{EntityType: entity.RelationshipDefinition}); n != tt.wantErrors {
+				t.Errorf("%d relationship errors recorded, want %d", n, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestRegisterSVGErrorKeys(t *testing.T) {
+	const unsafe = `<svg onload="alert(1)"><path d="M0 0"/></svg>`
+	rh, store := newTestRegistrationHelper(t, meshmodel.RelationshipValidationReport)
+	pu := testPackagingUnit("kubernetes", "Pod", "", "")
+	pu.Model.DisplayName = "Kubernetes"
+	pu.Model.Metadata = &model.ModelDefinition_Metadata{SvgColor: unsafe}
+	pu.Components[0].Styles = &component.Styles{SvgColor: unsafe}
+	rh.Register(pu)
+
+	// errors of models are recorded as the other errors of models, those of components under the display name of their model
+	for en, modelName := range map[entity.EntityType]string{entity.Model: "", entity.ComponentDefinition: "Kubernetes"} {
+		errs, _, err := store.Errors(RegistrationErrorFilter{EntityType: en})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(errs) != 1 || errs[0].ModelName != modelName {
+			t.Errorf("%s errors = %+v, want one recorded for model %q", en, errs, modelName)
+		}
+	}
+}
@@ -1,130 +1,42 @@
 package registration
 
 import (
-	"crypto/md5"
-	"encoding/hex"
-	"fmt"
-	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 )
 
-var hashCheckSVG = make(map[string]string)
-var mx sync.Mutex
+// Deprecated: icons are no longer written to a directory per model, use the references returned by the AssetStore.
 var UISVGPaths = make([]string, 1)
 
-func writeHashCheckSVG(key string, val string) {
-	mx.Lock()
-	hashCheckSVG[key] = val
-	mx.Unlock()
-}
-
-func WriteAndReplaceSVGWithFileSystemPath(svgColor, svgWhite, svgComplete string, baseDir, dirname, filename string) (svgColorPath, svgWhitePath, svgCompletePath string) {
-	filename = strings.ToLower(filename)
-	successCreatingDirectory := false
-	defer func() {
-		if successCreatingDirectory {
-			UISVGPaths = append(UISVGPaths, filepath.Join(baseDir, dirname))
-		}
-	}()
-	if svgColor != "" {
-		path := filepath.Join(baseDir, dirname, "color")
-		err := os.MkdirAll(path, 0777)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		successCreatingDirectory = true
+var uiSVGPathsMx sync.Mutex
 
-		hash := md5.Sum([]byte(svgColor))
-		hashString := hex.EncodeToString(hash[:])
-		pathsvg := hashCheckSVG[hashString]
-		if pathsvg != "" { // the image has already been loaded, point the component to that path
-			svgColorPath = pathsvg
-			goto White
-		}
-		f, err := os.Create(filepath.Join(path, filename+"-color.svg"))
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		_, err = f.WriteString(svgColor)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		svgColorPath = getRelativePathForAPI(baseDir, filepath.Join(dirname, "color", filename+"-color.svg")) //Replace the actual SVG with path to SVG
-		writeHashCheckSVG(hashString, svgColorPath)
-
-	}
-White:
-	if svgWhite != "" {
-		path := filepath.Join(baseDir, dirname, "white")
-		err := os.MkdirAll(path, 0777)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		successCreatingDirectory = true
+/*
+WriteAndReplaceSVGWithFileSystemPath writes the SVGs to baseDir and returns their paths, SVGs which cannot be written are replaced by an empty path.
 
-		hash := md5.Sum([]byte(svgWhite))
-		hashString := hex.EncodeToString(hash[:])
-		pathsvg := hashCheckSVG[hashString]
-		if pathsvg != "" { // the image has already been loaded, point the component to that path
-			svgWhitePath = pathsvg
-			goto Complete
-		}
-		f, err := os.Create(filepath.Join(path, filename+"-white.svg"))
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		_, err = f.WriteString(svgWhite)
-		if err != nil {
-			fmt.Println(err)
-			return
+Deprecated: use FilesystemAssetStore, or the AssetStore of the RegistrationHelper, which reports errors.
+*/
+func WriteAndReplaceSVGWithFileSystemPath(svgColor, svgWhite, svgComplete string, baseDir, dirname, filename string) (svgColorPath, svgWhitePath, svgCompletePath string) {
+	store := NewFilesystemAssetStore(baseDir)
+	put := func(svg string) string {
+		if svg == "" {
+			return ""
 		}
-		svgWhitePath = getRelativePathForAPI(baseDir, filepath.Join(dirname, "white", filename+"-white.svg")) //Replace the actual SVG with path to SVG
-		writeHashCheckSVG(hashString, svgWhitePath)
-
+		ref, _ := store.Put(svg)
+		return ref
 	}
-Complete:
-	if svgComplete != "" {
-		path := filepath.Join(baseDir, dirname, "complete")
-		err := os.MkdirAll(path, 0777)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		successCreatingDirectory = true
-
-		hash := md5.Sum([]byte(svgComplete))
-		hashString := hex.EncodeToString(hash[:])
-		pathsvg := hashCheckSVG[hashString]
-		if pathsvg != "" { // the image has already been loaded, point the component to that path
-			svgCompletePath = pathsvg
-			return
-		}
-		f, err := os.Create(filepath.Join(path, filename+"-complete.svg"))
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		_, err = f.WriteString(svgComplete)
-		if err != nil {
-			fmt.Println(err)
-			return
+	svgColorPath, svgWhitePath, svgCompletePath = put(svgColor), put(svgWhite), put(svgComplete)
+	if svgColorPath != "" || svgWhitePath != "" || svgCompletePath != "" {
+		uiSVGPathsMx.Lock()
+		if !slices.Contains(UISVGPaths, baseDir) {
+			UISVGPaths = append(UISVGPaths, baseDir)
 		}
-		svgCompletePath = getRelativePathForAPI(baseDir, filepath.Join(dirname, "complete", filename+"-complete.svg")) //Replace the actual SVG with path to SVG
-		writeHashCheckSVG(hashString, svgCompletePath)
-
+		uiSVGPathsMx.Unlock()
 	}
 	return
 }
 
-// func WriteAndReplaceSVGWithFileSystemPath(metadata map[string]interface{}, baseDir, dirname, filename string) {
-
 func getRelativePathForAPI(baseDir, path string) string {
 	ui := strings.TrimPrefix(baseDir, "../../")
 	return filepath.Join(ui, path)
@@ -1,10 +1,279 @@
 package registration
 
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/layer5io/meshkit/models/meshmodel/entity"
+	"github.com/layer5io/meshkit/utils"
+)
+
+const (
+	// DefaultMaxArchiveSize is the maximum total size of the files extracted from an archive.
+	DefaultMaxArchiveSize int64 = 1 << 30
+	// DefaultMaxArchiveEntries is the maximum number of entries in an archive.
+	DefaultMaxArchiveEntries = 10000
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+/*
+Tar is a model package archived as a .tar, .tar.gz or .zip file.
+The archive should contain one and only one `model`, the same as a Dir.
+*/
 type Tar struct {
-	_ string
+	path   string
+	reader io.Reader
+	name   string
+	// MaxSize limits the total size of the extracted files, DefaultMaxArchiveSize is used when zero.
+	MaxSize int64
+	// MaxEntries limits the number of entries in the archive, DefaultMaxArchiveEntries is used when zero.
+	MaxEntries int
 }
 
-func (t Tar) PkgUnit(regErrStore RegistrationErrorStore) (PackagingUnit, error) {
+func NewTar(path string) Tar {
+	return Tar{path: path, name: filepath.Base(path)}
+}
+
+/*
+NewTarFromReader reads the archive from r, name is used to report errors.
+*/
+func NewTarFromReader(r io.Reader, name string) Tar {
+	return Tar{reader: r, name: name}
+}
+
+/*
+PkgUnit extracts the archive to a temporary directory and finds the meshery definitions inside it, the same way as Dir.
+Entries with absolute paths or paths escaping the archive root, and archives exceeding the size limits, are rejected,
+the same applies to the archives nested in the archive.
+Symbolic and hard links are skipped.
+*/
+func (t Tar) PkgUnit(regErrStore RegistrationErrorStore) (_ PackagingUnit, err error) {
 	pkg := PackagingUnit{}
+
+	tempDir, err := os.MkdirTemp("", "model-archive-")
+	if err != nil {
+		err = ErrTarPkgUnitParseFail(t.name, utils.ErrCreateDir(err, tempDir))
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), t.name, err)
+		return pkg, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	limits := t.limits()
+	err = t.extract(tempDir, limits)
+	if err != nil {
+		err = ErrTarPkgUnitParseFail(t.name, err)
+		regErrStore.InsertEntityRegError("", "", entity.EntityType("unknown"), t.name, err)
+		return pkg, err
+	}
+
+	// nested archives are extracted within the limits of the archive
+	err = processDir(tempDir, &pkg, regErrStore, limits)
+	if err != nil {
+		modelName := ""
+		if !reflect.ValueOf(pkg.Model).IsZero() {
+			modelName = pkg.Model.Name
+		}
+		err = ErrTarPkgUnitParseFail(t.name, fmt.Errorf("could not process the archive: %w", err))
+		regErrStore.InsertEntityRegError("", modelName, entity.EntityType("unknown"), t.name, err)
+		return pkg, err
+	}
+
+	if reflect.ValueOf(pkg.Model).IsZero() {
+		regErrStore.InsertEntityRegError("", "", entity.Model, t.name, errMissingModel)
+		return pkg, errMissingModel
+	}
+
 	return pkg, nil
 }
+
+func (t Tar) open() (io.ReadCloser, error) {
+	if t.reader != nil {
+		return io.NopCloser(t.reader), nil
+	}
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, utils.ErrOpenFile(t.path)
+	}
+	return f, nil
+}
+
+// extract writes the contents of the archive to dest, the format is detected from the content.
+func (t Tar) extract(dest string, limits *archiveLimits) error {
+	r, err := t.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(zipMagic))
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return extractTar(gz, dest, limits)
+	case bytes.HasPrefix(header, zipMagic):
+		return extractZip(br, dest, limits)
+	default:
+		return extractTar(br, dest, limits)
+	}
+}
+
+// archiveLimits tracks the remaining budget while extracting an archive.
+type archiveLimits struct {
+	size    int64
+	entries int
+}
+
+func (t Tar) limits() *archiveLimits {
+	l := &archiveLimits{size: t.MaxSize, entries: t.MaxEntries}
+	if l.size <= 0 {
+		l.size = DefaultMaxArchiveSize
+	}
+	if l.entries <= 0 {
+		l.entries = DefaultMaxArchiveEntries
+	}
+	return l
+}
+
+func (l *archiveLimits) entry() error {
+	l.entries--
+	if l.entries < 0 {
+		return fmt.Errorf("archive contains too many entries")
+	}
+	return nil
+}
+
+func extractTar(r io.Reader, dest string, limits *archiveLimits) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := limits.entry(); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			target, err := archivePath(dest, header.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return utils.ErrCreateDir(err, target)
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(dest, header.Name, tr, limits); err != nil {
+				return err
+			}
+		default:
+			// links and special files are never part of a model package
+			continue
+		}
+	}
+}
+
+func extractZip(r io.Reader, dest string, limits *archiveLimits) error {
+	// zip archives are read from the end, spool the stream to a file to get random access
+	spool, err := os.CreateTemp("", "model-archive-*.zip")
+	if err != nil {
+		return utils.ErrCreateFile(err, os.TempDir())
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	n, err := io.Copy(spool, io.LimitReader(r, limits.size+1))
+	if err != nil {
+		return utils.ErrWriteFile(err, spool.Name())
+	}
+	if n > limits.size {
+		return fmt.Errorf("archive exceeds the size limit of %d bytes", limits.size)
+	}
+
+	zr, err := zip.NewReader(spool, n)
+	if err != nil {
+		return utils.ErrExtractZip(err, dest)
+	}
+	for _, file := range zr.File {
+		if err := limits.entry(); err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			target, err := archivePath(dest, file.Name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return utils.ErrCreateDir(err, target)
+			}
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		fr, err := file.Open()
+		if err != nil {
+			return utils.ErrExtractZip(err, dest)
+		}
+		err = writeArchiveFile(dest, file.Name, fr, limits)
+		fr.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeArchiveFile(dest, name string, r io.Reader, limits *archiveLimits) error {
+	target, err := archivePath(dest, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return utils.ErrCreateDir(err, filepath.Dir(target))
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return utils.ErrCreateFile(err, target)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(r, limits.size+1))
+	if err != nil {
+		return utils.ErrWriteFile(err, target)
+	}
+	limits.size -= n
+	if limits.size < 0 {
+		return fmt.Errorf("archive exceeds the size limit")
+	}
+	return nil
+}
+
+// archivePath returns the path an archive entry is extracted to, entries which would be written outside of dest are rejected.
+func archivePath(dest, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("archive entry %q is outside of the archive root", name)
+	}
+	return filepath.Join(dest, name), nil
+}
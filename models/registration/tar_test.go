@@ -0,0 +1,223 @@
+package registration
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layer5io/meshkit/models/oci"
+	"github.com/meshery/schemas/models/v1beta1"
+)
+
+type archiveEntry struct {
+	name     string
+	body     string
+	typeflag byte
+	linkname string
+}
+
+var testModelDefinition = fmt.Sprintf(`{"schemaVersion": %q, "name": "test-model", "displayName": "Test Model", "version": "v1.0.0", "model": {"version": "1.0.0"}, "category": {"name": "Orchestration"}}`, v1beta1.ModelSchemaVersion)
+
+func testComponentDefinition(kind, description string) string {
+	return fmt.Sprintf(`{"schemaVersion": %q, "displayName": %q, "description": %q, "component": {"kind": %q, "version": "v1", "schema": "{}"}, "model": {"name": "test-model"}}`,
+		v1beta1.ComponentSchemaVersion, kind, description, kind)
+}
+
+func tarGz(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{Name: e.name, Typeflag: typeflag, Linkname: e.linkname, Mode: 0644}
+		if typeflag == tar.TypeReg {
+			header.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// ociArtifact returns the tarball of an image built by oci.BuildImage from the given files.
+func ociArtifact(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	img, err := oci.BuildImage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "artifact.tar")
+	if err := oci.SaveOCIArtifact(img, path, "test-model"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeArchive(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTarPkgUnit(t *testing.T) {
+	model := archiveEntry{name: "model.json", body: testModelDefinition}
+	pod := archiveEntry{name: "components/pod.json", body: testComponentDefinition("Pod", "")}
+	// the repeated description compresses well, the archive is small but its content is large
+	large := archiveEntry{name: "components/large.json", body: testComponentDefinition("Large", strings.Repeat("a", 1<<20))}
+
+	tests := []struct {
+		name           string
+		archive        []byte
+		maxSize        int64
+		maxEntries     int
+		wantErr        bool
+		wantComponents int
+		// wantRecorded is the number of errors recorded by the error store for a package which is registered
+		wantRecorded int
+	}{
+		{"definitions", tarGz(t, model, pod), 0, 0, false, 1, 0},
+		{"zip", zipArchive(t, model, pod), 0, 0, false, 1, 0},
+		{"parent directory entry", tarGz(t, model, archiveEntry{name: "../escaped.json", body: "{}"}), 0, 0, true, 0, 0},
+		{"nested parent directory entry", tarGz(t, model, archiveEntry{name: "components/../../escaped.json", body: "{}"}), 0, 0, true, 0, 0},
+		{"absolute entry", tarGz(t, model, archiveEntry{name: "/tmp/escaped.json", body: "{}"}), 0, 0, true, 0, 0},
+		{"zip parent directory entry", zipArchive(t, model, archiveEntry{name: "../escaped.json", body: "{}"}), 0, 0, true, 0, 0},
+		{"symbolic link", tarGz(t, model, archiveEntry{name: "components/link.json", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}), 0, 0, false, 0, 0},
+		{"hard link", tarGz(t, model, pod, archiveEntry{name: "components/link.json", typeflag: tar.TypeLink, linkname: "components/pod.json"}), 0, 0, false, 1, 0},
+		{"entries limit", tarGz(t, model, pod, archiveEntry{name: "README.md", body: "model"}), 0, 2, true, 0, 0},
+		{"size limit", tarGz(t, model, large), 1 << 16, 0, true, 0, 0},
+		{"zip size limit", zipArchive(t, model, large), 1 << 16, 0, true, 0, 0},
+		{
+			"nested archive",
+			tarGz(t, model, archiveEntry{name: "components.tar.gz", body: string(tarGz(t, pod))}),
+			0, 0, false, 1, 0,
+		},
+		{
+			"nested archive exceeding the size limit",
+			tarGz(t, model, archiveEntry{name: "components.tar.gz", body: string(tarGz(t, pod, large))}),
+			1 << 16, 0, false, 0, 2,
+		},
+		{
+			"nested archive exceeding the entries limit",
+			tarGz(t, model, archiveEntry{name: "components.zip", body: string(zipArchive(t, pod, pod))}),
+			0, 3, false, 0, 2,
+		},
+		{
+			"nested archive with a parent directory entry",
+			tarGz(t, model, archiveEntry{name: "components.tar.gz", body: string(tarGz(t, archiveEntry{name: "../escaped.json", body: "{}"}))}),
+			0, 0, false, 0, 2,
+		},
+		{
+			"nested OCI artifact",
+			tarGz(t, model, archiveEntry{name: "artifact.tar", body: string(ociArtifact(t, map[string]string{"pod.json": pod.body}))}),
+			0, 0, false, 1, 0,
+		},
+		{
+			"nested OCI artifact exceeding the size limit",
+			tarGz(t, model, archiveEntry{name: "artifact.tar", body: string(ociArtifact(t, map[string]string{"large.json": large.body}))}),
+			1 << 16, 0, false, 0, 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outside := filepath.Join(os.TempDir(), "escaped.json")
+			os.Remove(outside)
+
+			archive := NewTar(writeArchive(t, "model.tar.gz", tt.archive))
+			archive.MaxSize = tt.maxSize
+			archive.MaxEntries = tt.maxEntries
+			store := NewMemoryRegistrationErrorStore()
+			pkg, err := archive.PkgUnit(store)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PkgUnit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat(outside); err == nil {
+				os.Remove(outside)
+				t.Error("an entry has been extracted outside of the archive root")
+			}
+			if tt.wantErr {
+				return
+			}
+			if pkg.Model.Name != "test-model" || len(pkg.Components) != tt.wantComponents {
+				t.Errorf("PkgUnit() = model %q and %d components, want test-model and %d", pkg.Model.Name, len(pkg.Components), tt.wantComponents)
+			}
+			if _, n, _ := store.Errors(RegistrationErrorFilter{}); n != int64(tt.wantRecorded) {
+				t.Errorf("%d errors recorded, want %d", n, tt.wantRecorded)
+			}
+		})
+	}
+}
+
+func TestArchivePath(t *testing.T) {
+	dest := t.TempDir()
+	for name, wantErr := range map[string]bool{
+		"model.json":            false,
+		"components/pod.json":   false,
+		"components/../a.json":  false,
+		"../a.json":             true,
+		"components/../../a":    true,
+		"/etc/passwd":           true,
+		"":                      true,
+		"components/../../../b": true,
+	} {
+		got, err := archivePath(dest, name)
+		if (err != nil) != wantErr {
+			t.Errorf("archivePath(%q) error = %v, wantErr %v", name, err, wantErr)
+			continue
+		}
+		if err == nil && !strings.HasPrefix(got, dest+string(filepath.Separator)) {
+			t.Errorf("archivePath(%q) = %s, outside of %s", name, got, dest)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/encoding/json"
 	"cuelang.org/go/encoding/jsonschema"
 	"cuelang.org/go/encoding/yaml"
@@ -25,8 +26,7 @@ func Validate(schema cue.Value, value cue.Value) (bool, []errors.Error) {
 	schema.Walk(func(v cue.Value) bool {
 		val := value.LookupPath(v.Path())
 		if !(val.Err() == nil && val.IsConcrete()) {
-			cueErr := errors.Errors(errors.New(fmt.Sprintf("%v is a required field", v.Path().String())))
-			errs = append(errs, cueErr...)
+			errs = append(errs, &requiredFieldError{path: v.Path()})
 		}
 		return true
 	}, nil)
@@ -37,6 +37,29 @@ func Validate(schema cue.Value, value cue.Value) (bool, []errors.Error) {
 	return true, make([]errors.Error, 0)
 }
 
+// requiredFieldError reports a field required by the schema which is missing from the value, keeping the path of the field.
+type requiredFieldError struct {
+	path cue.Path
+}
+
+func (e *requiredFieldError) Position() token.Pos         { return token.NoPos }
+func (e *requiredFieldError) InputPositions() []token.Pos { return nil }
+func (e *requiredFieldError) Error() string {
+	return fmt.Sprintf("%v is a required field", e.path.String())
+}
+
+func (e *requiredFieldError) Msg() (string, []interface{}) {
+	return "%v is a required field", []interface{}{e.path.String()}
+}
+
+func (e *requiredFieldError) Path() []string {
+	path := []string{}
+	for _, sel := range e.path.Selectors() {
+		path = append(path, sel.String())
+	}
+	return path
+}
+
 func GetNonConcreteFields(val cue.Value) []string {
 	res := make([]string, 0)
 	val.Walk(func(v cue.Value) bool {
@@ -50,6 +50,7 @@ var (
 	ErrConvertToByteCode        = "meshkit-11187"
 
 	ErrOpenFileCode             = "replace_me"
+	ErrSanitizeSVGCode          = "replace_me"
 
 )
 var (
@@ -242,3 +243,7 @@ func ErrCloseFile(err error) error {
 func ErrOpenFile(file string) error {
 	return errors.New(ErrOpenFileCode, errors.Alert, []string{"unable to open file: ", file}, []string{}, []string{"The file does not exist in the location"}, []string{"Make sure to upload the correct file"})
 }
+
+func ErrSanitizeSVG(err error) error {
+	return errors.New(ErrSanitizeSVGCode, errors.Alert, []string{"unable to sanitize the SVG"}, []string{err.Error()}, []string{"The SVG is not well-formed XML"}, []string{"Make sure the SVG is valid"})
+}
@@ -3,9 +3,11 @@ package utils
 import (
 	"bytes"
 	"encoding/xml"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 const XMLTAG = "<?xml version=\"1.0\" encoding=\"UTF-8\"?><!DOCTYPE svg>"
@@ -41,7 +43,6 @@ func UpdateSVGString(svgStr string, width, height int, skipHeader bool) (string,
 				// Set the width and height attributes to the desired values.
 				updatedH := false
 				updatedW := false
-				xmlnsindex := -1
 				for i, a := range se.Attr {
 					if a.Name.Local == "width" {
 						se.Attr[i].Value = strconv.Itoa(width)
@@ -50,9 +51,6 @@ func UpdateSVGString(svgStr string, width, height int, skipHeader bool) (string,
 						se.Attr[i].Value = strconv.Itoa(height)
 						updatedH = true
 					}
-					if a.Name.Local == "xmlns" {
-						xmlnsindex = i
-					}
 				}
 				if !updatedH {
 					se.Attr = append(se.Attr, xml.Attr{
@@ -70,22 +68,8 @@ func UpdateSVGString(svgStr string, width, height int, skipHeader bool) (string,
 						Value: strconv.Itoa(width),
 					})
 				}
-				if xmlnsindex > -1 {
-					se.Attr = append(se.Attr[0:xmlnsindex], se.Attr[xmlnsindex+1:]...)
-				}
-			} else {
-				for i, a := range se.Attr {
-					xmlnsindex := -1
-					nahbro := 0
-					if a.Name.Local == "xmlns" {
-						xmlnsindex = i
-						nahbro++
-					}
-					if xmlnsindex > -1 {
-						se.Attr = append(se.Attr[0:xmlnsindex], se.Attr[xmlnsindex+1:]...)
-					}
-				}
 			}
+			se.Attr = withoutNamespaceDeclarations(se.Attr)
 			t = se
 		}
 		// Write the modified token to the buffer.
@@ -106,3 +90,225 @@ func UpdateSVGString(svgStr string, width, height int, skipHeader bool) (string,
 	}
 	return svg, nil
 }
+
+/*
+withoutNamespaceDeclarations removes the xmlns and xmlns:* attributes.
+The decoder resolves the namespaces of elements and attributes and the encoder declares them again,
+encoding the declarations as well would declare them twice and turn xmlns:* into attributes of an "xmlns" namespace.
+*/
+func withoutNamespaceDeclarations(attrs []xml.Attr) []xml.Attr {
+	result := make([]xml.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+// DefaultMaxSVGImageSize is the size limit of data URIs embedded in SVGs, e.g. base64 encoded PNGs.
+const DefaultMaxSVGImageSize = 256 * 1024
+
+// unsafeSVGElements are removed from SVGs together with their content.
+var unsafeSVGElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+}
+
+// safeDataURIPrefixes are the data URIs allowed as references, SVG data URIs are excluded as they can contain scripts.
+var safeDataURIPrefixes = []string{"data:image/png", "data:image/jpeg", "data:image/jpg", "data:image/gif", "data:image/webp"}
+
+/*
+SanitizeSVG removes the content of the SVG which could run scripts or load external resources when the SVG is rendered:
+scripts, foreignObject and embedding elements, event handler attributes, javascript: URLs, animations of links and event handlers,
+references which are neither fragments nor data URIs of raster images, data URIs larger than maxImageSize and unsafe CSS.
+Comments, processing instructions and DOCTYPEs are removed as well. Documents which are not a single <svg> element,
+e.g. HTML or data URIs, are rejected.
+It returns the sanitized SVG and a description of every removed element and attribute.
+*/
+func SanitizeSVG(svg string, maxImageSize int) (string, []string, error) {
+	d := xml.NewDecoder(strings.NewReader(svg))
+	var b bytes.Buffer
+	e := xml.NewEncoder(&b)
+
+	removed := []string{}
+	// skip is the depth within a removed element, depth the depth within the kept ones
+	skip, depth, roots := 0, 0, 0
+	// the text of a style element is split by comments and CDATA sections, it is checked as a whole once the element ends
+	inStyle := false
+	var style strings.Builder
+	flushStyle := func() error {
+		if !inStyle {
+			return nil
+		}
+		inStyle = false
+		css := style.String()
+		style.Reset()
+		if unsafeCSS(css) {
+			removed = append(removed, "style sheet")
+			return nil
+		}
+		if css == "" {
+			return nil
+		}
+		return e.EncodeToken(xml.CharData(css))
+	}
+	for {
+		t, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", nil, ErrSanitizeSVG(err)
+		}
+
+		if skip > 0 {
+			switch t.(type) {
+			case xml.StartElement:
+				skip++
+			case xml.EndElement:
+				skip--
+			}
+			continue
+		}
+
+		switch tok := t.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				// HTML parsers ignore the case of the name, only a single <svg> root is treated as SVG by both
+				roots++
+				if roots > 1 || tok.Name.Local != "svg" {
+					return "", nil, ErrSanitizeSVG(fmt.Errorf("the root element of an SVG must be a single <svg>, found <%s>", tok.Name.Local))
+				}
+			}
+			if err := flushStyle(); err != nil {
+				return "", nil, ErrSanitizeSVG(err)
+			}
+			name := strings.ToLower(tok.Name.Local)
+			if unsafeSVGElements[name] || ((name == "set" || name == "animate") && animatesUnsafeAttribute(tok)) {
+				removed = append(removed, fmt.Sprintf("element <%s>", tok.Name.Local))
+				skip = 1
+				continue
+			}
+			tok.Attr = withoutNamespaceDeclarations(sanitizeSVGAttributes(tok, maxImageSize, &removed))
+			inStyle = name == "style"
+			depth++
+			t = tok
+		case xml.EndElement:
+			if err := flushStyle(); err != nil {
+				return "", nil, ErrSanitizeSVG(err)
+			}
+			depth--
+		case xml.CharData:
+			if depth == 0 {
+				if strings.TrimSpace(string(tok)) != "" {
+					return "", nil, ErrSanitizeSVG(fmt.Errorf("text outside of the <svg> element"))
+				}
+				continue
+			}
+			if inStyle {
+				style.Write(tok)
+				continue
+			}
+		case xml.ProcInst, xml.Directive, xml.Comment:
+			continue
+		}
+
+		if err := e.EncodeToken(t); err != nil {
+			return "", nil, ErrSanitizeSVG(err)
+		}
+	}
+	if roots == 0 {
+		return "", nil, ErrSanitizeSVG(fmt.Errorf("no <svg> element"))
+	}
+	if err := e.Flush(); err != nil {
+		return "", nil, ErrSanitizeSVG(err)
+	}
+	return b.String(), removed, nil
+}
+
+func sanitizeSVGAttributes(el xml.StartElement, maxImageSize int, removed *[]string) []xml.Attr {
+	attrs := make([]xml.Attr, 0, len(el.Attr))
+	for _, a := range el.Attr {
+		name := strings.ToLower(a.Name.Local)
+		value := normalizeSVGAttributeValue(a.Value)
+		reason := ""
+		switch {
+		case strings.HasPrefix(name, "on"):
+			reason = "event handler"
+		case strings.Contains(value, "javascript:") || strings.Contains(value, "vbscript:"):
+			reason = "script URL"
+		case name == "href" || name == "src":
+			reason = unsafeReference(value, maxImageSize)
+		case name == "style" && unsafeCSS(value):
+			reason = "unsafe style"
+		}
+		if reason != "" {
+			*removed = append(*removed, fmt.Sprintf("attribute %s of <%s> (%s)", a.Name.Local, el.Name.Local, reason))
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// normalizeSVGAttributeValue lower cases the value and removes whitespace and control characters, which browsers ignore in URLs.
+func normalizeSVGAttributeValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
+
+// unsafeReference returns why the normalized reference is not allowed, or an empty string if it is.
+func unsafeReference(value string, maxImageSize int) string {
+	if strings.HasPrefix(value, "#") {
+		return ""
+	}
+	for _, prefix := range safeDataURIPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			if len(value) > maxImageSize {
+				return fmt.Sprintf("embedded image larger than %d bytes", maxImageSize)
+			}
+			return ""
+		}
+	}
+	return "external reference"
+}
+
+func animatesUnsafeAttribute(el xml.StartElement) bool {
+	for _, a := range el.Attr {
+		if a.Name.Local != "attributeName" {
+			continue
+		}
+		name := normalizeSVGAttributeValue(a.Value)
+		return name == "href" || name == "xlink:href" || strings.HasPrefix(name, "on")
+	}
+	return false
+}
+
+// unsafeCSS reports whether the CSS can run scripts or load resources other than fragments of the SVG.
+func unsafeCSS(css string) bool {
+	css = normalizeSVGAttributeValue(css)
+	for _, pattern := range []string{"javascript:", "vbscript:", "expression(", "@import", "behavior:", "-moz-binding"} {
+		if strings.Contains(css, pattern) {
+			return true
+		}
+	}
+	for rest := css; ; {
+		i := strings.Index(rest, "url(")
+		if i < 0 {
+			return false
+		}
+		rest = strings.TrimLeft(rest[i+len("url("):], `"'`)
+		if !strings.HasPrefix(rest, "#") {
+			return true
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	largeImage := "data:image/png;base64," + strings.Repeat("A", 64)
+	var tests = []struct {
+		name        string
+		svg         string
+		maxImage    int
+		wantRemoved int
+		mustNotHave []string
+		mustHave    []string
+		wantErr     bool
+	}{
+		{"safe svg", `<svg viewBox="0 0 10 10"><use href="#a"/><path id="a" d="M0 0"/></svg>`, 1024, 0, nil, []string{`href="#a"`, `d="M0 0"`}, false},
+		{"script", `<svg><script>alert(1)</script><path d="M0 0"/></svg>`, 1024, 1, []string{"script", "alert"}, []string{"path"}, false},
+		{"event handler", `<svg onload="alert(1)"><rect onclick="alert(2)" width="1"/></svg>`, 1024, 2, []string{"onload", "onclick"}, []string{`width="1"`}, false},
+		{"foreignObject", `<svg><foreignObject><iframe src="https://example.com"/></foreignObject></svg>`, 1024, 1, []string{"foreignObject", "iframe"}, nil, false},
+		{"javascript url", `<svg><a href=" java&#x09;script:alert(1)"><text>x</text></a></svg>`, 1024, 1, []string{"script:"}, []string{"text"}, false},
+		{"external href", `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href="https://example.com/x.png"/></svg>`, 1024, 1, []string{"example.com"}, nil, false},
+		{"small embedded image", `<svg><image href="` + largeImage + `"/></svg>`, 1024, 0, nil, []string{largeImage}, false},
+		{"oversized embedded image", `<svg><image href="` + largeImage + `"/></svg>`, 16, 1, []string{largeImage}, nil, false},
+		{"svg data uri", `<svg><image href="data:image/svg+xml;base64,PHN2Zy8+"/></svg>`, 1024, 1, []string{"svg+xml"}, nil, false},
+		{"animated href", `<svg><a><set attributeName="href" to="https://example.com"/></a></svg>`, 1024, 1, []string{"set", "example.com"}, nil, false},
+		{"unsafe css", `<svg><style>@import url(https://example.com/x.css);</style><rect style="fill:url(https://example.com/x)"/><rect style="fill:url(#g)"/></svg>`, 1024, 2, []string{"example.com"}, []string{"url(#g)"}, false},
+		{"namespaces", `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"/><path id="a" d="M0 0"/></svg>`, 1024, 0, []string{"_xmlns"}, []string{`xmlns="http://www.w3.org/2000/svg"`, `xlink:href="#a"`}, false},
+		{"namespaced external href", `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href="https://example.com/x.png"/></svg>`, 1024, 1, []string{"example.com", "_xmlns"}, nil, false},
+		{"css split by a comment", `<svg><style>@im<!-- -->port "https://example.com/x.css";</style></svg>`, 1024, 1, []string{"example.com", "port"}, nil, false},
+		{"css split by cdata sections", `<svg><style><![CDATA[@im]]><![CDATA[port "https://example.com/x.css";]]></style></svg>`, 1024, 1, []string{"example.com", "port"}, nil, false},
+		{"safe css split by a comment", `<svg><style>rect { fill:<!-- -->red }</style><rect/></svg>`, 1024, 0, nil, []string{"<style>rect { fill:red }</style>"}, false},
+		{"malformed", `<svg><g></svg>`, 1024, 0, nil, nil, true},
+		{"upper case root", `<SVG onload="alert(1)"></SVG>`, 1024, 0, nil, nil, true},
+		{"html", `<div><svg></svg><img src="x" onerror="alert(1)"></div>`, 1024, 0, nil, nil, true},
+		{"data uri", `data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9ImFsZXJ0KDEpIi8+`, 1024, 0, nil, nil, true},
+		{"several roots", `<svg></svg><svg onload="alert(1)"></svg>`, 1024, 0, nil, nil, true},
+		{"text after the root", `<svg></svg><img src="x" onerror="alert(1)">`, 1024, 0, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed, err := SanitizeSVG(tt.svg, tt.maxImage)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SanitizeSVG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(removed) != tt.wantRemoved {
+				t.Errorf("SanitizeSVG() removed %v, want %d removals", removed, tt.wantRemoved)
+			}
+			for _, s := range tt.mustNotHave {
+				if strings.Contains(got, s) {
+					t.Errorf("SanitizeSVG() = %s, must not contain %q", got, s)
+				}
+			}
+			for _, s := range tt.mustHave {
+				if !strings.Contains(got, s) {
+					t.Errorf("SanitizeSVG() = %s, must contain %q", got, s)
+				}
+			}
+			checkWellFormedXML(t, got)
+
+			// icons are sized after they are sanitized
+			sized, err := UpdateSVGString(got, 64, 64, true)
+			if err != nil {
+				t.Fatalf("UpdateSVGString() error = %v", err)
+			}
+			checkWellFormedXML(t, sized)
+		})
+	}
+}
+
+// checkWellFormedXML parses the XML, failing on duplicate attributes and undeclared namespace prefixes as well.
+func checkWellFormedXML(t *testing.T, s string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(s))
+	// declared holds the prefixes declared by the open elements
+	declared := []map[string]bool{}
+	isDeclared := func(prefix string) bool {
+		for _, prefixes := range declared {
+			if prefixes[prefix] {
+				return true
+			}
+		}
+		return prefix == "" || prefix == "xml" || prefix == "xmlns"
+	}
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%s is not well-formed: %v", s, err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			prefixes := map[string]bool{}
+			seen := map[xml.Name]bool{}
+			for _, a := range tok.Attr {
+				if seen[a.Name] {
+					t.Fatalf("%s has the attribute %s:%s twice", s, a.Name.Space, a.Name.Local)
+				}
+				seen[a.Name] = true
+				if a.Name.Space == "xmlns" {
+					prefixes[a.Name.Local] = true
+				}
+			}
+			declared = append(declared, prefixes)
+			if !isDeclared(tok.Name.Space) {
+				t.Fatalf("%s uses the undeclared prefix %s", s, tok.Name.Space)
+			}
+			for _, a := range tok.Attr {
+				if !isDeclared(a.Name.Space) {
+					t.Fatalf("%s uses the undeclared prefix %s", s, a.Name.Space)
+				}
+			}
+		case xml.EndElement:
+			declared = declared[:len(declared)-1]
+		}
+	}
+	// the raw tokens are not checked for matching end elements
+	d = xml.NewDecoder(strings.NewReader(s))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%s is not well-formed: %v", s, err)
+		}
+	}
+}